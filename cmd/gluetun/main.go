@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -228,6 +229,20 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 		return err
 	}
 
+	var preVPNPublicIP netip.Addr
+	if *allSettings.Health.IPLeak.PreVPNIP {
+		// Fetch the public IP address before the firewall blocks
+		// traffic outside the VPN.
+		const preVPNTimeout = 10 * time.Second
+		preVPNClient := &http.Client{Timeout: preVPNTimeout}
+		preVPNPublicIP, err = publicip.FetchPreVPNIP(ctx, allSettings.PublicIP, preVPNClient,
+			logger.New(log.SetComponent("ip getter")))
+		if err != nil {
+			logger.Warn("fetching pre-VPN public IP address: " + err.Error() +
+				", the IP leak healthcheck will not compare with it")
+		}
+	}
+
 	if *allSettings.Firewall.Enabled {
 		err = firewallConf.SetEnabled(ctx, true)
 		if err != nil {
@@ -419,18 +434,38 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	unzipper := unzip.New(httpClient)
 	parallelResolver := resolver.NewParallelResolver(allSettings.Updater.DNSAddress)
 	openvpnFileExtractor := extract.New()
-	providers := provider.NewProviders(storage, time.Now, updaterLogger,
+	vpnLogger := logger.New(log.SetComponent("vpn"))
+	connectionBreaker := vpn.NewConnectionBreaker(storage, vpnLogger)
+	connectionSelector := vpn.NewConnectionSelector(connectionBreaker, firewallConf, vpnLogger)
+	providers := provider.NewProviders(connectionSelector, connectionSelector, time.Now, updaterLogger,
 		httpClient, unzipper, parallelResolver, publicIPLooper.Fetcher(), openvpnFileExtractor)
 
-	vpnLogger := logger.New(log.SetComponent("vpn"))
+	httpProxyLooper := httpproxy.NewLoop(
+		logger.New(log.SetComponent("http proxy")),
+		allSettings.HTTPProxy)
+	httpProxyHandler, httpProxyCtx, httpProxyDone := goshutdown.NewGoRoutineHandler(
+		"http proxy", goroutine.OptionTimeout(defaultShutdownTimeout))
+	go httpProxyLooper.Run(httpProxyCtx, httpProxyDone)
+	otherGroupHandler.Add(httpProxyHandler)
+
 	vpnLooper := vpn.NewLoop(allSettings.VPN, ipv6Supported, allSettings.Firewall.VPNInputPorts,
-		providers, storage, ovpnConf, netLinker, firewallConf, routingConf, portForwardLooper,
-		cmder, publicIPLooper, dnsLooper, vpnLogger, httpClient,
+		providers, storage, connectionBreaker, connectionSelector, ovpnConf, netLinker, firewallConf, routingConf, portForwardLooper,
+		cmder, publicIPLooper, dnsLooper, httpProxyLooper, vpnLogger, httpClient,
 		buildInfo, *allSettings.Version.Enabled)
 	vpnHandler, vpnCtx, vpnDone := goshutdown.NewGoRoutineHandler(
 		"vpn", goroutine.OptionTimeout(time.Second))
 	go vpnLooper.Run(vpnCtx, vpnDone)
 
+	vpnRotationHandler, vpnRotationCtx, vpnRotationDone := goshutdown.NewGoRoutineHandler(
+		"vpn rotation", goroutine.OptionTimeout(defaultShutdownTimeout))
+	go vpnLooper.RunRotation(vpnRotationCtx, vpnRotationDone)
+	controlGroupHandler.Add(vpnRotationHandler)
+
+	vpnFailoverHandler, vpnFailoverCtx, vpnFailoverDone := goshutdown.NewGoRoutineHandler(
+		"vpn failover", goroutine.OptionTimeout(defaultShutdownTimeout))
+	go vpnLooper.RunFailoverRetry(vpnFailoverCtx, vpnFailoverDone)
+	controlGroupHandler.Add(vpnFailoverHandler)
+
 	updaterLooper := updater.NewLoop(allSettings.Updater,
 		providers, storage, httpClient, updaterLogger)
 	updaterHandler, updaterCtx, updaterDone := goshutdown.NewGoRoutineHandler(
@@ -444,13 +479,10 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	go updaterLooper.RunRestartTicker(updaterTickerCtx, updaterTickerDone)
 	controlGroupHandler.Add(updaterTickerHandler)
 
-	httpProxyLooper := httpproxy.NewLoop(
-		logger.New(log.SetComponent("http proxy")),
-		allSettings.HTTPProxy)
-	httpProxyHandler, httpProxyCtx, httpProxyDone := goshutdown.NewGoRoutineHandler(
-		"http proxy", goroutine.OptionTimeout(defaultShutdownTimeout))
-	go httpProxyLooper.Run(httpProxyCtx, httpProxyDone)
-	otherGroupHandler.Add(httpProxyHandler)
+	loadRefresherHandler, loadRefresherCtx, loadRefresherDone := goshutdown.NewGoRoutineHandler(
+		"server load refresher", goroutine.OptionTimeout(defaultShutdownTimeout))
+	go updaterLooper.RunLoadRefresher(loadRefresherCtx, loadRefresherDone)
+	controlGroupHandler.Add(loadRefresherHandler)
 
 	shadowsocksLooper := shadowsocks.NewLoop(allSettings.Shadowsocks,
 		logger.New(log.SetComponent("shadowsocks")))
@@ -477,7 +509,8 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	controlGroupHandler.Add(httpServerHandler)
 
 	healthLogger := logger.New(log.SetComponent("healthcheck"))
-	healthcheckServer := healthcheck.NewServer(allSettings.Health, healthLogger, vpnLooper)
+	healthcheckServer := healthcheck.NewServer(allSettings.Health, healthLogger, vpnLooper,
+		publicIPLooper.Fetcher(), preVPNPublicIP)
 	healthServerHandler, healthServerCtx, healthServerDone := goshutdown.NewGoRoutineHandler(
 		"HTTP health server", goroutine.OptionTimeout(defaultShutdownTimeout))
 	go healthcheckServer.Run(healthServerCtx, healthServerDone)
@@ -580,6 +613,7 @@ type Linker interface {
 	LinkDel(link netlink.Link) (err error)
 	LinkSetUp(link netlink.Link) (linkIndex int, err error)
 	LinkSetDown(link netlink.Link) (err error)
+	LinkStatistics(name string) (statistics netlink.LinkStatistics, err error)
 }
 
 type clier interface {
@@ -75,10 +75,10 @@ func (c *CLI) OpenvpnConfig(logger OpenvpnConfigLogger, reader *reader.Reader,
 	ipFetcher := (IPFetcher)(nil)
 	openvpnFileExtractor := extract.New()
 
-	providers := provider.NewProviders(storage, time.Now, warner, client,
+	providers := provider.NewProviders(storage, nil, time.Now, warner, client,
 		unzipper, parallelResolver, ipFetcher, openvpnFileExtractor)
 	providerConf := providers.Get(allSettings.VPN.Provider.Name)
-	connection, err := providerConf.GetConnection(
+	connection, err := providerConf.GetConnection(context.Background(),
 		allSettings.VPN.Provider.ServerSelection, ipv6Supported)
 	if err != nil {
 		return err
@@ -93,7 +93,7 @@ func (c *CLI) Update(ctx context.Context, args []string, logger UpdaterLogger) e
 
 	openvpnFileExtractor := extract.New()
 
-	providers := provider.NewProviders(storage, time.Now, logger, httpClient,
+	providers := provider.NewProviders(storage, nil, time.Now, logger, httpClient,
 		unzipper, parallelResolver, ipFetcher, openvpnFileExtractor)
 
 	updater := updater.New(httpClient, storage, providers, logger)
@@ -0,0 +1,13 @@
+package command
+
+import "os/exec"
+
+type Starter interface {
+	Start(cmd *exec.Cmd) (stdoutLines, stderrLines <-chan string,
+		waitError <-chan error, startErr error)
+}
+
+type Logger interface {
+	Info(s string)
+	Error(s string)
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// RunAndStream splits and runs the command string given, logging its
+// stdout lines at the info level and its stderr lines at the error level.
+// It blocks until the command exits or the context is canceled.
+func RunAndStream(ctx context.Context, starter Starter, logger Logger,
+	commandString string,
+) (err error) {
+	args, err := Split(commandString)
+	if err != nil {
+		return fmt.Errorf("parsing command: %w", err)
+	}
+	return RunArgsAndStream(ctx, starter, logger, args)
+}
+
+// RunArgsAndStream is like RunAndStream but runs the command
+// from the arguments given, the first one being the program.
+func RunArgsAndStream(ctx context.Context, starter Starter, logger Logger,
+	args []string,
+) (err error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204
+	stdout, stderr, waitError, err := starter.Start(cmd)
+	if err != nil {
+		return err
+	}
+
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	streamDone := make(chan struct{})
+	go streamLines(streamCtx, streamDone, logger, stdout, stderr)
+
+	err = <-waitError
+	streamCancel()
+	<-streamDone
+	return err
+}
+
+func streamLines(ctx context.Context, done chan<- struct{},
+	logger Logger, stdout, stderr <-chan string,
+) {
+	defer close(done)
+
+	var line string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line = <-stdout:
+			logger.Info(line)
+		case line = <-stderr:
+			logger.Error(line)
+		}
+	}
+}
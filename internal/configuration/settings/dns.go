@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"errors"
 	"fmt"
 	"net/netip"
 
@@ -39,24 +40,112 @@ type DNS struct {
 	// BypassResolver is the DNS server to use for bypass domains.
 	// If not set, it will be auto-detected from the original resolv.conf.
 	BypassResolver netip.Addr
+	// BypassSearchDomains is true if the search domains found in the
+	// original resolv.conf should be added to the bypass domains.
+	// This is useful in Kubernetes to bypass all the cluster domains.
+	// It defaults to false and cannot be nil in the internal state.
+	BypassSearchDomains *bool
+	// ListeningAddresses are the IP addresses the internal DNS server
+	// listens on. It defaults to the empty slice to listen on all
+	// the IPv4 and IPv6 addresses.
+	ListeningAddresses []netip.Addr
+	// ListeningPort is the port the internal DNS server listens on.
+	// It defaults to 53 and cannot be nil in the internal state.
+	ListeningPort *uint16
+	// AllowedClientSubnets are the subnets of clients allowed to
+	// query the internal DNS server, other clients being answered
+	// with REFUSED. Loopback clients are always allowed.
+	// It defaults to the empty slice to allow all clients.
+	AllowedClientSubnets []netip.Prefix
+	// Encrypted contains settings to serve DNS over TLS and
+	// DNS over HTTPS on the listening addresses.
+	Encrypted DNSEncryptedServer
+	// LeakTest contains settings to configure the DNS leak
+	// test run each time the VPN tunnel is up.
+	LeakTest DNSLeakTest
 }
 
+var (
+	ErrDNSListeningPortZero        = errors.New("DNS listening port cannot be zero")
+	ErrDNSServerAddressNotListened = errors.New("DNS server address is not a listening address")
+	ErrDNSListeningPortNotSystem   = errors.New("DNS listening port cannot be used system wide")
+)
+
 func (d DNS) validate() (err error) {
+	if *d.ListeningPort == 0 {
+		return fmt.Errorf("%w", ErrDNSListeningPortZero)
+	}
+
+	if !*d.KeepNameserver && d.ServerAddress.IsLoopback() {
+		if !d.listensOn(d.ServerAddress) {
+			return fmt.Errorf("%w: %s is not in %v",
+				ErrDNSServerAddressNotListened, d.ServerAddress, d.ListeningAddresses)
+		}
+
+		// /etc/resolv.conf nameserver entries cannot specify a port,
+		// so the system and the containers sharing the network would
+		// lose DNS resolution with a port other than 53.
+		const systemDNSPort = 53
+		if *d.ListeningPort != systemDNSPort {
+			return fmt.Errorf("%w: port %d is not %d, "+
+				"which is required for the system to use the internal DNS server",
+				ErrDNSListeningPortNotSystem, *d.ListeningPort, systemDNSPort)
+		}
+	}
+
 	err = d.DoT.validate()
 	if err != nil {
 		return fmt.Errorf("validating DoT settings: %w", err)
 	}
 
+	err = d.Encrypted.validate()
+	if err != nil {
+		return fmt.Errorf("validating encrypted DNS server settings: %w", err)
+	}
+
+	err = d.LeakTest.validate()
+	if err != nil {
+		return fmt.Errorf("validating DNS leak test settings: %w", err)
+	}
+
+	if (*d.Encrypted.DoTEnabled && *d.Encrypted.DoTPort == *d.ListeningPort) ||
+		(*d.Encrypted.DoHEnabled && *d.Encrypted.DoHPort == *d.ListeningPort) {
+		return fmt.Errorf("%w: encrypted DNS server port is the listening port %d",
+			ErrDNSEncryptedPortsConflict, *d.ListeningPort)
+	}
+
 	return nil
 }
 
+// listensOn returns true if the internal DNS server listens
+// on the given IP address.
+func (d DNS) listensOn(ip netip.Addr) bool {
+	if len(d.ListeningAddresses) == 0 {
+		return true
+	}
+	ip = ip.Unmap()
+	for _, address := range d.ListeningAddresses {
+		address = address.Unmap()
+		if address == ip || (address.IsUnspecified() && address.Is4() == ip.Is4()) {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *DNS) Copy() (copied DNS) {
 	return DNS{
-		ServerAddress:  d.ServerAddress,
-		KeepNameserver: gosettings.CopyPointer(d.KeepNameserver),
-		DoT:            d.DoT.copy(),
-		BypassDomains:  gosettings.CopySlice(d.BypassDomains),
-		BypassResolver: d.BypassResolver,
+		ServerAddress:        d.ServerAddress,
+		KeepNameserver:       gosettings.CopyPointer(d.KeepNameserver),
+		DoT:                  d.DoT.copy(),
+		BypassDomains:        gosettings.CopySlice(d.BypassDomains),
+		BypassResolver:       d.BypassResolver,
+		BypassSearchDomains:  gosettings.CopyPointer(d.BypassSearchDomains),
+		ListeningAddresses:   gosettings.CopySlice(d.ListeningAddresses),
+		ListeningPort:        gosettings.CopyPointer(d.ListeningPort),
+		AllowedClientSubnets: gosettings.CopySlice(d.AllowedClientSubnets),
+		Encrypted:            d.Encrypted.copy(),
+		LeakTest:             d.LeakTest.copy(),
 	}
 }
 
@@ -69,6 +158,12 @@ func (d *DNS) overrideWith(other DNS) {
 	d.DoT.overrideWith(other.DoT)
 	d.BypassDomains = gosettings.OverrideWithSlice(d.BypassDomains, other.BypassDomains)
 	d.BypassResolver = gosettings.OverrideWithValidator(d.BypassResolver, other.BypassResolver)
+	d.BypassSearchDomains = gosettings.OverrideWithPointer(d.BypassSearchDomains, other.BypassSearchDomains)
+	d.ListeningAddresses = gosettings.OverrideWithSlice(d.ListeningAddresses, other.ListeningAddresses)
+	d.ListeningPort = gosettings.OverrideWithPointer(d.ListeningPort, other.ListeningPort)
+	d.AllowedClientSubnets = gosettings.OverrideWithSlice(d.AllowedClientSubnets, other.AllowedClientSubnets)
+	d.Encrypted.overrideWith(other.Encrypted)
+	d.LeakTest.overrideWith(other.LeakTest)
 }
 
 func (d *DNS) setDefaults() {
@@ -77,6 +172,11 @@ func (d *DNS) setDefaults() {
 	d.KeepNameserver = gosettings.DefaultPointer(d.KeepNameserver, false)
 	d.DoT.setDefaults()
 	// BypassDomains and BypassResolver are optional and set at runtime if needed
+	d.BypassSearchDomains = gosettings.DefaultPointer(d.BypassSearchDomains, false)
+	const defaultListeningPort = 53
+	d.ListeningPort = gosettings.DefaultPointer(d.ListeningPort, defaultListeningPort)
+	d.Encrypted.setDefaults()
+	d.LeakTest.setDefaults()
 }
 
 func (d DNS) String() string {
@@ -91,13 +191,30 @@ func (d DNS) toLinesNode() (node *gotree.Node) {
 	}
 	node.Appendf("DNS server address to use: %s", d.ServerAddress)
 
-	if len(d.BypassDomains) > 0 {
-		node.Appendf("Bypass domains: %v", d.BypassDomains)
+	if len(d.ListeningAddresses) > 0 || *d.ListeningPort != 53 || len(d.AllowedClientSubnets) > 0 {
+		listeningAddresses := "all"
+		if len(d.ListeningAddresses) > 0 {
+			listeningAddresses = fmt.Sprint(d.ListeningAddresses)
+		}
+		node.Appendf("Listening addresses: %s", listeningAddresses)
+		node.Appendf("Listening port: %d", *d.ListeningPort)
+		if len(d.AllowedClientSubnets) > 0 {
+			node.Appendf("Allowed client subnets: %v", d.AllowedClientSubnets)
+		}
+	}
+
+	if len(d.BypassDomains) > 0 || *d.BypassSearchDomains {
+		if len(d.BypassDomains) > 0 {
+			node.Appendf("Bypass domains: %v", d.BypassDomains)
+		}
+		node.Appendf("Bypass search domains: %s", gosettings.BoolToYesNo(d.BypassSearchDomains))
 		if d.BypassResolver.IsValid() {
 			node.Appendf("Bypass resolver: %s", d.BypassResolver)
 		}
 	}
 
+	node.AppendNode(d.Encrypted.toLinesNode())
+	node.AppendNode(d.LeakTest.toLinesNode())
 	node.AppendNode(d.DoT.toLinesNode())
 	return node
 }
@@ -120,6 +237,36 @@ func (d *DNS) read(r *reader.Reader) (err error) {
 		return err
 	}
 
+	d.BypassSearchDomains, err = r.BoolPtr("DNS_BYPASS_SEARCH_DOMAINS")
+	if err != nil {
+		return err
+	}
+
+	d.ListeningAddresses, err = r.CSVNetipAddresses("DNS_LISTENING_ADDRESSES")
+	if err != nil {
+		return err
+	}
+
+	d.ListeningPort, err = r.Uint16Ptr("DNS_LISTENING_PORT")
+	if err != nil {
+		return err
+	}
+
+	d.AllowedClientSubnets, err = r.CSVNetipPrefixes("DNS_ALLOWED_CLIENT_SUBNETS")
+	if err != nil {
+		return err
+	}
+
+	err = d.Encrypted.read(r)
+	if err != nil {
+		return fmt.Errorf("encrypted DNS server settings: %w", err)
+	}
+
+	err = d.LeakTest.read(r)
+	if err != nil {
+		return fmt.Errorf("DNS leak test settings: %w", err)
+	}
+
 	err = d.DoT.read(r)
 	if err != nil {
 		return fmt.Errorf("DNS over TLS settings: %w", err)
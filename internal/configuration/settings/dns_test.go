@@ -0,0 +1,70 @@
+package settings
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DNS_listensOn(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		listeningAddresses []netip.Addr
+		ip                 netip.Addr
+		listens            bool
+	}{
+		"all_addresses": {
+			ip:      netip.MustParseAddr("127.0.0.1"),
+			listens: true,
+		},
+		"same_address": {
+			listeningAddresses: []netip.Addr{
+				netip.MustParseAddr("192.168.1.2"),
+				netip.MustParseAddr("127.0.0.1"),
+			},
+			ip:      netip.MustParseAddr("127.0.0.1"),
+			listens: true,
+		},
+		"unspecified_same_family": {
+			listeningAddresses: []netip.Addr{netip.IPv4Unspecified()},
+			ip:                 netip.MustParseAddr("127.0.0.1"),
+			listens:            true,
+		},
+		"unspecified_other_family": {
+			listeningAddresses: []netip.Addr{netip.IPv6Unspecified()},
+			ip:                 netip.MustParseAddr("127.0.0.1"),
+		},
+		"other_address": {
+			listeningAddresses: []netip.Addr{netip.MustParseAddr("192.168.1.2")},
+			ip:                 netip.MustParseAddr("127.0.0.1"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := DNS{ListeningAddresses: testCase.listeningAddresses}
+			assert.Equal(t, testCase.listens, settings.listensOn(testCase.ip))
+		})
+	}
+}
+
+func Test_DNS_validate_listeningPort(t *testing.T) {
+	t.Parallel()
+
+	var settings DNS
+	settings.setDefaults()
+	port := uint16(5353)
+	settings.ListeningPort = &port
+
+	err := settings.validate()
+	assert.ErrorIs(t, err, ErrDNSListeningPortNotSystem)
+
+	keepNameserver := true
+	settings.KeepNameserver = &keepNameserver
+	err = settings.validate()
+	assert.NoError(t, err)
+}
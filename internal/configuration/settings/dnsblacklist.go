@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"net/netip"
+	"net/url"
+	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/qdm12/dns/v2/pkg/blockbuilder"
 	"github.com/qdm12/gosettings"
@@ -15,19 +18,39 @@ import (
 
 // DNSBlacklist is settings for the DNS blacklist building.
 type DNSBlacklist struct {
-	BlockMalicious       *bool
-	BlockAds             *bool
-	BlockSurveillance    *bool
-	AllowedHosts         []string
+	BlockMalicious    *bool
+	BlockAds          *bool
+	BlockSurveillance *bool
+	// AllowedHosts are hosts rules to allow, taking precedence over
+	// blocked hosts. Each rule can be an exact host such as `example.com`,
+	// a wildcard such as `*.example.com` matching its subdomains, or
+	// a regular expression such as `/^ads[0-9]+\.example\.com$/`.
+	AllowedHosts []string
+	// AddBlockedHosts are hosts rules to block, in the same format
+	// as AllowedHosts.
 	AddBlockedHosts      []string
 	AddBlockedIPs        []netip.Addr
 	AddBlockedIPPrefixes []netip.Prefix
+	// CustomBlockLists are URLs or local file paths of block
+	// lists in the hosts, plain domain or AdBlock formats.
+	CustomBlockLists []string
+	// CacheFilepath is the file path to persist the last block lists
+	// built successfully to, in order to use them at startup without
+	// waiting for the block lists to be downloaded.
+	// It can be set to the empty string to disable it, and cannot be
+	// nil in the internal state.
+	CacheFilepath *string
+	// Rebinding contains settings to configure the
+	// DNS rebinding protection.
+	Rebinding DNSRebinding
 }
 
 func (b *DNSBlacklist) setDefaults() {
 	b.BlockMalicious = gosettings.DefaultPointer(b.BlockMalicious, true)
 	b.BlockAds = gosettings.DefaultPointer(b.BlockAds, false)
 	b.BlockSurveillance = gosettings.DefaultPointer(b.BlockSurveillance, true)
+	b.CacheFilepath = gosettings.DefaultPointer(b.CacheFilepath, "/gluetun/dns/blocklists.json")
+	b.Rebinding.setDefaults()
 }
 
 var hostRegex = regexp.MustCompile(`^([a-zA-Z0-9]|[a-zA-Z0-9_][a-zA-Z0-9\-_]{0,61}[a-zA-Z0-9_])(\.([a-zA-Z0-9]|[a-zA-Z0-9_][a-zA-Z0-9\-_]{0,61}[a-zA-Z0-9]))*$`) //nolint:lll
@@ -35,21 +58,92 @@ var hostRegex = regexp.MustCompile(`^([a-zA-Z0-9]|[a-zA-Z0-9_][a-zA-Z0-9\-_]{0,6
 var (
 	ErrAllowedHostNotValid = errors.New("allowed host is not valid")
 	ErrBlockedHostNotValid = errors.New("blocked host is not valid")
+	ErrBlockListNotValid   = errors.New("block list is not a valid URL or file path")
 )
 
 func (b DNSBlacklist) validate() (err error) {
 	for _, host := range b.AllowedHosts {
-		if !hostRegex.MatchString(host) {
-			return fmt.Errorf("%w: %s", ErrAllowedHostNotValid, host)
+		err = validateHostRule(host)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrAllowedHostNotValid, err)
 		}
 	}
 
 	for _, host := range b.AddBlockedHosts {
-		if !hostRegex.MatchString(host) {
-			return fmt.Errorf("%w: %s", ErrBlockedHostNotValid, host)
+		err = validateHostRule(host)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrBlockedHostNotValid, err)
 		}
 	}
 
+	for _, blockList := range b.CustomBlockLists {
+		err = validateBlockList(blockList)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrBlockListNotValid, blockList, err)
+		}
+	}
+
+	if *b.CacheFilepath != "" && !filepath.IsAbs(*b.CacheFilepath) {
+		return fmt.Errorf("block lists cache %w: %s", errFilePathNotAbsolute, *b.CacheFilepath)
+	}
+
+	err = b.Rebinding.validate()
+	if err != nil {
+		return fmt.Errorf("rebinding protection settings: %w", err)
+	}
+
+	return nil
+}
+
+var (
+	errHostRuleNotValid = errors.New("host rule is not valid")
+	errHostRuleRegex    = errors.New("host rule regular expression is not valid")
+)
+
+func validateHostRule(rule string) (err error) {
+	if isRegexHostRule(rule) {
+		_, err = regexp.Compile(rule[1 : len(rule)-1])
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errHostRuleRegex, rule, err)
+		}
+		return nil
+	}
+
+	host := strings.TrimPrefix(rule, "*.")
+	if !hostRegex.MatchString(host) {
+		return fmt.Errorf("%w: %s", errHostRuleNotValid, rule)
+	}
+	return nil
+}
+
+func isRegexHostRule(rule string) bool {
+	return len(rule) > 2 && strings.HasPrefix(rule, "/") && strings.HasSuffix(rule, "/")
+}
+
+// exactHosts returns the hosts rules which are neither
+// wildcard nor regular expression rules.
+func exactHosts(rules []string) (hosts []string) {
+	for _, rule := range rules {
+		if isRegexHostRule(rule) || strings.HasPrefix(rule, "*.") {
+			continue
+		}
+		hosts = append(hosts, strings.ToLower(rule))
+	}
+	return hosts
+}
+
+var errFilePathNotAbsolute = errors.New("file path is not absolute")
+
+func validateBlockList(blockList string) (err error) {
+	if strings.HasPrefix(blockList, "http://") ||
+		strings.HasPrefix(blockList, "https://") {
+		_, err = url.ParseRequestURI(blockList)
+		return err
+	}
+
+	if !filepath.IsAbs(blockList) {
+		return errFilePathNotAbsolute
+	}
 	return nil
 }
 
@@ -62,6 +156,9 @@ func (b DNSBlacklist) copy() (copied DNSBlacklist) {
 		AddBlockedHosts:      gosettings.CopySlice(b.AddBlockedHosts),
 		AddBlockedIPs:        gosettings.CopySlice(b.AddBlockedIPs),
 		AddBlockedIPPrefixes: gosettings.CopySlice(b.AddBlockedIPPrefixes),
+		CustomBlockLists:     gosettings.CopySlice(b.CustomBlockLists),
+		CacheFilepath:        gosettings.CopyPointer(b.CacheFilepath),
+		Rebinding:            b.Rebinding.copy(),
 	}
 }
 
@@ -73,6 +170,9 @@ func (b *DNSBlacklist) overrideWith(other DNSBlacklist) {
 	b.AddBlockedHosts = gosettings.OverrideWithSlice(b.AddBlockedHosts, other.AddBlockedHosts)
 	b.AddBlockedIPs = gosettings.OverrideWithSlice(b.AddBlockedIPs, other.AddBlockedIPs)
 	b.AddBlockedIPPrefixes = gosettings.OverrideWithSlice(b.AddBlockedIPPrefixes, other.AddBlockedIPPrefixes)
+	b.CustomBlockLists = gosettings.OverrideWithSlice(b.CustomBlockLists, other.CustomBlockLists)
+	b.CacheFilepath = gosettings.OverrideWithPointer(b.CacheFilepath, other.CacheFilepath)
+	b.Rebinding.overrideWith(other.Rebinding)
 }
 
 func (b DNSBlacklist) ToBlockBuilderSettings(client *http.Client) (
@@ -83,8 +183,8 @@ func (b DNSBlacklist) ToBlockBuilderSettings(client *http.Client) (
 		BlockMalicious:       b.BlockMalicious,
 		BlockAds:             b.BlockAds,
 		BlockSurveillance:    b.BlockSurveillance,
-		AllowedHosts:         b.AllowedHosts,
-		AddBlockedHosts:      b.AddBlockedHosts,
+		AllowedHosts:         exactHosts(b.AllowedHosts),
+		AddBlockedHosts:      exactHosts(b.AddBlockedHosts),
 		AddBlockedIPs:        b.AddBlockedIPs,
 		AddBlockedIPPrefixes: b.AddBlockedIPPrefixes,
 	}
@@ -129,6 +229,21 @@ func (b DNSBlacklist) toLinesNode() (node *gotree.Node) {
 		}
 	}
 
+	if len(b.CustomBlockLists) > 0 {
+		customBlockListsNode := node.Append("Custom block lists:")
+		for _, blockList := range b.CustomBlockLists {
+			customBlockListsNode.Append(blockList)
+		}
+	}
+
+	cacheFilepath := *b.CacheFilepath
+	if cacheFilepath == "" {
+		cacheFilepath = "disabled"
+	}
+	node.Appendf("Block lists cache file: %s", cacheFilepath)
+
+	node.AppendNode(b.Rebinding.toLinesNode())
+
 	return node
 }
 
@@ -155,7 +270,19 @@ func (b *DNSBlacklist) read(r *reader.Reader) (err error) {
 		return err
 	}
 
-	b.AllowedHosts = r.CSV("UNBLOCK") // TODO v4 change name
+	// Do not lowercase to keep regular expression rules as they are.
+	b.AllowedHosts = r.CSV("UNBLOCK", // TODO v4 change name
+		reader.ForceLowercase(false))
+
+	b.CustomBlockLists = r.CSV("BLOCK_CUSTOM_LISTS", reader.ForceLowercase(false))
+
+	b.CacheFilepath = r.Get("BLOCK_LISTS_CACHE_FILEPATH",
+		reader.ForceLowercase(false), reader.AcceptEmpty(true))
+
+	err = b.Rebinding.read(r)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
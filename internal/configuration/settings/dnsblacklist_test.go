@@ -0,0 +1,63 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DNSBlacklist_validate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings   DNSBlacklist
+		errWrapped error
+		errMessage string
+	}{
+		"valid_rules": {
+			settings: DNSBlacklist{
+				AllowedHosts:    []string{"good.example.com", "*.cdn.example.com"},
+				AddBlockedHosts: []string{"*.tracker.example", `/^ads[0-9]+\./`},
+			},
+		},
+		"invalid_wildcard": {
+			settings: DNSBlacklist{
+				AllowedHosts: []string{"a.*.com"},
+			},
+			errWrapped: ErrAllowedHostNotValid,
+			errMessage: "allowed host is not valid: host rule is not valid: a.*.com",
+		},
+		"invalid_regex": {
+			settings: DNSBlacklist{
+				AddBlockedHosts: []string{"/(/"},
+			},
+			errWrapped: ErrBlockedHostNotValid,
+			errMessage: "blocked host is not valid: host rule regular expression " +
+				"is not valid: /(/: error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			testCase.settings.setDefaults()
+			err := testCase.settings.validate()
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func Test_exactHosts(t *testing.T) {
+	t.Parallel()
+
+	rules := []string{"Example.com", "*.tracker.example", `/^ads\./`}
+
+	hosts := exactHosts(rules)
+
+	assert.Equal(t, []string{"example.com"}, hosts)
+}
@@ -0,0 +1,186 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// DNSEncryptedServer contains settings to serve DNS over TLS
+// and DNS over HTTPS to clients, using the internal DNS server
+// listening addresses and middlewares.
+type DNSEncryptedServer struct {
+	// DoTEnabled is true if the internal DNS server should
+	// serve DNS over TLS. It defaults to false and cannot
+	// be nil in the internal state.
+	DoTEnabled *bool `json:"dot_enabled"`
+	// DoTPort is the DNS over TLS listening port.
+	// It defaults to 853 and cannot be nil in the internal state.
+	DoTPort *uint16 `json:"dot_port"`
+	// DoHEnabled is true if the internal DNS server should
+	// serve DNS over HTTPS. It defaults to false and cannot
+	// be nil in the internal state.
+	DoHEnabled *bool `json:"doh_enabled"`
+	// DoHPort is the DNS over HTTPS listening port.
+	// It defaults to 443 and cannot be nil in the internal state.
+	DoHPort *uint16 `json:"doh_port"`
+	// DoHPath is the HTTP path to serve DNS over HTTPS on.
+	// It defaults to /dns-query and cannot be nil in the internal state.
+	DoHPath *string `json:"doh_path"`
+	// CertificateFilepath is the path to the PEM encoded TLS certificate
+	// file. If it and the key file do not exist, a self-signed
+	// certificate and key are generated and written to these paths.
+	// It defaults to /gluetun/dns/server.crt and cannot be nil
+	// in the internal state.
+	CertificateFilepath *string `json:"certificate_filepath"`
+	// KeyFilepath is the path to the PEM encoded TLS private key file.
+	// It defaults to /gluetun/dns/server.key and cannot be nil
+	// in the internal state.
+	KeyFilepath *string `json:"key_filepath"`
+	// Hostnames are the hostnames to set in a generated
+	// self-signed certificate, in addition to the listening
+	// IP addresses.
+	Hostnames []string `json:"hostnames"`
+}
+
+var (
+	ErrDNSEncryptedPortZero       = errors.New("port cannot be zero")
+	ErrDNSEncryptedPortsConflict  = errors.New("DNS over TLS and DNS over HTTPS ports conflict")
+	ErrDoHPathNotValid            = errors.New("DNS over HTTPS path is not valid")
+	ErrDNSEncryptedFilepathNotSet = errors.New("filepath is not set")
+)
+
+func (d DNSEncryptedServer) validate() (err error) {
+	if !*d.DoTEnabled && !*d.DoHEnabled {
+		return nil
+	}
+
+	if *d.DoTEnabled && *d.DoTPort == 0 {
+		return fmt.Errorf("DNS over TLS: %w", ErrDNSEncryptedPortZero)
+	}
+
+	if *d.DoHEnabled {
+		if *d.DoHPort == 0 {
+			return fmt.Errorf("DNS over HTTPS: %w", ErrDNSEncryptedPortZero)
+		}
+		if !strings.HasPrefix(*d.DoHPath, "/") {
+			return fmt.Errorf("%w: %s must start with /", ErrDoHPathNotValid, *d.DoHPath)
+		}
+	}
+
+	if *d.DoTEnabled && *d.DoHEnabled && *d.DoTPort == *d.DoHPort {
+		return fmt.Errorf("%w: both are %d", ErrDNSEncryptedPortsConflict, *d.DoTPort)
+	}
+
+	for name, path := range map[string]string{
+		"certificate": *d.CertificateFilepath,
+		"key":         *d.KeyFilepath,
+	} {
+		if path == "" {
+			return fmt.Errorf("%s %w", name, ErrDNSEncryptedFilepathNotSet)
+		}
+		_, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("%s filepath is not valid: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (d DNSEncryptedServer) copy() (copied DNSEncryptedServer) {
+	return DNSEncryptedServer{
+		DoTEnabled:          gosettings.CopyPointer(d.DoTEnabled),
+		DoTPort:             gosettings.CopyPointer(d.DoTPort),
+		DoHEnabled:          gosettings.CopyPointer(d.DoHEnabled),
+		DoHPort:             gosettings.CopyPointer(d.DoHPort),
+		DoHPath:             gosettings.CopyPointer(d.DoHPath),
+		CertificateFilepath: gosettings.CopyPointer(d.CertificateFilepath),
+		KeyFilepath:         gosettings.CopyPointer(d.KeyFilepath),
+		Hostnames:           gosettings.CopySlice(d.Hostnames),
+	}
+}
+
+func (d *DNSEncryptedServer) overrideWith(other DNSEncryptedServer) {
+	d.DoTEnabled = gosettings.OverrideWithPointer(d.DoTEnabled, other.DoTEnabled)
+	d.DoTPort = gosettings.OverrideWithPointer(d.DoTPort, other.DoTPort)
+	d.DoHEnabled = gosettings.OverrideWithPointer(d.DoHEnabled, other.DoHEnabled)
+	d.DoHPort = gosettings.OverrideWithPointer(d.DoHPort, other.DoHPort)
+	d.DoHPath = gosettings.OverrideWithPointer(d.DoHPath, other.DoHPath)
+	d.CertificateFilepath = gosettings.OverrideWithPointer(d.CertificateFilepath, other.CertificateFilepath)
+	d.KeyFilepath = gosettings.OverrideWithPointer(d.KeyFilepath, other.KeyFilepath)
+	d.Hostnames = gosettings.OverrideWithSlice(d.Hostnames, other.Hostnames)
+}
+
+func (d *DNSEncryptedServer) setDefaults() {
+	d.DoTEnabled = gosettings.DefaultPointer(d.DoTEnabled, false)
+	const defaultDoTPort = 853
+	d.DoTPort = gosettings.DefaultPointer(d.DoTPort, defaultDoTPort)
+	d.DoHEnabled = gosettings.DefaultPointer(d.DoHEnabled, false)
+	const defaultDoHPort = 443
+	d.DoHPort = gosettings.DefaultPointer(d.DoHPort, defaultDoHPort)
+	d.DoHPath = gosettings.DefaultPointer(d.DoHPath, "/dns-query")
+	d.CertificateFilepath = gosettings.DefaultPointer(d.CertificateFilepath, "/gluetun/dns/server.crt")
+	d.KeyFilepath = gosettings.DefaultPointer(d.KeyFilepath, "/gluetun/dns/server.key")
+}
+
+func (d DNSEncryptedServer) String() string {
+	return d.toLinesNode().String()
+}
+
+func (d DNSEncryptedServer) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("Encrypted DNS server settings:")
+	if !*d.DoTEnabled && !*d.DoHEnabled {
+		node.Append("Enabled: no")
+		return node
+	}
+
+	if *d.DoTEnabled {
+		node.Appendf("DNS over TLS port: %d", *d.DoTPort)
+	}
+	if *d.DoHEnabled {
+		node.Appendf("DNS over HTTPS port and path: %d %s", *d.DoHPort, *d.DoHPath)
+	}
+	node.Appendf("Certificate file: %s", *d.CertificateFilepath)
+	node.Appendf("Key file: %s", *d.KeyFilepath)
+	if len(d.Hostnames) > 0 {
+		node.Appendf("Self-signed certificate hostnames: %s", strings.Join(d.Hostnames, ", "))
+	}
+
+	return node
+}
+
+func (d *DNSEncryptedServer) read(r *reader.Reader) (err error) {
+	d.DoTEnabled, err = r.BoolPtr("DNS_SERVER_DOT")
+	if err != nil {
+		return err
+	}
+
+	d.DoTPort, err = r.Uint16Ptr("DNS_SERVER_DOT_PORT")
+	if err != nil {
+		return err
+	}
+
+	d.DoHEnabled, err = r.BoolPtr("DNS_SERVER_DOH")
+	if err != nil {
+		return err
+	}
+
+	d.DoHPort, err = r.Uint16Ptr("DNS_SERVER_DOH_PORT")
+	if err != nil {
+		return err
+	}
+
+	d.DoHPath = r.Get("DNS_SERVER_DOH_PATH", reader.ForceLowercase(false))
+	d.CertificateFilepath = r.Get("DNS_SERVER_TLS_CERTIFICATE_FILEPATH",
+		reader.ForceLowercase(false))
+	d.KeyFilepath = r.Get("DNS_SERVER_TLS_KEY_FILEPATH", reader.ForceLowercase(false))
+	d.Hostnames = r.CSV("DNS_SERVER_TLS_HOSTNAMES")
+
+	return nil
+}
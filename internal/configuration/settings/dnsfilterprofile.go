@@ -0,0 +1,163 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// DNSFilterProfile is a named DNS filtering profile used
+// for queries from clients in its subnets.
+type DNSFilterProfile struct {
+	// Name is the profile name, made of lowercase letters,
+	// digits and underscores.
+	Name string `json:"name"`
+	// ClientSubnets are the subnets of clients using this profile.
+	// If a client is in the subnets of multiple profiles, the first
+	// profile is used.
+	ClientSubnets []netip.Prefix `json:"client_subnets"`
+	// Blacklist contains the filtering settings of the profile.
+	// Fields left unset default to the fields of the default
+	// DNS over TLS filtering settings.
+	Blacklist DNSBlacklist `json:"blacklist"`
+}
+
+var profileNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var (
+	ErrFilterProfileNameNotValid      = errors.New("filter profile name is not valid")
+	ErrFilterProfileNameDuplicate     = errors.New("filter profile name is duplicated")
+	ErrFilterProfileSubnetsNotSet     = errors.New("filter profile client subnets are not set")
+	ErrFilterProfileBlacklistNotValid = errors.New("filter profile settings are not valid")
+)
+
+func validateFilterProfiles(profiles []DNSFilterProfile) (err error) {
+	names := make(map[string]struct{}, len(profiles))
+	for _, profile := range profiles {
+		if !profileNameRegex.MatchString(profile.Name) {
+			return fmt.Errorf("%w: %q must match %s",
+				ErrFilterProfileNameNotValid, profile.Name, profileNameRegex)
+		}
+
+		if _, ok := names[profile.Name]; ok {
+			return fmt.Errorf("%w: %s", ErrFilterProfileNameDuplicate, profile.Name)
+		}
+		names[profile.Name] = struct{}{}
+
+		if len(profile.ClientSubnets) == 0 {
+			return fmt.Errorf("%w: for profile %s", ErrFilterProfileSubnetsNotSet, profile.Name)
+		}
+
+		err = profile.Blacklist.validate()
+		if err != nil {
+			return fmt.Errorf("%w: profile %s: %w",
+				ErrFilterProfileBlacklistNotValid, profile.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p DNSFilterProfile) copy() (copied DNSFilterProfile) {
+	return DNSFilterProfile{
+		Name:          p.Name,
+		ClientSubnets: gosettings.CopySlice(p.ClientSubnets),
+		Blacklist:     p.Blacklist.copy(),
+	}
+}
+
+func copyFilterProfiles(profiles []DNSFilterProfile) (copied []DNSFilterProfile) {
+	if profiles == nil {
+		return nil
+	}
+	copied = make([]DNSFilterProfile, len(profiles))
+	for i, profile := range profiles {
+		copied[i] = profile.copy()
+	}
+	return copied
+}
+
+// setDefaults sets unset fields of the profile filtering settings to
+// the fields of the default filtering settings given, and sets the
+// block lists cache file path from the default one.
+func (p *DNSFilterProfile) setDefaults(defaultBlacklist DNSBlacklist) {
+	blacklist := defaultBlacklist.copy()
+	blacklist.overrideWith(p.Blacklist)
+	cacheFilepath := ""
+	if *defaultBlacklist.CacheFilepath != "" {
+		extension := filepath.Ext(*defaultBlacklist.CacheFilepath)
+		cacheFilepath = strings.TrimSuffix(*defaultBlacklist.CacheFilepath, extension) +
+			"-" + p.Name + extension
+	}
+	blacklist.CacheFilepath = &cacheFilepath
+	p.Blacklist = blacklist
+}
+
+func (p DNSFilterProfile) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("Profile " + p.Name + ":")
+	node.Appendf("Client subnets: %v", p.ClientSubnets)
+	node.Appendf("Block malicious: %s", gosettings.BoolToYesNo(p.Blacklist.BlockMalicious))
+	node.Appendf("Block ads: %s", gosettings.BoolToYesNo(p.Blacklist.BlockAds))
+	node.Appendf("Block surveillance: %s", gosettings.BoolToYesNo(p.Blacklist.BlockSurveillance))
+	if len(p.Blacklist.AllowedHosts) > 0 {
+		node.Appendf("Allowed hosts: %s", strings.Join(p.Blacklist.AllowedHosts, ", "))
+	}
+	if len(p.Blacklist.CustomBlockLists) > 0 {
+		node.Appendf("Custom block lists: %s", strings.Join(p.Blacklist.CustomBlockLists, ", "))
+	}
+	return node
+}
+
+func readFilterProfiles(r *reader.Reader) (profiles []DNSFilterProfile, err error) {
+	names := r.CSV("DNS_FILTER_PROFILES")
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	profiles = make([]DNSFilterProfile, len(names))
+	for i, name := range names {
+		profiles[i].Name = name
+		err = profiles[i].read(r)
+		if err != nil {
+			return nil, fmt.Errorf("filter profile %s: %w", name, err)
+		}
+	}
+	return profiles, nil
+}
+
+func (p *DNSFilterProfile) read(r *reader.Reader) (err error) {
+	prefix := "DNS_FILTER_PROFILE_" + strings.ToUpper(p.Name) + "_"
+
+	p.ClientSubnets, err = r.CSVNetipPrefixes(prefix + "CLIENT_SUBNETS")
+	if err != nil {
+		return err
+	}
+
+	p.Blacklist.BlockMalicious, err = r.BoolPtr(prefix + "BLOCK_MALICIOUS")
+	if err != nil {
+		return err
+	}
+
+	p.Blacklist.BlockSurveillance, err = r.BoolPtr(prefix + "BLOCK_SURVEILLANCE")
+	if err != nil {
+		return err
+	}
+
+	p.Blacklist.BlockAds, err = r.BoolPtr(prefix + "BLOCK_ADS")
+	if err != nil {
+		return err
+	}
+
+	// Do not lowercase to keep regular expression rules as they are.
+	p.Blacklist.AllowedHosts = r.CSV(prefix+"UNBLOCK", reader.ForceLowercase(false))
+	p.Blacklist.CustomBlockLists = r.CSV(prefix+"BLOCK_CUSTOM_LISTS",
+		reader.ForceLowercase(false))
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package settings
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DNSFilterProfile_setDefaults(t *testing.T) {
+	t.Parallel()
+
+	defaultBlacklist := DNSBlacklist{
+		BlockAds:     ptrTo(false),
+		AllowedHosts: []string{"example.com"},
+	}
+	defaultBlacklist.setDefaults()
+
+	profile := DNSFilterProfile{
+		Name:          "kids",
+		ClientSubnets: []netip.Prefix{netip.MustParsePrefix("192.168.2.0/24")},
+		Blacklist: DNSBlacklist{
+			BlockAds:       ptrTo(true),
+			BlockMalicious: ptrTo(false),
+		},
+	}
+	profile.setDefaults(defaultBlacklist)
+
+	assert.True(t, *profile.Blacklist.BlockAds)
+	assert.False(t, *profile.Blacklist.BlockMalicious)
+	assert.True(t, *profile.Blacklist.BlockSurveillance)
+	assert.Equal(t, []string{"example.com"}, profile.Blacklist.AllowedHosts)
+	assert.Equal(t, "/gluetun/dns/blocklists-kids.json", *profile.Blacklist.CacheFilepath)
+	assert.Equal(t, "/gluetun/dns/blocklists.json", *defaultBlacklist.CacheFilepath)
+}
+
+func Test_validateFilterProfiles(t *testing.T) {
+	t.Parallel()
+
+	newProfile := func(name string, subnets ...netip.Prefix) DNSFilterProfile {
+		profile := DNSFilterProfile{Name: name, ClientSubnets: subnets}
+		profile.Blacklist.setDefaults()
+		return profile
+	}
+	subnet := netip.MustParsePrefix("10.0.0.0/8")
+
+	testCases := map[string]struct {
+		profiles   []DNSFilterProfile
+		errWrapped error
+	}{
+		"valid": {
+			profiles: []DNSFilterProfile{newProfile("kids", subnet), newProfile("servers", subnet)},
+		},
+		"invalid_name": {
+			profiles:   []DNSFilterProfile{newProfile("Kids-1", subnet)},
+			errWrapped: ErrFilterProfileNameNotValid,
+		},
+		"duplicate_name": {
+			profiles:   []DNSFilterProfile{newProfile("kids", subnet), newProfile("kids", subnet)},
+			errWrapped: ErrFilterProfileNameDuplicate,
+		},
+		"no_subnet": {
+			profiles:   []DNSFilterProfile{newProfile("kids")},
+			errWrapped: ErrFilterProfileSubnetsNotSet,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateFilterProfiles(testCase.profiles)
+			if testCase.errWrapped == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+}
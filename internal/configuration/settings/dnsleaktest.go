@@ -0,0 +1,77 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// DNSLeakTest contains settings to configure the DNS leak
+// test run each time the VPN tunnel is up.
+type DNSLeakTest struct {
+	// Enabled is true if the DNS leak test should run.
+	// It defaults to false and cannot be nil in the internal state.
+	Enabled *bool `json:"enabled"`
+	// URL is the base URL of the leak test service, which must
+	// implement the bash.ws API. It defaults to https://bash.ws
+	// and cannot be nil in the internal state.
+	URL *string `json:"url"`
+}
+
+var ErrLeakTestURLNotValid = errors.New("DNS leak test URL is not valid")
+
+func (d DNSLeakTest) validate() (err error) {
+	parsedURL, err := url.Parse(*d.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLeakTestURLNotValid, err)
+	}
+	if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("%w: %s", ErrLeakTestURLNotValid, *d.URL)
+	}
+	return nil
+}
+
+func (d DNSLeakTest) copy() (copied DNSLeakTest) {
+	return DNSLeakTest{
+		Enabled: gosettings.CopyPointer(d.Enabled),
+		URL:     gosettings.CopyPointer(d.URL),
+	}
+}
+
+func (d *DNSLeakTest) overrideWith(other DNSLeakTest) {
+	d.Enabled = gosettings.OverrideWithPointer(d.Enabled, other.Enabled)
+	d.URL = gosettings.OverrideWithPointer(d.URL, other.URL)
+}
+
+func (d *DNSLeakTest) setDefaults() {
+	d.Enabled = gosettings.DefaultPointer(d.Enabled, false)
+	d.URL = gosettings.DefaultPointer(d.URL, "https://bash.ws")
+}
+
+func (d DNSLeakTest) String() string {
+	return d.toLinesNode().String()
+}
+
+func (d DNSLeakTest) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("DNS leak test settings:")
+	node.Appendf("Enabled: %s", gosettings.BoolToYesNo(d.Enabled))
+	if !*d.Enabled {
+		return node
+	}
+	node.Appendf("Service URL: %s", *d.URL)
+	return node
+}
+
+func (d *DNSLeakTest) read(r *reader.Reader) (err error) {
+	d.Enabled, err = r.BoolPtr("DNS_LEAK_TEST")
+	if err != nil {
+		return err
+	}
+
+	d.URL = r.Get("DNS_LEAK_TEST_URL", reader.ForceLowercase(false))
+	return nil
+}
@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// DNSQueryLog contains settings to configure the DNS query log.
+type DNSQueryLog struct {
+	// Enabled is true if DNS queries should be recorded.
+	// It defaults to false and cannot be nil in the internal state.
+	Enabled *bool `json:"enabled"`
+	// MaxEntries is the maximum number of queries to keep in memory,
+	// from which the per-client statistics are aggregated.
+	// It defaults to 1000 and cannot be nil in the internal state.
+	MaxEntries *uint `json:"max_entries"`
+	// Filepath is the path of a file to append each query to,
+	// in the JSON lines format. It can be the empty string to
+	// indicate not to write to a file, which is its default.
+	// It cannot be nil in the internal state.
+	Filepath *string `json:"filepath"`
+}
+
+var ErrQueryLogMaxEntriesZero = errors.New("query log maximum entries cannot be zero")
+
+func (q DNSQueryLog) validate() (err error) {
+	if *q.MaxEntries == 0 {
+		return fmt.Errorf("%w", ErrQueryLogMaxEntriesZero)
+	}
+
+	if *q.Filepath != "" { // optional
+		_, err := filepath.Abs(*q.Filepath)
+		if err != nil {
+			return fmt.Errorf("filepath is not valid: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (q DNSQueryLog) copy() (copied DNSQueryLog) {
+	return DNSQueryLog{
+		Enabled:    gosettings.CopyPointer(q.Enabled),
+		MaxEntries: gosettings.CopyPointer(q.MaxEntries),
+		Filepath:   gosettings.CopyPointer(q.Filepath),
+	}
+}
+
+func (q *DNSQueryLog) overrideWith(other DNSQueryLog) {
+	q.Enabled = gosettings.OverrideWithPointer(q.Enabled, other.Enabled)
+	q.MaxEntries = gosettings.OverrideWithPointer(q.MaxEntries, other.MaxEntries)
+	q.Filepath = gosettings.OverrideWithPointer(q.Filepath, other.Filepath)
+}
+
+func (q *DNSQueryLog) setDefaults() {
+	q.Enabled = gosettings.DefaultPointer(q.Enabled, false)
+	const defaultMaxEntries = 1000
+	q.MaxEntries = gosettings.DefaultPointer(q.MaxEntries, defaultMaxEntries)
+	q.Filepath = gosettings.DefaultPointer(q.Filepath, "")
+}
+
+func (q DNSQueryLog) String() string {
+	return q.toLinesNode().String()
+}
+
+func (q DNSQueryLog) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("DNS query log settings:")
+	node.Appendf("Enabled: %s", gosettings.BoolToYesNo(q.Enabled))
+	if !*q.Enabled {
+		return node
+	}
+
+	node.Appendf("Maximum entries in memory: %d", *q.MaxEntries)
+
+	filepath := *q.Filepath
+	if filepath == "" {
+		filepath = "[not set]"
+	}
+	node.Appendf("File path: %s", filepath)
+
+	return node
+}
+
+func (q *DNSQueryLog) read(r *reader.Reader) (err error) {
+	q.Enabled, err = r.BoolPtr("DNS_QUERY_LOG")
+	if err != nil {
+		return err
+	}
+
+	q.MaxEntries, err = r.UintPtr("DNS_QUERY_LOG_MAX_ENTRIES")
+	if err != nil {
+		return err
+	}
+
+	q.Filepath = r.Get("DNS_QUERY_LOG_FILEPATH", reader.ForceLowercase(false))
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package settings
+
+import (
+	"fmt"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/validate"
+	"github.com/qdm12/gotree"
+)
+
+// DNSRebinding contains settings to configure the DNS
+// rebinding protection of the DNS over TLS server.
+type DNSRebinding struct {
+	// Enabled is true if answers with loopback, private, link-local
+	// or unique local IP addresses, as well as the blocked IP addresses
+	// and networks, should be stripped or refused for names other than
+	// the allowed hosts and DNS bypass domains.
+	// It defaults to false and cannot be nil in the internal state.
+	Enabled *bool `json:"enabled"`
+	// Action is the action to take on a response containing
+	// protected IP addresses, and can be 'strip' or 'refuse'.
+	// It defaults to 'strip' and cannot be nil in the internal state.
+	Action *string `json:"action"`
+	// AllowedHosts are host rules, in the same format as
+	// DNSBlacklist.AllowedHosts, for which answers are not checked.
+	AllowedHosts []string `json:"allowed_hosts"`
+}
+
+func (d DNSRebinding) validate() (err error) {
+	err = validate.IsOneOf(*d.Action, "strip", "refuse")
+	if err != nil {
+		return fmt.Errorf("action: %w", err)
+	}
+
+	for _, host := range d.AllowedHosts {
+		err = validateHostRule(host)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrAllowedHostNotValid, err)
+		}
+	}
+
+	return nil
+}
+
+func (d DNSRebinding) copy() (copied DNSRebinding) {
+	return DNSRebinding{
+		Enabled:      gosettings.CopyPointer(d.Enabled),
+		Action:       gosettings.CopyPointer(d.Action),
+		AllowedHosts: gosettings.CopySlice(d.AllowedHosts),
+	}
+}
+
+func (d *DNSRebinding) overrideWith(other DNSRebinding) {
+	d.Enabled = gosettings.OverrideWithPointer(d.Enabled, other.Enabled)
+	d.Action = gosettings.OverrideWithPointer(d.Action, other.Action)
+	d.AllowedHosts = gosettings.OverrideWithSlice(d.AllowedHosts, other.AllowedHosts)
+}
+
+func (d *DNSRebinding) setDefaults() {
+	d.Enabled = gosettings.DefaultPointer(d.Enabled, false)
+	d.Action = gosettings.DefaultPointer(d.Action, "strip")
+}
+
+func (d DNSRebinding) String() string {
+	return d.toLinesNode().String()
+}
+
+func (d DNSRebinding) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("DNS rebinding protection settings:")
+	node.Appendf("Enabled: %s", gosettings.BoolToYesNo(d.Enabled))
+	if !*d.Enabled {
+		return node
+	}
+
+	node.Appendf("Action: %s", *d.Action)
+
+	if len(d.AllowedHosts) > 0 {
+		allowedHostsNode := node.Append("Allowed hosts:")
+		for _, host := range d.AllowedHosts {
+			allowedHostsNode.Append(host)
+		}
+	}
+
+	return node
+}
+
+func (d *DNSRebinding) read(r *reader.Reader) (err error) {
+	d.Enabled, err = r.BoolPtr("DNS_REBINDING_PROTECTION")
+	if err != nil {
+		return err
+	}
+
+	d.Action = r.Get("DNS_REBINDING_PROTECTION_ACTION")
+
+	// Do not lowercase to keep regular expression rules as they are.
+	d.AllowedHosts = r.CSV("DNS_REBINDING_PROTECTION_ALLOWED_HOSTS",
+		reader.ForceLowercase(false))
+
+	return nil
+}
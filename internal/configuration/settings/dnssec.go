@@ -0,0 +1,100 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// DNSSEC contains settings to configure the DNSSEC validation
+// of the DNS over TLS server responses.
+type DNSSEC struct {
+	// Enabled is true if responses should be DNSSEC validated.
+	// It defaults to false and cannot be nil in the internal state.
+	Enabled *bool `json:"enabled"`
+	// TrustAnchors are DS records in their presentation format,
+	// trusted on top of the built-in root zone trust anchors.
+	TrustAnchors []string `json:"trust_anchors"`
+	// SkipBypassDomains is true if validation should be skipped
+	// for the DNS bypass domains, which are usually not signed.
+	// It defaults to true and cannot be nil in the internal state.
+	SkipBypassDomains *bool `json:"skip_bypass_domains"`
+}
+
+var ErrTrustAnchorNotValid = errors.New("trust anchor is not a valid DS record")
+
+func (d DNSSEC) validate() (err error) {
+	for _, trustAnchor := range d.TrustAnchors {
+		rr, err := dns.NewRR(trustAnchor)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTrustAnchorNotValid, err)
+		}
+		_, ok := rr.(*dns.DS)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrTrustAnchorNotValid, trustAnchor)
+		}
+	}
+	return nil
+}
+
+func (d DNSSEC) copy() (copied DNSSEC) {
+	return DNSSEC{
+		Enabled:           gosettings.CopyPointer(d.Enabled),
+		TrustAnchors:      gosettings.CopySlice(d.TrustAnchors),
+		SkipBypassDomains: gosettings.CopyPointer(d.SkipBypassDomains),
+	}
+}
+
+func (d *DNSSEC) overrideWith(other DNSSEC) {
+	d.Enabled = gosettings.OverrideWithPointer(d.Enabled, other.Enabled)
+	d.TrustAnchors = gosettings.OverrideWithSlice(d.TrustAnchors, other.TrustAnchors)
+	d.SkipBypassDomains = gosettings.OverrideWithPointer(d.SkipBypassDomains, other.SkipBypassDomains)
+}
+
+func (d *DNSSEC) setDefaults() {
+	d.Enabled = gosettings.DefaultPointer(d.Enabled, false)
+	d.SkipBypassDomains = gosettings.DefaultPointer(d.SkipBypassDomains, true)
+}
+
+func (d DNSSEC) String() string {
+	return d.toLinesNode().String()
+}
+
+func (d DNSSEC) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("DNSSEC validation settings:")
+	node.Appendf("Enabled: %s", gosettings.BoolToYesNo(d.Enabled))
+	if !*d.Enabled {
+		return node
+	}
+
+	if len(d.TrustAnchors) > 0 {
+		trustAnchorsNode := node.Append("Additional trust anchors:")
+		for _, trustAnchor := range d.TrustAnchors {
+			trustAnchorsNode.Append(trustAnchor)
+		}
+	}
+
+	node.Appendf("Skip bypass domains: %s", gosettings.BoolToYesNo(d.SkipBypassDomains))
+
+	return node
+}
+
+func (d *DNSSEC) read(r *reader.Reader) (err error) {
+	d.Enabled, err = r.BoolPtr("DOT_DNSSEC")
+	if err != nil {
+		return err
+	}
+
+	d.TrustAnchors = r.CSV("DOT_DNSSEC_TRUST_ANCHORS", reader.ForceLowercase(false))
+
+	d.SkipBypassDomains, err = r.BoolPtr("DOT_DNSSEC_SKIP_BYPASS_DOMAINS")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
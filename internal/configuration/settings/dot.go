@@ -28,14 +28,36 @@ type DoT struct {
 	// Caching is true if the DoT server should cache
 	// DNS responses.
 	Caching *bool `json:"caching"`
+	// ServeStaleMaxAge is the maximum duration after its expiry
+	// a cached response can be served if the upstream resolution
+	// fails or is too slow, as described in RFC 8767.
+	// It defaults to 0 to disable serving stale responses,
+	// and cannot be nil in the internal state.
+	ServeStaleMaxAge *time.Duration `json:"serve_stale_max_age"`
+	// Prefetch is true if popular cached responses should be
+	// refreshed in the background shortly before they expire.
+	// It defaults to false and cannot be nil in the internal state.
+	Prefetch *bool `json:"prefetch"`
 	// IPv6 is true if the DoT server should connect over IPv6.
 	IPv6 *bool `json:"ipv6"`
 	// Blacklist contains settings to configure the filter
 	// block lists.
 	Blacklist DNSBlacklist
+	// FilterProfiles are named filtering profiles used instead
+	// of Blacklist for clients in their subnets.
+	FilterProfiles []DNSFilterProfile
+	// QueryLog contains settings to configure the
+	// DNS query log and per-client statistics.
+	QueryLog DNSQueryLog
+	// DNSSEC contains settings to configure the
+	// DNSSEC validation of responses.
+	DNSSEC DNSSEC
 }
 
-var ErrDoTUpdatePeriodTooShort = errors.New("update period is too short")
+var (
+	ErrDoTUpdatePeriodTooShort  = errors.New("update period is too short")
+	ErrServeStaleMaxAgeNegative = errors.New("serve stale maximum age is negative")
+)
 
 func (d DoT) validate() (err error) {
 	const minUpdatePeriod = 30 * time.Second
@@ -52,22 +74,46 @@ func (d DoT) validate() (err error) {
 		}
 	}
 
+	if *d.ServeStaleMaxAge < 0 {
+		return fmt.Errorf("%w: %s", ErrServeStaleMaxAgeNegative, *d.ServeStaleMaxAge)
+	}
+
 	err = d.Blacklist.validate()
 	if err != nil {
 		return err
 	}
 
+	err = validateFilterProfiles(d.FilterProfiles)
+	if err != nil {
+		return err
+	}
+
+	err = d.QueryLog.validate()
+	if err != nil {
+		return fmt.Errorf("query log settings: %w", err)
+	}
+
+	err = d.DNSSEC.validate()
+	if err != nil {
+		return fmt.Errorf("DNSSEC settings: %w", err)
+	}
+
 	return nil
 }
 
 func (d *DoT) copy() (copied DoT) {
 	return DoT{
-		Enabled:      gosettings.CopyPointer(d.Enabled),
-		UpdatePeriod: gosettings.CopyPointer(d.UpdatePeriod),
-		Providers:    gosettings.CopySlice(d.Providers),
-		Caching:      gosettings.CopyPointer(d.Caching),
-		IPv6:         gosettings.CopyPointer(d.IPv6),
-		Blacklist:    d.Blacklist.copy(),
+		Enabled:          gosettings.CopyPointer(d.Enabled),
+		UpdatePeriod:     gosettings.CopyPointer(d.UpdatePeriod),
+		Providers:        gosettings.CopySlice(d.Providers),
+		Caching:          gosettings.CopyPointer(d.Caching),
+		ServeStaleMaxAge: gosettings.CopyPointer(d.ServeStaleMaxAge),
+		Prefetch:         gosettings.CopyPointer(d.Prefetch),
+		IPv6:             gosettings.CopyPointer(d.IPv6),
+		Blacklist:        d.Blacklist.copy(),
+		FilterProfiles:   copyFilterProfiles(d.FilterProfiles),
+		QueryLog:         d.QueryLog.copy(),
+		DNSSEC:           d.DNSSEC.copy(),
 	}
 }
 
@@ -79,8 +125,13 @@ func (d *DoT) overrideWith(other DoT) {
 	d.UpdatePeriod = gosettings.OverrideWithPointer(d.UpdatePeriod, other.UpdatePeriod)
 	d.Providers = gosettings.OverrideWithSlice(d.Providers, other.Providers)
 	d.Caching = gosettings.OverrideWithPointer(d.Caching, other.Caching)
+	d.ServeStaleMaxAge = gosettings.OverrideWithPointer(d.ServeStaleMaxAge, other.ServeStaleMaxAge)
+	d.Prefetch = gosettings.OverrideWithPointer(d.Prefetch, other.Prefetch)
 	d.IPv6 = gosettings.OverrideWithPointer(d.IPv6, other.IPv6)
 	d.Blacklist.overrideWith(other.Blacklist)
+	d.FilterProfiles = gosettings.OverrideWithSlice(d.FilterProfiles, other.FilterProfiles)
+	d.QueryLog.overrideWith(other.QueryLog)
+	d.DNSSEC.overrideWith(other.DNSSEC)
 }
 
 func (d *DoT) setDefaults() {
@@ -91,8 +142,15 @@ func (d *DoT) setDefaults() {
 		provider.Cloudflare().Name,
 	})
 	d.Caching = gosettings.DefaultPointer(d.Caching, true)
+	d.ServeStaleMaxAge = gosettings.DefaultPointer(d.ServeStaleMaxAge, 0)
+	d.Prefetch = gosettings.DefaultPointer(d.Prefetch, false)
 	d.IPv6 = gosettings.DefaultPointer(d.IPv6, false)
 	d.Blacklist.setDefaults()
+	for i := range d.FilterProfiles {
+		d.FilterProfiles[i].setDefaults(d.Blacklist)
+	}
+	d.QueryLog.setDefaults()
+	d.DNSSEC.setDefaults()
 }
 
 func (d DoT) GetFirstPlaintextIPv4() (ipv4 netip.Addr) {
@@ -130,10 +188,26 @@ func (d DoT) toLinesNode() (node *gotree.Node) {
 		upstreamResolvers.Append(provider)
 	}
 
-	node.Appendf("Caching: %s", gosettings.BoolToYesNo(d.Caching))
+	cachingNode := node.Appendf("Caching: %s", gosettings.BoolToYesNo(d.Caching))
+	if *d.Caching {
+		if *d.ServeStaleMaxAge > 0 {
+			cachingNode.Appendf("Serve stale responses for up to: %s", *d.ServeStaleMaxAge)
+		}
+		if *d.Prefetch {
+			cachingNode.Appendf("Prefetch popular responses: yes")
+		}
+	}
 	node.Appendf("IPv6: %s", gosettings.BoolToYesNo(d.IPv6))
 
 	node.AppendNode(d.Blacklist.toLinesNode())
+	if len(d.FilterProfiles) > 0 {
+		profilesNode := node.Append("DNS filtering profiles:")
+		for _, profile := range d.FilterProfiles {
+			profilesNode.AppendNode(profile.toLinesNode())
+		}
+	}
+	node.AppendNode(d.QueryLog.toLinesNode())
+	node.AppendNode(d.DNSSEC.toLinesNode())
 
 	return node
 }
@@ -156,6 +230,16 @@ func (d *DoT) read(reader *reader.Reader) (err error) {
 		return err
 	}
 
+	d.ServeStaleMaxAge, err = reader.DurationPtr("DOT_CACHE_SERVE_STALE_MAX_AGE")
+	if err != nil {
+		return err
+	}
+
+	d.Prefetch, err = reader.BoolPtr("DOT_CACHE_PREFETCH")
+	if err != nil {
+		return err
+	}
+
 	d.IPv6, err = reader.BoolPtr("DOT_IPV6")
 	if err != nil {
 		return err
@@ -166,5 +250,20 @@ func (d *DoT) read(reader *reader.Reader) (err error) {
 		return err
 	}
 
+	d.FilterProfiles, err = readFilterProfiles(reader)
+	if err != nil {
+		return err
+	}
+
+	err = d.QueryLog.read(reader)
+	if err != nil {
+		return err
+	}
+
+	err = d.DNSSEC.read(reader)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -11,8 +11,12 @@ var (
 	ErrFilepathMissing                 = errors.New("filepath is missing")
 	ErrFirewallZeroPort                = errors.New("cannot have a zero port")
 	ErrFirewallPublicOutboundSubnet    = errors.New("outbound subnet has an unspecified address")
+	ErrHealthIPLeakPeriodTooSmall      = errors.New("health IP leak check period is too small")
+	ErrHealthTargetsNotSet             = errors.New("health target addresses are not set")
+	ErrHealthTargetQuorumNotValid      = errors.New("health target quorum is not valid")
 	ErrHostnameNotValid                = errors.New("the hostname specified is not valid")
 	ErrISPNotValid                     = errors.New("the ISP specified is not valid")
+	ErrLatencySampleSizeNotValid       = errors.New("latency sample size is not valid")
 	ErrMinRatioNotValid                = errors.New("minimum ratio is not valid")
 	ErrMissingValue                    = errors.New("missing value")
 	ErrNameNotValid                    = errors.New("the server name specified is not valid")
@@ -35,8 +39,15 @@ var (
 	ErrSystemPGIDNotValid              = errors.New("process group id is not valid")
 	ErrSystemPUIDNotValid              = errors.New("process user id is not valid")
 	ErrSystemTimezoneNotValid          = errors.New("timezone is not valid")
+	ErrUpdaterLoadPeriodTooSmall       = errors.New("server load refresh period is too small")
 	ErrUpdaterPeriodTooSmall           = errors.New("VPN server data updater period is too small")
+	ErrVPNCommandTimeoutTooSmall       = errors.New("VPN command timeout is too small")
+	ErrVPNFailoverRetryPeriodTooSmall  = errors.New("VPN failover primary retry period is too small")
+	ErrVPNFailoverThresholdZero        = errors.New("VPN failover threshold cannot be zero")
 	ErrVPNProviderNameNotValid         = errors.New("VPN provider name is not valid")
+	ErrVPNRotationCronNotValid         = errors.New("VPN rotation cron expression is not valid")
+	ErrVPNRotationIntervalAndCronSet   = errors.New("VPN rotation interval and cron expression are both set")
+	ErrVPNRotationIntervalTooSmall     = errors.New("VPN rotation interval is too small")
 	ErrVPNTypeNotValid                 = errors.New("VPN type is not valid")
 	ErrWireguardAllowedIPNotSet        = errors.New("allowed IP is not set")
 	ErrWireguardAllowedIPsNotSet       = errors.New("allowed IPs is not set")
@@ -3,6 +3,7 @@ package settings
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/qdm12/gosettings"
@@ -23,15 +24,32 @@ type Health struct {
 	// ReadTimeout is the HTTP read timeout duration of the
 	// HTTP server. It defaults to 500 milliseconds.
 	ReadTimeout time.Duration
-	// TargetAddress is the address (host or host:port)
-	// to TCP dial to periodically for the health check.
-	// It cannot be the empty string in the internal state.
-	TargetAddress string
+	// TargetAddresses are the targets to probe periodically
+	// for the health check, each of the form [type://]address
+	// as described in ParseHealthTarget. Targets without type
+	// are TCP dialed, and HTTP targets can set their expected
+	// status code and body substring in their URL fragment.
+	// It cannot be empty in the internal state.
+	TargetAddresses []string
+	// TargetQuorum is the minimum number of target addresses
+	// to successfully dial for the health check to succeed.
+	// It defaults to the majority of the target addresses,
+	// and cannot be zero in the internal state.
+	TargetQuorum uint
+	// WireguardHandshakeMaxAge is the maximum age of the last
+	// Wireguard handshake for Wireguard targets. It is global and
+	// applies to all the Wireguard targets, which all check the
+	// same VPN tunnel interface. It defaults to 3 minutes and
+	// cannot be zero in the internal state.
+	WireguardHandshakeMaxAge time.Duration
 	// SuccessWait is the duration to wait to re-run the
 	// healthcheck after a successful healthcheck.
 	// It defaults to 5 seconds and cannot be zero in
 	// the internal state.
 	SuccessWait time.Duration
+	// IPLeak has the health criteria settings to check
+	// the public IP address goes through the VPN.
+	IPLeak HealthIPLeak
 	// VPN has health settings specific to the VPN loop.
 	VPN HealthyWait
 }
@@ -42,6 +60,27 @@ func (h Health) Validate() (err error) {
 		return fmt.Errorf("server listening address is not valid: %w", err)
 	}
 
+	if len(h.TargetAddresses) == 0 {
+		return fmt.Errorf("%w", ErrHealthTargetsNotSet)
+	}
+
+	for _, target := range h.TargetAddresses {
+		_, err = ParseHealthTarget(target)
+		if err != nil {
+			return fmt.Errorf("target address: %w", err)
+		}
+	}
+
+	if h.TargetQuorum == 0 || h.TargetQuorum > uint(len(h.TargetAddresses)) {
+		return fmt.Errorf("%w: %d must be between 1 and the number of target addresses %d",
+			ErrHealthTargetQuorumNotValid, h.TargetQuorum, len(h.TargetAddresses))
+	}
+
+	err = h.IPLeak.validate()
+	if err != nil {
+		return fmt.Errorf("IP leak settings: %w", err)
+	}
+
 	err = h.VPN.validate()
 	if err != nil {
 		return fmt.Errorf("health VPN settings: %w", err)
@@ -52,12 +91,15 @@ func (h Health) Validate() (err error) {
 
 func (h *Health) copy() (copied Health) {
 	return Health{
-		ServerAddress:     h.ServerAddress,
-		ReadHeaderTimeout: h.ReadHeaderTimeout,
-		ReadTimeout:       h.ReadTimeout,
-		TargetAddress:     h.TargetAddress,
-		SuccessWait:       h.SuccessWait,
-		VPN:               h.VPN.copy(),
+		ServerAddress:            h.ServerAddress,
+		ReadHeaderTimeout:        h.ReadHeaderTimeout,
+		ReadTimeout:              h.ReadTimeout,
+		TargetAddresses:          gosettings.CopySlice(h.TargetAddresses),
+		TargetQuorum:             h.TargetQuorum,
+		WireguardHandshakeMaxAge: h.WireguardHandshakeMaxAge,
+		SuccessWait:              h.SuccessWait,
+		IPLeak:                   h.IPLeak.copy(),
+		VPN:                      h.VPN.copy(),
 	}
 }
 
@@ -68,8 +110,12 @@ func (h *Health) OverrideWith(other Health) {
 	h.ServerAddress = gosettings.OverrideWithComparable(h.ServerAddress, other.ServerAddress)
 	h.ReadHeaderTimeout = gosettings.OverrideWithComparable(h.ReadHeaderTimeout, other.ReadHeaderTimeout)
 	h.ReadTimeout = gosettings.OverrideWithComparable(h.ReadTimeout, other.ReadTimeout)
-	h.TargetAddress = gosettings.OverrideWithComparable(h.TargetAddress, other.TargetAddress)
+	h.TargetAddresses = gosettings.OverrideWithSlice(h.TargetAddresses, other.TargetAddresses)
+	h.TargetQuorum = gosettings.OverrideWithComparable(h.TargetQuorum, other.TargetQuorum)
+	h.WireguardHandshakeMaxAge = gosettings.OverrideWithComparable(h.WireguardHandshakeMaxAge,
+		other.WireguardHandshakeMaxAge)
 	h.SuccessWait = gosettings.OverrideWithComparable(h.SuccessWait, other.SuccessWait)
+	h.IPLeak.overrideWith(other.IPLeak)
 	h.VPN.overrideWith(other.VPN)
 }
 
@@ -79,9 +125,15 @@ func (h *Health) SetDefaults() {
 	h.ReadHeaderTimeout = gosettings.DefaultComparable(h.ReadHeaderTimeout, defaultReadHeaderTimeout)
 	const defaultReadTimeout = 500 * time.Millisecond
 	h.ReadTimeout = gosettings.DefaultComparable(h.ReadTimeout, defaultReadTimeout)
-	h.TargetAddress = gosettings.DefaultComparable(h.TargetAddress, "cloudflare.com:443")
+	h.TargetAddresses = gosettings.DefaultSlice(h.TargetAddresses, []string{"cloudflare.com:443"})
+	majority := uint(len(h.TargetAddresses))/2 + 1 //nolint:mnd
+	h.TargetQuorum = gosettings.DefaultComparable(h.TargetQuorum, majority)
+	const defaultWireguardHandshakeMaxAge = 3 * time.Minute
+	h.WireguardHandshakeMaxAge = gosettings.DefaultComparable(h.WireguardHandshakeMaxAge,
+		defaultWireguardHandshakeMaxAge)
 	const defaultSuccessWait = 5 * time.Second
 	h.SuccessWait = gosettings.DefaultComparable(h.SuccessWait, defaultSuccessWait)
+	h.IPLeak.setDefaults()
 	h.VPN.setDefaults()
 }
 
@@ -92,24 +144,50 @@ func (h Health) String() string {
 func (h Health) toLinesNode() (node *gotree.Node) {
 	node = gotree.New("Health settings:")
 	node.Appendf("Server listening address: %s", h.ServerAddress)
-	node.Appendf("Target address: %s", h.TargetAddress)
+	if len(h.TargetAddresses) == 1 {
+		node.Appendf("Target address: %s", h.TargetAddresses[0])
+	} else {
+		node.Appendf("Target addresses: %s", strings.Join(h.TargetAddresses, ", "))
+		node.Appendf("Target quorum: %d", h.TargetQuorum)
+	}
+	if healthTargetsHaveType(h.TargetAddresses, HealthTargetWireguard) {
+		node.Appendf("Wireguard handshake maximum age: %s", h.WireguardHandshakeMaxAge)
+	}
 	node.Appendf("Duration to wait after success: %s", h.SuccessWait)
 	node.Appendf("Read header timeout: %s", h.ReadHeaderTimeout)
 	node.Appendf("Read timeout: %s", h.ReadTimeout)
+	node.AppendNode(h.IPLeak.toLinesNode())
 	node.AppendNode(h.VPN.toLinesNode("VPN"))
 	return node
 }
 
 func (h *Health) Read(r *reader.Reader) (err error) {
 	h.ServerAddress = r.String("HEALTH_SERVER_ADDRESS")
-	h.TargetAddress = r.String("HEALTH_TARGET_ADDRESS",
-		reader.RetroKeys("HEALTH_ADDRESS_TO_PING"))
+	// Do not lowercase to keep HTTP target URL paths as they are.
+	h.TargetAddresses = r.CSV("HEALTH_TARGET_ADDRESSES",
+		reader.RetroKeys("HEALTH_ADDRESS_TO_PING", "HEALTH_TARGET_ADDRESS"),
+		reader.ForceLowercase(false))
+
+	h.TargetQuorum, err = r.Uint("HEALTH_TARGET_QUORUM")
+	if err != nil {
+		return err
+	}
+
+	h.WireguardHandshakeMaxAge, err = r.Duration("HEALTH_WIREGUARD_HANDSHAKE_MAX_AGE")
+	if err != nil {
+		return err
+	}
 
 	h.SuccessWait, err = r.Duration("HEALTH_SUCCESS_WAIT_DURATION")
 	if err != nil {
 		return err
 	}
 
+	err = h.IPLeak.read(r)
+	if err != nil {
+		return fmt.Errorf("IP leak health settings: %w", err)
+	}
+
 	err = h.VPN.read(r)
 	if err != nil {
 		return fmt.Errorf("VPN health settings: %w", err)
@@ -0,0 +1,124 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// HealthIPLeak contains settings for the health criteria
+// checking the public IP address goes through the VPN.
+type HealthIPLeak struct {
+	// PreVPNIP is true if the public IP address must differ from
+	// the public IP address fetched at startup before the VPN is up.
+	// It cannot be nil in the internal state.
+	PreVPNIP *bool
+	// ServerIP is true if the public IP address must be one of
+	// the VPN server IP addresses, if they are known.
+	// It cannot be nil in the internal state.
+	ServerIP *bool
+	// ServerCountry is true if the public IP address country
+	// must match the VPN server country, if it is known.
+	// It cannot be nil in the internal state.
+	ServerCountry *bool
+	// Provider is true if the VPN provider must confirm the traffic
+	// goes through its VPN, for providers supporting it.
+	// It cannot be nil in the internal state.
+	Provider *bool
+	// Period is the minimum period between two public IP address
+	// fetches, to avoid being rate limited by the public IP APIs.
+	// It defaults to 5 minutes and cannot be nil in the internal state.
+	Period *time.Duration
+}
+
+// Enabled returns true if any of the IP leak criteria is enabled.
+func (h HealthIPLeak) Enabled() bool {
+	return *h.PreVPNIP || *h.ServerIP || *h.ServerCountry || *h.Provider
+}
+
+func (h HealthIPLeak) validate() (err error) {
+	const minPeriod = time.Minute
+	if *h.Period < minPeriod {
+		return fmt.Errorf("%w: %s must be at least %s",
+			ErrHealthIPLeakPeriodTooSmall, *h.Period, minPeriod)
+	}
+	return nil
+}
+
+func (h *HealthIPLeak) copy() (copied HealthIPLeak) {
+	return HealthIPLeak{
+		PreVPNIP:      gosettings.CopyPointer(h.PreVPNIP),
+		ServerIP:      gosettings.CopyPointer(h.ServerIP),
+		ServerCountry: gosettings.CopyPointer(h.ServerCountry),
+		Provider:      gosettings.CopyPointer(h.Provider),
+		Period:        gosettings.CopyPointer(h.Period),
+	}
+}
+
+func (h *HealthIPLeak) overrideWith(other HealthIPLeak) {
+	h.PreVPNIP = gosettings.OverrideWithPointer(h.PreVPNIP, other.PreVPNIP)
+	h.ServerIP = gosettings.OverrideWithPointer(h.ServerIP, other.ServerIP)
+	h.ServerCountry = gosettings.OverrideWithPointer(h.ServerCountry, other.ServerCountry)
+	h.Provider = gosettings.OverrideWithPointer(h.Provider, other.Provider)
+	h.Period = gosettings.OverrideWithPointer(h.Period, other.Period)
+}
+
+func (h *HealthIPLeak) setDefaults() {
+	h.PreVPNIP = gosettings.DefaultPointer(h.PreVPNIP, false)
+	h.ServerIP = gosettings.DefaultPointer(h.ServerIP, false)
+	h.ServerCountry = gosettings.DefaultPointer(h.ServerCountry, false)
+	h.Provider = gosettings.DefaultPointer(h.Provider, false)
+	const defaultPeriod = 5 * time.Minute
+	h.Period = gosettings.DefaultPointer(h.Period, defaultPeriod)
+}
+
+func (h HealthIPLeak) String() string {
+	return h.toLinesNode().String()
+}
+
+func (h HealthIPLeak) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("IP leak check settings:")
+	if !h.Enabled() {
+		node.Appendf("Enabled: no")
+		return node
+	}
+
+	node.Appendf("Differs from pre-VPN public IP address: %s", gosettings.BoolToYesNo(h.PreVPNIP))
+	node.Appendf("Is a VPN server IP address: %s", gosettings.BoolToYesNo(h.ServerIP))
+	node.Appendf("Matches VPN server country: %s", gosettings.BoolToYesNo(h.ServerCountry))
+	node.Appendf("Confirmed by VPN provider: %s", gosettings.BoolToYesNo(h.Provider))
+	node.Appendf("Check period: %s", *h.Period)
+	return node
+}
+
+func (h *HealthIPLeak) read(r *reader.Reader) (err error) {
+	h.PreVPNIP, err = r.BoolPtr("HEALTH_IP_LEAK_PRE_VPN_IP")
+	if err != nil {
+		return err
+	}
+
+	h.ServerIP, err = r.BoolPtr("HEALTH_IP_LEAK_SERVER_IP")
+	if err != nil {
+		return err
+	}
+
+	h.ServerCountry, err = r.BoolPtr("HEALTH_IP_LEAK_SERVER_COUNTRY")
+	if err != nil {
+		return err
+	}
+
+	h.Provider, err = r.BoolPtr("HEALTH_IP_LEAK_PROVIDER")
+	if err != nil {
+		return err
+	}
+
+	h.Period, err = r.DurationPtr("HEALTH_IP_LEAK_PERIOD")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
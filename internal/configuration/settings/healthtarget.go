@@ -0,0 +1,147 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HealthTargetType is the type of probe to run against a health target.
+type HealthTargetType string
+
+const (
+	// HealthTargetTCP dials the target host and port with TCP,
+	// and runs a TLS handshake if the port is 443.
+	HealthTargetTCP HealthTargetType = "tcp"
+	// HealthTargetICMP sends an ICMP echo request to the target
+	// host through the VPN tunnel interface.
+	HealthTargetICMP HealthTargetType = "icmp"
+	// HealthTargetDNS resolves the target hostname using the
+	// system resolver, which is the internal DNS server if enabled.
+	HealthTargetDNS HealthTargetType = "dns"
+	// HealthTargetHTTP sends an HTTP or HTTPS GET request to the target
+	// URL and checks the response status code and body are the ones
+	// expected for the target.
+	HealthTargetHTTP HealthTargetType = "http"
+	// HealthTargetWireguard checks the last Wireguard handshake
+	// of the VPN tunnel interface is recent enough.
+	HealthTargetWireguard HealthTargetType = "wireguard"
+)
+
+// HealthTarget is a parsed health target.
+type HealthTarget struct {
+	Type HealthTargetType
+	// Address is the host or host:port for the TCP type, the host for
+	// the ICMP type, the hostname for the DNS type, the URL for the HTTP
+	// type and is empty for the Wireguard type.
+	Address string
+	// HTTPExpectedStatus is the status code expected for the HTTP type.
+	// It is 0 if any 2xx status code is expected.
+	HTTPExpectedStatus uint16
+	// HTTPExpectedBody is a substring expected in the response body
+	// for the HTTP type. It is empty if the body is not checked.
+	HTTPExpectedBody string
+}
+
+var (
+	ErrHealthTargetTypeNotValid    = errors.New("health target type is not valid")
+	ErrHealthTargetAddressNotValid = errors.New("health target address is not valid")
+	ErrHealthTargetOptionNotValid  = errors.New("health target option is not valid")
+)
+
+// ParseHealthTarget parses a health target string of the form
+// `[type://]address`, where the type defaults to tcp. For example:
+// `cloudflare.com:443`, `icmp://1.1.1.1`, `dns://github.com`,
+// `https://www.google.com/generate_204` and `wireguard://`.
+// HTTP targets can set their expected status code and body substring
+// in the URL fragment, which is not sent to the server, for example
+// `https://example.com/status#status=200&body=connected`.
+func ParseHealthTarget(s string) (target HealthTarget, err error) {
+	scheme, address, found := strings.Cut(s, "://")
+	if !found {
+		scheme, address = string(HealthTargetTCP), s
+	}
+
+	switch HealthTargetType(strings.ToLower(scheme)) {
+	case HealthTargetTCP:
+		target.Type = HealthTargetTCP
+		if address == "" || strings.Contains(address, "/") {
+			return HealthTarget{}, fmt.Errorf("%w: %s", ErrHealthTargetAddressNotValid, s)
+		}
+	case HealthTargetICMP, HealthTargetDNS:
+		target.Type = HealthTargetType(strings.ToLower(scheme))
+		_, _, err := net.SplitHostPort(address)
+		if address == "" || strings.Contains(address, "/") || err == nil {
+			return HealthTarget{}, fmt.Errorf("%w: %s must be a host without port",
+				ErrHealthTargetAddressNotValid, s)
+		}
+	case "http", "https":
+		target.Type = HealthTargetHTTP
+		parsedURL, err := url.Parse(s)
+		if err != nil || parsedURL.Host == "" {
+			return HealthTarget{}, fmt.Errorf("%w: %s", ErrHealthTargetAddressNotValid, s)
+		}
+		address, _, _ = strings.Cut(s, "#")
+		target.HTTPExpectedStatus, target.HTTPExpectedBody, err = parseHTTPExpectations(
+			parsedURL.EscapedFragment())
+		if err != nil {
+			return HealthTarget{}, fmt.Errorf("%w: %s: %w", ErrHealthTargetOptionNotValid, s, err)
+		}
+	case HealthTargetWireguard:
+		target.Type = HealthTargetWireguard
+		if address != "" {
+			return HealthTarget{}, fmt.Errorf("%w: %s must have no address",
+				ErrHealthTargetAddressNotValid, s)
+		}
+	default:
+		return HealthTarget{}, fmt.Errorf("%w: %s", ErrHealthTargetTypeNotValid, scheme)
+	}
+
+	target.Address = address
+	return target, nil
+}
+
+var (
+	errHTTPExpectationUnknown = errors.New("unknown option")
+	errHTTPExpectedStatus     = errors.New("expected status is not a valid status code")
+)
+
+// parseHTTPExpectations parses the expected status code and body
+// substring from the fragment of an HTTP target URL, of the form
+// `status=204&body=connected` where both options are optional.
+func parseHTTPExpectations(fragment string) (status uint16, body string, err error) {
+	values, err := url.ParseQuery(fragment)
+	if err != nil {
+		return 0, "", err
+	}
+
+	for key := range values {
+		switch key {
+		case "status":
+			const minStatus, maxStatus = 100, 599
+			parsed, err := strconv.ParseUint(values.Get(key), 10, 16)
+			if err != nil || parsed < minStatus || parsed > maxStatus {
+				return 0, "", fmt.Errorf("%w: %s", errHTTPExpectedStatus, values.Get(key))
+			}
+			status = uint16(parsed)
+		case "body":
+			body = values.Get(key)
+		default:
+			return 0, "", fmt.Errorf("%w: %s", errHTTPExpectationUnknown, key)
+		}
+	}
+	return status, body, nil
+}
+
+func healthTargetsHaveType(targets []string, targetType HealthTargetType) bool {
+	for _, s := range targets {
+		target, err := ParseHealthTarget(s)
+		if err == nil && target.Type == targetType {
+			return true
+		}
+	}
+	return false
+}
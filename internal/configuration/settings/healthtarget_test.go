@@ -0,0 +1,98 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseHealthTarget(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s          string
+		target     HealthTarget
+		errWrapped error
+		errMessage string
+	}{
+		"tcp_without_type": {
+			s:      "cloudflare.com:443",
+			target: HealthTarget{Type: HealthTargetTCP, Address: "cloudflare.com:443"},
+		},
+		"tcp_with_type": {
+			s:      "tcp://1.1.1.1:53",
+			target: HealthTarget{Type: HealthTargetTCP, Address: "1.1.1.1:53"},
+		},
+		"icmp": {
+			s:      "ICMP://1.1.1.1",
+			target: HealthTarget{Type: HealthTargetICMP, Address: "1.1.1.1"},
+		},
+		"icmp_with_port": {
+			s:          "icmp://1.1.1.1:53",
+			errWrapped: ErrHealthTargetAddressNotValid,
+			errMessage: "health target address is not valid: icmp://1.1.1.1:53 must be a host without port",
+		},
+		"dns": {
+			s:      "dns://github.com",
+			target: HealthTarget{Type: HealthTargetDNS, Address: "github.com"},
+		},
+		"https": {
+			s:      "https://www.google.com/generate_204",
+			target: HealthTarget{Type: HealthTargetHTTP, Address: "https://www.google.com/generate_204"},
+		},
+		"https_with_expectations": {
+			s: "https://example.com/status?q=1#status=200&body=connected%20to%20VPN",
+			target: HealthTarget{
+				Type:               HealthTargetHTTP,
+				Address:            "https://example.com/status?q=1",
+				HTTPExpectedStatus: 200,
+				HTTPExpectedBody:   "connected to VPN",
+			},
+		},
+		"https_with_invalid_status": {
+			s:          "https://example.com#status=2000",
+			errWrapped: ErrHealthTargetOptionNotValid,
+			errMessage: "health target option is not valid: https://example.com#status=2000: " +
+				"expected status is not a valid status code: 2000",
+		},
+		"https_with_unknown_option": {
+			s:          "https://example.com#method=POST",
+			errWrapped: ErrHealthTargetOptionNotValid,
+			errMessage: "health target option is not valid: https://example.com#method=POST: " +
+				"unknown option: method",
+		},
+		"http_without_host": {
+			s:          "http://",
+			errWrapped: ErrHealthTargetAddressNotValid,
+			errMessage: "health target address is not valid: http://",
+		},
+		"wireguard": {
+			s:      "wireguard://",
+			target: HealthTarget{Type: HealthTargetWireguard},
+		},
+		"wireguard_with_address": {
+			s:          "wireguard://wg0",
+			errWrapped: ErrHealthTargetAddressNotValid,
+			errMessage: "health target address is not valid: wireguard://wg0 must have no address",
+		},
+		"unknown_type": {
+			s:          "udp://1.1.1.1:53",
+			errWrapped: ErrHealthTargetTypeNotValid,
+			errMessage: "health target type is not valid: udp",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			target, err := ParseHealthTarget(testCase.s)
+
+			assert.Equal(t, testCase.target, target)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
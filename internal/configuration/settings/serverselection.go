@@ -70,14 +70,47 @@ type ServerSelection struct { //nolint:maligned
 	// TorOnly is true if VPN servers without tor should
 	// be filtered. This is used with ProtonVPN.
 	TorOnly *bool `json:"tor_only"`
+	// MaxLoad is the maximum server load percentage to filter
+	// VPN servers with. Servers without load information are
+	// not filtered. It defaults to 0 meaning servers are not
+	// filtered by load. This is used with NordVPN, ProtonVPN
+	// and Surfshark.
+	MaxLoad uint8 `json:"max_load"`
 	// OpenVPN contains settings to select OpenVPN servers
 	// and the final connection.
 	OpenVPN OpenVPNSelection `json:"openvpn"`
 	// Wireguard contains settings to select Wireguard servers
 	// and the final connection.
 	Wireguard WireguardSelection `json:"wireguard"`
+	// Strategy is the strategy to pick a connection amongst
+	// the filtered servers, and can be 'random', 'lowest-latency',
+	// 'least-loaded', 'round-robin' or 'sticky'. It defaults to 'random' and
+	// cannot be the empty string in the internal state.
+	Strategy string `json:"strategy"`
+	// LatencyProbe is the probe type to measure the latency
+	// of servers for the 'lowest-latency' strategy, and can be
+	// 'icmp' or 'tcp'. It defaults to 'icmp' and cannot be the
+	// empty string in the internal state.
+	LatencyProbe string `json:"latency_probe"`
+	// LatencySampleSize is the maximum number of candidate
+	// connections to probe for the 'lowest-latency' strategy.
+	// It defaults to 10 and cannot be zero in the internal state.
+	LatencySampleSize uint `json:"latency_sample_size"`
 }
 
+const (
+	SelectionStrategyRandom        = "random"
+	SelectionStrategyLowestLatency = "lowest-latency"
+	SelectionStrategyLeastLoaded   = "least-loaded"
+	SelectionStrategyRoundRobin    = "round-robin"
+	SelectionStrategySticky        = "sticky"
+)
+
+const (
+	LatencyProbeICMP = "icmp"
+	LatencyProbeTCP  = "tcp"
+)
+
 var (
 	ErrOwnedOnlyNotSupported       = errors.New("owned only filter is not supported")
 	ErrFreeOnlyNotSupported        = errors.New("free only filter is not supported")
@@ -88,6 +121,9 @@ var (
 	ErrFreePremiumBothSet          = errors.New("free only and premium only filters are both set")
 	ErrSecureCoreOnlyNotSupported  = errors.New("secure core only filter is not supported")
 	ErrTorOnlyNotSupported         = errors.New("tor only filter is not supported")
+	ErrMaxLoadNotSupported         = errors.New("maximum load filter is not supported")
+	ErrMaxLoadNotValid             = errors.New("maximum load is not valid")
+	ErrLeastLoadedNotSupported     = errors.New("least loaded selection strategy is not supported")
 )
 
 func (ss *ServerSelection) validate(vpnServiceProvider string,
@@ -127,6 +163,28 @@ func (ss *ServerSelection) validate(vpnServiceProvider string,
 		return fmt.Errorf("for VPN service provider %s: %w", vpnServiceProvider, err)
 	}
 
+	err = validate.IsOneOf(ss.Strategy, SelectionStrategyRandom,
+		SelectionStrategyLowestLatency, SelectionStrategyLeastLoaded,
+		SelectionStrategyRoundRobin, SelectionStrategySticky)
+	if err != nil {
+		return fmt.Errorf("selection strategy: %w", err)
+	}
+
+	if ss.Strategy == SelectionStrategyLeastLoaded &&
+		!providerPublishesLoad(vpnServiceProvider) {
+		return fmt.Errorf("for VPN service provider %s: %w",
+			vpnServiceProvider, ErrLeastLoadedNotSupported)
+	}
+
+	err = validate.IsOneOf(ss.LatencyProbe, LatencyProbeICMP, LatencyProbeTCP)
+	if err != nil {
+		return fmt.Errorf("latency probe: %w", err)
+	}
+
+	if ss.LatencySampleSize == 0 {
+		return fmt.Errorf("%w", ErrLatencySampleSizeNotValid)
+	}
+
 	if ss.VPN == vpn.OpenVPN {
 		err = ss.OpenVPN.validate(vpnServiceProvider)
 		if err != nil {
@@ -291,33 +349,46 @@ func validateFeatureFilters(settings ServerSelection, vpnServiceProvider string)
 		return fmt.Errorf("%w", ErrSecureCoreOnlyNotSupported)
 	case *settings.TorOnly && vpnServiceProvider != providers.Protonvpn:
 		return fmt.Errorf("%w", ErrTorOnlyNotSupported)
+	case settings.MaxLoad > 100: //nolint:mnd
+		return fmt.Errorf("%w: %d must be between 0 and 100", ErrMaxLoadNotValid, settings.MaxLoad)
+	case settings.MaxLoad > 0 && !providerPublishesLoad(vpnServiceProvider):
+		return fmt.Errorf("%w", ErrMaxLoadNotSupported)
 	default:
 		return nil
 	}
 }
 
+func providerPublishesLoad(vpnServiceProvider string) bool {
+	return helpers.IsOneOf(vpnServiceProvider,
+		providers.Nordvpn, providers.Protonvpn, providers.Surfshark)
+}
+
 func (ss *ServerSelection) copy() (copied ServerSelection) {
 	return ServerSelection{
-		VPN:             ss.VPN,
-		TargetIP:        ss.TargetIP,
-		Countries:       gosettings.CopySlice(ss.Countries),
-		Categories:      gosettings.CopySlice(ss.Categories),
-		Regions:         gosettings.CopySlice(ss.Regions),
-		Cities:          gosettings.CopySlice(ss.Cities),
-		ISPs:            gosettings.CopySlice(ss.ISPs),
-		Hostnames:       gosettings.CopySlice(ss.Hostnames),
-		Names:           gosettings.CopySlice(ss.Names),
-		Numbers:         gosettings.CopySlice(ss.Numbers),
-		OwnedOnly:       gosettings.CopyPointer(ss.OwnedOnly),
-		FreeOnly:        gosettings.CopyPointer(ss.FreeOnly),
-		PremiumOnly:     gosettings.CopyPointer(ss.PremiumOnly),
-		StreamOnly:      gosettings.CopyPointer(ss.StreamOnly),
-		SecureCoreOnly:  gosettings.CopyPointer(ss.SecureCoreOnly),
-		TorOnly:         gosettings.CopyPointer(ss.TorOnly),
-		PortForwardOnly: gosettings.CopyPointer(ss.PortForwardOnly),
-		MultiHopOnly:    gosettings.CopyPointer(ss.MultiHopOnly),
-		OpenVPN:         ss.OpenVPN.copy(),
-		Wireguard:       ss.Wireguard.copy(),
+		VPN:               ss.VPN,
+		TargetIP:          ss.TargetIP,
+		Countries:         gosettings.CopySlice(ss.Countries),
+		Categories:        gosettings.CopySlice(ss.Categories),
+		Regions:           gosettings.CopySlice(ss.Regions),
+		Cities:            gosettings.CopySlice(ss.Cities),
+		ISPs:              gosettings.CopySlice(ss.ISPs),
+		Hostnames:         gosettings.CopySlice(ss.Hostnames),
+		Names:             gosettings.CopySlice(ss.Names),
+		Numbers:           gosettings.CopySlice(ss.Numbers),
+		OwnedOnly:         gosettings.CopyPointer(ss.OwnedOnly),
+		FreeOnly:          gosettings.CopyPointer(ss.FreeOnly),
+		PremiumOnly:       gosettings.CopyPointer(ss.PremiumOnly),
+		StreamOnly:        gosettings.CopyPointer(ss.StreamOnly),
+		SecureCoreOnly:    gosettings.CopyPointer(ss.SecureCoreOnly),
+		TorOnly:           gosettings.CopyPointer(ss.TorOnly),
+		MaxLoad:           ss.MaxLoad,
+		PortForwardOnly:   gosettings.CopyPointer(ss.PortForwardOnly),
+		MultiHopOnly:      gosettings.CopyPointer(ss.MultiHopOnly),
+		OpenVPN:           ss.OpenVPN.copy(),
+		Wireguard:         ss.Wireguard.copy(),
+		Strategy:          ss.Strategy,
+		LatencyProbe:      ss.LatencyProbe,
+		LatencySampleSize: ss.LatencySampleSize,
 	}
 }
 
@@ -338,10 +409,14 @@ func (ss *ServerSelection) overrideWith(other ServerSelection) {
 	ss.StreamOnly = gosettings.OverrideWithPointer(ss.StreamOnly, other.StreamOnly)
 	ss.SecureCoreOnly = gosettings.OverrideWithPointer(ss.SecureCoreOnly, other.SecureCoreOnly)
 	ss.TorOnly = gosettings.OverrideWithPointer(ss.TorOnly, other.TorOnly)
+	ss.MaxLoad = gosettings.OverrideWithComparable(ss.MaxLoad, other.MaxLoad)
 	ss.MultiHopOnly = gosettings.OverrideWithPointer(ss.MultiHopOnly, other.MultiHopOnly)
 	ss.PortForwardOnly = gosettings.OverrideWithPointer(ss.PortForwardOnly, other.PortForwardOnly)
 	ss.OpenVPN.overrideWith(other.OpenVPN)
 	ss.Wireguard.overrideWith(other.Wireguard)
+	ss.Strategy = gosettings.OverrideWithComparable(ss.Strategy, other.Strategy)
+	ss.LatencyProbe = gosettings.OverrideWithComparable(ss.LatencyProbe, other.LatencyProbe)
+	ss.LatencySampleSize = gosettings.OverrideWithComparable(ss.LatencySampleSize, other.LatencySampleSize)
 }
 
 func (ss *ServerSelection) setDefaults(vpnProvider string, portForwardingEnabled bool) {
@@ -362,6 +437,10 @@ func (ss *ServerSelection) setDefaults(vpnProvider string, portForwardingEnabled
 	ss.PortForwardOnly = gosettings.DefaultPointer(ss.PortForwardOnly, defaultPortForwardOnly)
 	ss.OpenVPN.setDefaults(vpnProvider)
 	ss.Wireguard.setDefaults()
+	ss.Strategy = gosettings.DefaultComparable(ss.Strategy, SelectionStrategyRandom)
+	ss.LatencyProbe = gosettings.DefaultComparable(ss.LatencyProbe, LatencyProbeICMP)
+	const defaultLatencySampleSize = 10
+	ss.LatencySampleSize = gosettings.DefaultComparable(ss.LatencySampleSize, defaultLatencySampleSize)
 }
 
 func (ss ServerSelection) String() string {
@@ -442,6 +521,16 @@ func (ss ServerSelection) toLinesNode() (node *gotree.Node) {
 		node.Appendf("Port forwarding only servers: yes")
 	}
 
+	if ss.MaxLoad > 0 {
+		node.Appendf("Maximum server load: %d%%", ss.MaxLoad)
+	}
+
+	node.Appendf("Selection strategy: %s", ss.Strategy)
+	if ss.Strategy == SelectionStrategyLowestLatency {
+		node.Appendf("Latency probe: %s", ss.LatencyProbe)
+		node.Appendf("Latency sample size: %d", ss.LatencySampleSize)
+	}
+
 	if ss.VPN == vpn.OpenVPN {
 		node.AppendNode(ss.OpenVPN.toLinesNode())
 	} else {
@@ -535,6 +624,19 @@ func (ss *ServerSelection) read(r *reader.Reader,
 		return err
 	}
 
+	// NordVPN, ProtonVPN and Surfshark only
+	ss.MaxLoad, err = r.Uint8("SERVER_MAX_LOAD")
+	if err != nil {
+		return err
+	}
+
+	ss.Strategy = r.String("SERVER_SELECTION_STRATEGY")
+	ss.LatencyProbe = r.String("SERVER_LATENCY_PROBE")
+	ss.LatencySampleSize, err = r.Uint("SERVER_LATENCY_SAMPLE_SIZE")
+	if err != nil {
+		return err
+	}
+
 	err = ss.OpenVPN.read(r)
 	if err != nil {
 		return err
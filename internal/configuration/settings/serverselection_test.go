@@ -0,0 +1,28 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/constants/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServerSelection_copy_overrideWith(t *testing.T) {
+	t.Parallel()
+
+	original := ServerSelection{
+		Countries:         []string{"sweden"},
+		MaxLoad:           50,
+		Strategy:          "least-loaded",
+		LatencySampleSize: 3,
+	}
+	original.setDefaults(providers.Nordvpn, false)
+
+	copied := original.copy()
+	assert.Equal(t, original, copied)
+
+	var overridden ServerSelection
+	overridden.setDefaults(providers.Nordvpn, false)
+	overridden.overrideWith(copied)
+	assert.Equal(t, original, overridden)
+}
@@ -22,10 +22,18 @@ func Test_Settings_String(t *testing.T) {
 			settings: withDefaults(Settings{}),
 			s: `Settings summary:
 ├── VPN settings:
+|   ├── Failed server cooldown: 10m0s
+|   ├── Server rotation settings:
+|   |   └── Enabled: no
+|   ├── Failover settings:
+|   |   └── Enabled: no
+|   ├── Sticky connection settings:
+|   |   └── Enabled: no
 |   ├── VPN provider settings:
 |   |   ├── Name: private internet access
 |   |   └── Server selection settings:
 |   |       ├── VPN type: openvpn
+|   |       ├── Selection strategy: random
 |   |       └── OpenVPN server selection settings:
 |   |           ├── Protocol: UDP
 |   |           └── Private Internet Access encryption preset: strong
@@ -40,6 +48,10 @@ func Test_Settings_String(t *testing.T) {
 ├── DNS settings:
 |   ├── Keep existing nameserver(s): no
 |   ├── DNS server address to use: 127.0.0.1
+|   ├── Encrypted DNS server settings:
+|   |   └── Enabled: no
+|   ├── DNS leak test settings:
+|   |   └── Enabled: no
 |   └── DNS over TLS settings:
 |       ├── Enabled: yes
 |       ├── Update period: every 24h0m0s
@@ -47,10 +59,17 @@ func Test_Settings_String(t *testing.T) {
 |       |   └── Cloudflare
 |       ├── Caching: yes
 |       ├── IPv6: no
-|       └── DNS filtering settings:
-|           ├── Block malicious: yes
-|           ├── Block ads: no
-|           └── Block surveillance: yes
+|       ├── DNS filtering settings:
+|       |   ├── Block malicious: yes
+|       |   ├── Block ads: no
+|       |   ├── Block surveillance: yes
+|       |   ├── Block lists cache file: /gluetun/dns/blocklists.json
+|       |   └── DNS rebinding protection settings:
+|       |       └── Enabled: no
+|       ├── DNS query log settings:
+|       |   └── Enabled: no
+|       └── DNSSEC validation settings:
+|           └── Enabled: no
 ├── Firewall settings:
 |   └── Enabled: yes
 ├── Log settings:
@@ -61,6 +80,8 @@ func Test_Settings_String(t *testing.T) {
 |   ├── Duration to wait after success: 5s
 |   ├── Read header timeout: 100ms
 |   ├── Read timeout: 500ms
+|   ├── IP leak check settings:
+|   |   └── Enabled: no
 |   └── VPN wait durations:
 |       ├── Initial duration: 6s
 |       └── Additional duration: 5s
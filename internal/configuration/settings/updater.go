@@ -31,6 +31,11 @@ type Updater struct {
 	// Providers is the list of VPN service providers
 	// to update server information for.
 	Providers []string
+	// LoadPeriod is the period to refresh the server loads
+	// of the providers publishing them, in memory only.
+	// It can be set to 0 to disable refreshing server loads,
+	// and cannot be nil in the internal state.
+	LoadPeriod *time.Duration
 }
 
 func (u Updater) Validate() (err error) {
@@ -40,6 +45,11 @@ func (u Updater) Validate() (err error) {
 			ErrUpdaterPeriodTooSmall, *u.Period, minPeriod)
 	}
 
+	if *u.LoadPeriod > 0 && *u.LoadPeriod < minPeriod {
+		return fmt.Errorf("%w: %s must be larger than %s",
+			ErrUpdaterLoadPeriodTooSmall, *u.LoadPeriod, minPeriod)
+	}
+
 	if u.MinRatio <= 0 || u.MinRatio > 1 {
 		return fmt.Errorf("%w: %.2f must be between 0+ and 1",
 			ErrMinRatioNotValid, u.MinRatio)
@@ -62,6 +72,7 @@ func (u *Updater) copy() (copied Updater) {
 		DNSAddress: u.DNSAddress,
 		MinRatio:   u.MinRatio,
 		Providers:  gosettings.CopySlice(u.Providers),
+		LoadPeriod: gosettings.CopyPointer(u.LoadPeriod),
 	}
 }
 
@@ -73,6 +84,7 @@ func (u *Updater) overrideWith(other Updater) {
 	u.DNSAddress = gosettings.OverrideWithComparable(u.DNSAddress, other.DNSAddress)
 	u.MinRatio = gosettings.OverrideWithComparable(u.MinRatio, other.MinRatio)
 	u.Providers = gosettings.OverrideWithSlice(u.Providers, other.Providers)
+	u.LoadPeriod = gosettings.OverrideWithPointer(u.LoadPeriod, other.LoadPeriod)
 }
 
 func (u *Updater) SetDefaults(vpnProvider string) {
@@ -87,6 +99,8 @@ func (u *Updater) SetDefaults(vpnProvider string) {
 	if len(u.Providers) == 0 && vpnProvider != providers.Custom {
 		u.Providers = []string{vpnProvider}
 	}
+
+	u.LoadPeriod = gosettings.DefaultPointer(u.LoadPeriod, 0)
 }
 
 func (u Updater) String() string {
@@ -94,14 +108,19 @@ func (u Updater) String() string {
 }
 
 func (u Updater) toLinesNode() (node *gotree.Node) {
-	if *u.Period == 0 || len(u.Providers) == 0 {
+	if (*u.Period == 0 && *u.LoadPeriod == 0) || len(u.Providers) == 0 {
 		return nil
 	}
 
 	node = gotree.New("Server data updater settings:")
-	node.Appendf("Update period: %s", *u.Period)
-	node.Appendf("DNS address: %s", u.DNSAddress)
-	node.Appendf("Minimum ratio: %.1f", u.MinRatio)
+	if *u.Period > 0 {
+		node.Appendf("Update period: %s", *u.Period)
+		node.Appendf("DNS address: %s", u.DNSAddress)
+		node.Appendf("Minimum ratio: %.1f", u.MinRatio)
+	}
+	if *u.LoadPeriod > 0 {
+		node.Appendf("Server load refresh period: %s", *u.LoadPeriod)
+	}
 	node.Appendf("Providers to update: %s", strings.Join(u.Providers, ", "))
 
 	return node
@@ -125,6 +144,11 @@ func (u *Updater) read(r *reader.Reader) (err error) {
 
 	u.Providers = r.CSV("UPDATER_VPN_SERVICE_PROVIDERS")
 
+	u.LoadPeriod, err = r.DurationPtr("UPDATER_LOAD_PERIOD")
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
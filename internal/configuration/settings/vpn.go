@@ -2,6 +2,7 @@ package settings
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/qdm12/gluetun/internal/constants/vpn"
 	"github.com/qdm12/gosettings"
@@ -18,6 +19,23 @@ type VPN struct {
 	Provider  Provider  `json:"provider"`
 	OpenVPN   OpenVPN   `json:"openvpn"`
 	Wireguard Wireguard `json:"wireguard"`
+	// FailedServerCooldown is the duration during which a VPN server
+	// which failed is excluded from the server selection, unless no
+	// other server matches the selection. It is disabled if set to 0,
+	// and cannot be nil in the internal state.
+	FailedServerCooldown *time.Duration `json:"failed_server_cooldown"`
+	// Rotation contains settings to periodically reconnect
+	// to another server matching the server selection.
+	Rotation VPNRotation `json:"rotation"`
+	// Failover contains settings to fail over to backup
+	// VPN configurations when the current one keeps failing.
+	Failover VPNFailover `json:"failover"`
+	// StickyConnection contains settings to persist the last
+	// working connection and prefer it on the next start.
+	StickyConnection VPNStickyConnection `json:"sticky_connection"`
+	// Commands contains settings for commands to run
+	// when the VPN tunnel goes up and down.
+	Commands VPNCommands `json:"commands"`
 }
 
 // TODO v4 remove pointer for receiver (because of Surfshark).
@@ -45,6 +63,26 @@ func (v *VPN) Validate(filterChoicesGetter FilterChoicesGetter, ipv6Supported bo
 		}
 	}
 
+	err = v.Rotation.validate()
+	if err != nil {
+		return fmt.Errorf("rotation settings: %w", err)
+	}
+
+	err = v.Failover.validate(filterChoicesGetter, ipv6Supported, warner)
+	if err != nil {
+		return fmt.Errorf("failover settings: %w", err)
+	}
+
+	err = v.StickyConnection.validate()
+	if err != nil {
+		return fmt.Errorf("sticky connection settings: %w", err)
+	}
+
+	err = v.Commands.validate()
+	if err != nil {
+		return fmt.Errorf("commands settings: %w", err)
+	}
+
 	return nil
 }
 
@@ -54,6 +92,12 @@ func (v *VPN) Copy() (copied VPN) {
 		Provider:  v.Provider.copy(),
 		OpenVPN:   v.OpenVPN.copy(),
 		Wireguard: v.Wireguard.copy(),
+
+		FailedServerCooldown: gosettings.CopyPointer(v.FailedServerCooldown),
+		Rotation:             v.Rotation.copy(),
+		Failover:             v.Failover.copy(),
+		StickyConnection:     v.StickyConnection.copy(),
+		Commands:             v.Commands.copy(),
 	}
 }
 
@@ -62,6 +106,11 @@ func (v *VPN) OverrideWith(other VPN) {
 	v.Provider.overrideWith(other.Provider)
 	v.OpenVPN.overrideWith(other.OpenVPN)
 	v.Wireguard.overrideWith(other.Wireguard)
+	v.FailedServerCooldown = gosettings.OverrideWithPointer(v.FailedServerCooldown, other.FailedServerCooldown)
+	v.Rotation.overrideWith(other.Rotation)
+	v.Failover.overrideWith(other.Failover)
+	v.StickyConnection.overrideWith(other.StickyConnection)
+	v.Commands.overrideWith(other.Commands)
 }
 
 func (v *VPN) setDefaults() {
@@ -69,6 +118,12 @@ func (v *VPN) setDefaults() {
 	v.Provider.setDefaults()
 	v.OpenVPN.setDefaults(v.Provider.Name)
 	v.Wireguard.setDefaults(v.Provider.Name)
+	const defaultFailedServerCooldown = 10 * time.Minute
+	v.FailedServerCooldown = gosettings.DefaultPointer(v.FailedServerCooldown, defaultFailedServerCooldown)
+	v.Rotation.setDefaults()
+	v.Failover.setDefaults()
+	v.StickyConnection.setDefaults()
+	v.Commands.setDefaults()
 }
 
 func (v VPN) String() string {
@@ -78,6 +133,16 @@ func (v VPN) String() string {
 func (v VPN) toLinesNode() (node *gotree.Node) {
 	node = gotree.New("VPN settings:")
 
+	failedServerCooldown := "disabled"
+	if *v.FailedServerCooldown > 0 {
+		failedServerCooldown = v.FailedServerCooldown.String()
+	}
+	node.Appendf("Failed server cooldown: %s", failedServerCooldown)
+	node.AppendNode(v.Rotation.toLinesNode())
+	node.AppendNode(v.Failover.toLinesNode())
+	node.AppendNode(v.StickyConnection.toLinesNode())
+	node.AppendNode(v.Commands.toLinesNode())
+
 	node.AppendNode(v.Provider.toLinesNode())
 
 	if v.Type == vpn.OpenVPN {
@@ -107,5 +172,30 @@ func (v *VPN) read(r *reader.Reader) (err error) {
 		return fmt.Errorf("wireguard: %w", err)
 	}
 
+	v.FailedServerCooldown, err = r.DurationPtr("VPN_FAILED_SERVER_COOLDOWN")
+	if err != nil {
+		return err
+	}
+
+	err = v.Rotation.read(r)
+	if err != nil {
+		return fmt.Errorf("rotation: %w", err)
+	}
+
+	err = v.Failover.read(r)
+	if err != nil {
+		return fmt.Errorf("failover: %w", err)
+	}
+
+	err = v.StickyConnection.read(r)
+	if err != nil {
+		return fmt.Errorf("sticky connection: %w", err)
+	}
+
+	err = v.Commands.read(r)
+	if err != nil {
+		return fmt.Errorf("commands: %w", err)
+	}
+
 	return nil
 }
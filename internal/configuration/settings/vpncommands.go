@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/command"
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// VPNCommands contains settings for commands to run
+// when the VPN tunnel goes up and down.
+type VPNCommands struct {
+	// Up is the command to run when the VPN tunnel is up.
+	// It can contain the template variables {{VPN_INTERFACE}},
+	// {{TUNNEL_IP}}, {{PUBLIC_IP}}, {{SERVER_NAME}} and
+	// {{SERVER_COUNTRY}}, which are replaced in each argument after
+	// the command is split into arguments. It can be the empty string
+	// to indicate not to run a command, and cannot be nil in the
+	// internal state.
+	Up *string `json:"up"`
+	// Down is the command to run before the VPN tunnel goes down,
+	// if it went up. It supports the same template variables as Up,
+	// with the values they had when the tunnel went up. It can be
+	// the empty string to indicate not to run a command, and cannot
+	// be nil in the internal state.
+	Down *string `json:"down"`
+	// Timeout is the maximum duration a command can run for.
+	// It cannot be nil in the internal state.
+	Timeout *time.Duration `json:"timeout"`
+}
+
+func (v VPNCommands) validate() (err error) {
+	if *v.Up != "" {
+		_, err = command.Split(*v.Up)
+		if err != nil {
+			return fmt.Errorf("up command: %w", err)
+		}
+	}
+
+	if *v.Down != "" {
+		_, err = command.Split(*v.Down)
+		if err != nil {
+			return fmt.Errorf("down command: %w", err)
+		}
+	}
+
+	const minTimeout = time.Second
+	if *v.Timeout < minTimeout {
+		return fmt.Errorf("%w: %s must be at least %s",
+			ErrVPNCommandTimeoutTooSmall, *v.Timeout, minTimeout)
+	}
+
+	return nil
+}
+
+func (v *VPNCommands) copy() (copied VPNCommands) {
+	return VPNCommands{
+		Up:      gosettings.CopyPointer(v.Up),
+		Down:    gosettings.CopyPointer(v.Down),
+		Timeout: gosettings.CopyPointer(v.Timeout),
+	}
+}
+
+func (v *VPNCommands) overrideWith(other VPNCommands) {
+	v.Up = gosettings.OverrideWithPointer(v.Up, other.Up)
+	v.Down = gosettings.OverrideWithPointer(v.Down, other.Down)
+	v.Timeout = gosettings.OverrideWithPointer(v.Timeout, other.Timeout)
+}
+
+func (v *VPNCommands) setDefaults() {
+	v.Up = gosettings.DefaultPointer(v.Up, "")
+	v.Down = gosettings.DefaultPointer(v.Down, "")
+	const defaultTimeout = 60 * time.Second
+	v.Timeout = gosettings.DefaultPointer(v.Timeout, defaultTimeout)
+}
+
+func (v VPNCommands) String() string {
+	return v.toLinesNode().String()
+}
+
+func (v VPNCommands) toLinesNode() (node *gotree.Node) {
+	if *v.Up == "" && *v.Down == "" {
+		return nil
+	}
+
+	node = gotree.New("Commands settings:")
+	if *v.Up != "" {
+		node.Appendf("Up command: %s", *v.Up)
+	}
+	if *v.Down != "" {
+		node.Appendf("Down command: %s", *v.Down)
+	}
+	node.Appendf("Timeout: %s", *v.Timeout)
+	return node
+}
+
+func (v *VPNCommands) read(r *reader.Reader) (err error) {
+	v.Up = r.Get("VPN_UP_COMMAND", reader.ForceLowercase(false))
+	v.Down = r.Get("VPN_DOWN_COMMAND", reader.ForceLowercase(false))
+	v.Timeout, err = r.DurationPtr("VPN_COMMAND_TIMEOUT")
+	if err != nil {
+		return err
+	}
+	return nil
+}
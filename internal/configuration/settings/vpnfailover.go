@@ -0,0 +1,258 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/validate"
+	"github.com/qdm12/gotree"
+)
+
+// VPNFailover contains settings to fail over to other
+// VPN configurations when the current one keeps failing.
+type VPNFailover struct {
+	// Backups is the ordered list of VPN configurations to fail
+	// over to, after the primary VPN configuration.
+	// It cannot be nil in the internal state.
+	Backups []VPNBackup `json:"backups"`
+	// Threshold is the number of consecutive connection or health
+	// failures after which the next VPN configuration is used.
+	// It cannot be nil or 0 in the internal state.
+	Threshold *uint `json:"threshold"`
+	// RetryPrimaryPeriod is the duration after which to try the primary
+	// VPN configuration again, once failed over to a backup one.
+	// It is disabled if set to 0, and cannot be nil in the internal state.
+	RetryPrimaryPeriod *time.Duration `json:"retry_primary_period"`
+}
+
+// VPNBackup is a backup VPN configuration used by the failover.
+type VPNBackup struct {
+	// Type is the VPN type and can only be
+	// 'openvpn' or 'wireguard'. It cannot be the
+	// empty string in the internal state.
+	Type      string    `json:"type"`
+	Provider  Provider  `json:"provider"`
+	OpenVPN   OpenVPN   `json:"openvpn"`
+	Wireguard Wireguard `json:"wireguard"`
+}
+
+// Enabled returns true if at least one backup VPN configuration is set.
+func (v VPNFailover) Enabled() bool {
+	return len(v.Backups) > 0
+}
+
+func (v *VPNFailover) validate(filterChoicesGetter FilterChoicesGetter,
+	ipv6Supported bool, warner Warner,
+) (err error) {
+	if *v.Threshold == 0 {
+		return ErrVPNFailoverThresholdZero
+	}
+
+	const minRetryPrimaryPeriod = time.Minute
+	if *v.RetryPrimaryPeriod > 0 && *v.RetryPrimaryPeriod < minRetryPrimaryPeriod {
+		return fmt.Errorf("%w: %s must be at least %s",
+			ErrVPNFailoverRetryPeriodTooSmall, *v.RetryPrimaryPeriod, minRetryPrimaryPeriod)
+	}
+
+	for i := range v.Backups {
+		err = v.Backups[i].validate(filterChoicesGetter, ipv6Supported, warner)
+		if err != nil {
+			return fmt.Errorf("backup %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *VPNFailover) copy() (copied VPNFailover) {
+	copied = VPNFailover{
+		Threshold:          gosettings.CopyPointer(v.Threshold),
+		RetryPrimaryPeriod: gosettings.CopyPointer(v.RetryPrimaryPeriod),
+	}
+	if v.Backups != nil {
+		copied.Backups = make([]VPNBackup, len(v.Backups))
+		for i := range v.Backups {
+			copied.Backups[i] = v.Backups[i].copy()
+		}
+	}
+	return copied
+}
+
+func (v *VPNFailover) overrideWith(other VPNFailover) {
+	if other.Backups != nil {
+		v.Backups = other.copy().Backups
+	}
+	v.Threshold = gosettings.OverrideWithPointer(v.Threshold, other.Threshold)
+	v.RetryPrimaryPeriod = gosettings.OverrideWithPointer(v.RetryPrimaryPeriod, other.RetryPrimaryPeriod)
+}
+
+func (v *VPNFailover) setDefaults() {
+	v.Backups = gosettings.DefaultSlice(v.Backups, []VPNBackup{})
+	for i := range v.Backups {
+		v.Backups[i].setDefaults()
+	}
+	const defaultThreshold = 3
+	v.Threshold = gosettings.DefaultPointer(v.Threshold, defaultThreshold)
+	const defaultRetryPrimaryPeriod = 30 * time.Minute
+	v.RetryPrimaryPeriod = gosettings.DefaultPointer(v.RetryPrimaryPeriod, defaultRetryPrimaryPeriod)
+}
+
+func (v VPNFailover) String() string {
+	return v.toLinesNode().String()
+}
+
+func (v VPNFailover) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("Failover settings:")
+	if !v.Enabled() {
+		node.Appendf("Enabled: no")
+		return node
+	}
+
+	node.Appendf("Consecutive failures threshold: %d", *v.Threshold)
+	retryPrimary := "disabled"
+	if *v.RetryPrimaryPeriod > 0 {
+		retryPrimary = "every " + v.RetryPrimaryPeriod.String()
+	}
+	node.Appendf("Retry primary VPN: %s", retryPrimary)
+	for _, backup := range v.Backups {
+		node.AppendNode(backup.toLinesNode())
+	}
+	return node
+}
+
+// read reads the backup VPN configurations from the keys prefixed
+// with VPN_FAILOVER_1_, VPN_FAILOVER_2_, etc. until no VPN service
+// provider is set for a prefix, for example VPN_FAILOVER_1_VPN_TYPE
+// or VPN_FAILOVER_1_WIREGUARD_PRIVATE_KEY.
+func (v *VPNFailover) read(r *reader.Reader) (err error) {
+	for i := 1; ; i++ {
+		prefix := "VPN_FAILOVER_" + strconv.Itoa(i) + "_"
+		prefixedReader := reader.New(reader.Settings{
+			Sources: []reader.Source{&prefixedSource{reader: r, prefix: prefix}},
+		})
+		if prefixedReader.Get("VPN_SERVICE_PROVIDER") == nil {
+			break
+		}
+
+		var backup VPNBackup
+		err = backup.read(prefixedReader)
+		if err != nil {
+			return fmt.Errorf("backup %d: %w", i, err)
+		}
+		v.Backups = append(v.Backups, backup)
+	}
+
+	v.Threshold, err = r.UintPtr("VPN_FAILOVER_THRESHOLD")
+	if err != nil {
+		return err
+	}
+
+	v.RetryPrimaryPeriod, err = r.DurationPtr("VPN_FAILOVER_RETRY_PRIMARY_PERIOD")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *VPNBackup) validate(filterChoicesGetter FilterChoicesGetter,
+	ipv6Supported bool, warner Warner,
+) (err error) {
+	validVPNTypes := []string{vpn.OpenVPN, vpn.Wireguard}
+	if err = validate.IsOneOf(v.Type, validVPNTypes...); err != nil {
+		return fmt.Errorf("%w: %w", ErrVPNTypeNotValid, err)
+	}
+
+	err = v.Provider.validate(v.Type, filterChoicesGetter, warner)
+	if err != nil {
+		return fmt.Errorf("provider settings: %w", err)
+	}
+
+	if v.Type == vpn.OpenVPN {
+		err := v.OpenVPN.validate(v.Provider.Name)
+		if err != nil {
+			return fmt.Errorf("OpenVPN settings: %w", err)
+		}
+	} else {
+		err := v.Wireguard.validate(v.Provider.Name, ipv6Supported)
+		if err != nil {
+			return fmt.Errorf("Wireguard settings: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v *VPNBackup) copy() (copied VPNBackup) {
+	return VPNBackup{
+		Type:      v.Type,
+		Provider:  v.Provider.copy(),
+		OpenVPN:   v.OpenVPN.copy(),
+		Wireguard: v.Wireguard.copy(),
+	}
+}
+
+func (v *VPNBackup) setDefaults() {
+	v.Type = gosettings.DefaultComparable(v.Type, vpn.OpenVPN)
+	v.Provider.setDefaults()
+	v.OpenVPN.setDefaults(v.Provider.Name)
+	v.Wireguard.setDefaults(v.Provider.Name)
+}
+
+func (v VPNBackup) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("VPN backup settings:")
+	node.Appendf("Type: %s", v.Type)
+	node.AppendNode(v.Provider.toLinesNode())
+	if v.Type == vpn.OpenVPN {
+		node.AppendNode(v.OpenVPN.toLinesNode())
+	} else {
+		node.AppendNode(v.Wireguard.toLinesNode())
+	}
+	return node
+}
+
+func (v *VPNBackup) read(r *reader.Reader) (err error) {
+	v.Type = r.String("VPN_TYPE")
+
+	err = v.Provider.read(r, v.Type)
+	if err != nil {
+		return fmt.Errorf("VPN provider: %w", err)
+	}
+
+	err = v.OpenVPN.read(r)
+	if err != nil {
+		return fmt.Errorf("OpenVPN: %w", err)
+	}
+
+	err = v.Wireguard.read(r)
+	if err != nil {
+		return fmt.Errorf("wireguard: %w", err)
+	}
+
+	return nil
+}
+
+// prefixedSource is a reader source getting values from
+// the reader given, using keys prefixed with prefix.
+type prefixedSource struct {
+	reader *reader.Reader
+	prefix string
+}
+
+func (p *prefixedSource) String() string { return "setting" }
+
+func (p *prefixedSource) Get(key string) (value string, isSet bool) {
+	valuePtr := p.reader.Get(key, reader.ForceLowercase(false), reader.AcceptEmpty(true))
+	if valuePtr == nil {
+		return "", false
+	}
+	return *valuePtr, true
+}
+
+func (p *prefixedSource) KeyTransform(key string) string {
+	return p.prefix + key
+}
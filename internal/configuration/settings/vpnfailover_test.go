@@ -0,0 +1,45 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/constants/providers"
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gosettings/reader/sources/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VPNFailover_read(t *testing.T) {
+	t.Parallel()
+
+	r := reader.New(reader.Settings{
+		Sources: []reader.Source{env.New(env.Settings{Environ: []string{
+			"VPN_SERVICE_PROVIDER=nordvpn",
+			"WIREGUARD_PRIVATE_KEY=primaryKey",
+			"VPN_FAILOVER_1_VPN_SERVICE_PROVIDER=Mullvad",
+			"VPN_FAILOVER_1_VPN_TYPE=wireguard",
+			"VPN_FAILOVER_1_WIREGUARD_PRIVATE_KEY=BackupKey",
+			"VPN_FAILOVER_1_SERVER_COUNTRIES=Sweden",
+			"VPN_FAILOVER_2_VPN_SERVICE_PROVIDER=surfshark",
+			"VPN_FAILOVER_4_VPN_SERVICE_PROVIDER=ivpn",
+			"VPN_FAILOVER_THRESHOLD=5",
+		}})},
+	})
+
+	var failover VPNFailover
+	err := failover.read(r)
+	require.NoError(t, err)
+
+	require.Len(t, failover.Backups, 2)
+	first := failover.Backups[0]
+	assert.Equal(t, vpn.Wireguard, first.Type)
+	assert.Equal(t, providers.Mullvad, first.Provider.Name)
+	assert.Equal(t, []string{"sweden"}, first.Provider.ServerSelection.Countries)
+	assert.Equal(t, ptrTo("BackupKey"), first.Wireguard.PrivateKey)
+	second := failover.Backups[1]
+	assert.Equal(t, providers.Surfshark, second.Provider.Name)
+	assert.Nil(t, second.Wireguard.PrivateKey)
+	assert.Equal(t, ptrTo(uint(5)), failover.Threshold)
+}
@@ -0,0 +1,112 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/cron"
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// VPNRotation contains settings to periodically reconnect
+// the VPN to another server matching the server selection.
+type VPNRotation struct {
+	// Interval is the duration between two rotations.
+	// It is disabled if set to 0, and cannot be nil
+	// in the internal state.
+	Interval *time.Duration `json:"interval"`
+	// Cron is a 5 fields cron expression defining when to rotate,
+	// evaluated in the container timezone. It is disabled if set to
+	// the empty string, and cannot be nil in the internal state.
+	// It cannot be set together with Interval.
+	Cron *string `json:"cron"`
+	// OnlyWhenIdle is true if a rotation should be skipped
+	// when the HTTP proxy has active connections.
+	// It cannot be nil in the internal state.
+	OnlyWhenIdle *bool `json:"only_when_idle"`
+}
+
+// Enabled returns true if the rotation interval
+// or cron expression is set.
+func (v VPNRotation) Enabled() bool {
+	return *v.Interval > 0 || *v.Cron != ""
+}
+
+func (v VPNRotation) validate() (err error) {
+	if *v.Interval > 0 && *v.Cron != "" {
+		return ErrVPNRotationIntervalAndCronSet
+	}
+
+	const minInterval = time.Minute
+	if *v.Interval > 0 && *v.Interval < minInterval {
+		return fmt.Errorf("%w: %s must be at least %s",
+			ErrVPNRotationIntervalTooSmall, *v.Interval, minInterval)
+	}
+
+	if *v.Cron != "" {
+		_, err = cron.Parse(*v.Cron)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrVPNRotationCronNotValid, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *VPNRotation) copy() (copied VPNRotation) {
+	return VPNRotation{
+		Interval:     gosettings.CopyPointer(v.Interval),
+		Cron:         gosettings.CopyPointer(v.Cron),
+		OnlyWhenIdle: gosettings.CopyPointer(v.OnlyWhenIdle),
+	}
+}
+
+func (v *VPNRotation) overrideWith(other VPNRotation) {
+	v.Interval = gosettings.OverrideWithPointer(v.Interval, other.Interval)
+	v.Cron = gosettings.OverrideWithPointer(v.Cron, other.Cron)
+	v.OnlyWhenIdle = gosettings.OverrideWithPointer(v.OnlyWhenIdle, other.OnlyWhenIdle)
+}
+
+func (v *VPNRotation) setDefaults() {
+	v.Interval = gosettings.DefaultPointer(v.Interval, 0)
+	v.Cron = gosettings.DefaultPointer(v.Cron, "")
+	v.OnlyWhenIdle = gosettings.DefaultPointer(v.OnlyWhenIdle, false)
+}
+
+func (v VPNRotation) String() string {
+	return v.toLinesNode().String()
+}
+
+func (v VPNRotation) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("Server rotation settings:")
+	if !v.Enabled() {
+		node.Appendf("Enabled: no")
+		return node
+	}
+
+	if *v.Interval > 0 {
+		node.Appendf("Interval: %s", *v.Interval)
+	} else {
+		node.Appendf("Cron expression: %s", *v.Cron)
+	}
+	node.Appendf("Only when HTTP proxy is idle: %s", gosettings.BoolToYesNo(v.OnlyWhenIdle))
+	return node
+}
+
+func (v *VPNRotation) read(r *reader.Reader) (err error) {
+	v.Interval, err = r.DurationPtr("VPN_ROTATION_INTERVAL")
+	if err != nil {
+		return err
+	}
+
+	v.Cron = r.Get("VPN_ROTATION_CRON")
+
+	v.OnlyWhenIdle, err = r.BoolPtr("VPN_ROTATION_ONLY_WHEN_IDLE")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/reader"
+	"github.com/qdm12/gotree"
+)
+
+// VPNStickyConnection contains settings to persist the last
+// working VPN connection, and to prefer it on the next start
+// if it still matches the server selection.
+type VPNStickyConnection struct {
+	// Enabled is true if the last working VPN connection
+	// should be persisted and preferred on the next start.
+	// It cannot be nil in the internal state.
+	Enabled *bool `json:"enabled"`
+	// Filepath is the path to the file storing the last working
+	// VPN connection. It cannot be nil in the internal state.
+	Filepath *string `json:"filepath"`
+}
+
+func (v VPNStickyConnection) validate() (err error) {
+	if !*v.Enabled {
+		return nil
+	}
+
+	_, err = filepath.Abs(*v.Filepath)
+	if err != nil {
+		return fmt.Errorf("filepath is not valid: %w", err)
+	}
+	return nil
+}
+
+func (v *VPNStickyConnection) copy() (copied VPNStickyConnection) {
+	return VPNStickyConnection{
+		Enabled:  gosettings.CopyPointer(v.Enabled),
+		Filepath: gosettings.CopyPointer(v.Filepath),
+	}
+}
+
+func (v *VPNStickyConnection) overrideWith(other VPNStickyConnection) {
+	v.Enabled = gosettings.OverrideWithPointer(v.Enabled, other.Enabled)
+	v.Filepath = gosettings.OverrideWithPointer(v.Filepath, other.Filepath)
+}
+
+func (v *VPNStickyConnection) setDefaults() {
+	v.Enabled = gosettings.DefaultPointer(v.Enabled, false)
+	const defaultFilepath = "/gluetun/last_connection.json"
+	v.Filepath = gosettings.DefaultPointer(v.Filepath, defaultFilepath)
+}
+
+func (v VPNStickyConnection) String() string {
+	return v.toLinesNode().String()
+}
+
+func (v VPNStickyConnection) toLinesNode() (node *gotree.Node) {
+	node = gotree.New("Sticky connection settings:")
+	if !*v.Enabled {
+		node.Appendf("Enabled: no")
+		return node
+	}
+
+	node.Appendf("Filepath: %s", *v.Filepath)
+	return node
+}
+
+func (v *VPNStickyConnection) read(r *reader.Reader) (err error) {
+	v.Enabled, err = r.BoolPtr("VPN_STICKY_CONNECTION")
+	if err != nil {
+		return err
+	}
+
+	v.Filepath = r.Get("VPN_STICKY_CONNECTION_FILEPATH", reader.ForceLowercase(false))
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package cron parses standard 5 fields cron expressions
+// and computes their next activation times.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// daysRestricted and weekdaysRestricted are true if the day of
+	// month and day of week fields are not `*`. If both are restricted,
+	// a day matches if either of the fields matches, as for the
+	// standard cron.
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+type fieldBounds struct {
+	name     string
+	min, max uint
+}
+
+var (
+	ErrFieldsCountNotValid = errors.New("number of fields is not valid")
+	ErrFieldNotValid       = errors.New("field is not valid")
+	ErrValueOutOfBounds    = errors.New("value is out of bounds")
+	ErrStepNotValid        = errors.New("step is not valid")
+	ErrRangeNotValid       = errors.New("range is not valid")
+)
+
+// Parse parses a cron expression made of the 5 fields minute,
+// hour, day of month, month and day of week, each field supporting
+// `*`, values, ranges `a-b`, steps `*/n` or `a-b/n` and lists `a,b`.
+// Days of week go from 0 (Sunday) to 7 (Sunday).
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily,
+// @midnight and @hourly are also supported.
+func Parse(expression string) (schedule Schedule, err error) {
+	expression = strings.TrimSpace(expression)
+	switch expression {
+	case "@yearly", "@annually":
+		expression = "0 0 1 1 *"
+	case "@monthly":
+		expression = "0 0 1 * *"
+	case "@weekly":
+		expression = "0 0 * * 0"
+	case "@daily", "@midnight":
+		expression = "0 0 * * *"
+	case "@hourly":
+		expression = "0 * * * *"
+	}
+
+	fields := strings.Fields(expression)
+	const expectedFields = 5
+	if len(fields) != expectedFields {
+		return schedule, fmt.Errorf("%w: expected %d fields but got %d",
+			ErrFieldsCountNotValid, expectedFields, len(fields))
+	}
+
+	bounds := [...]fieldBounds{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12},
+		{name: "day of week", min: 0, max: 7},
+	}
+	bitsets := [...]*uint64{
+		&schedule.minutes, &schedule.hours, &schedule.days,
+		&schedule.months, &schedule.weekdays,
+	}
+	for i, field := range fields {
+		*bitsets[i], err = parseField(field, bounds[i])
+		if err != nil {
+			return schedule, fmt.Errorf("%s field: %w", bounds[i].name, err)
+		}
+	}
+
+	const sundayBit, sunday7Bit = 1 << 0, 1 << 7
+	if schedule.weekdays&sunday7Bit != 0 {
+		schedule.weekdays |= sundayBit
+	}
+	schedule.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	schedule.weekdaysRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+func parseField(field string, bounds fieldBounds) (bitset uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		partBitset, err := parsePart(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bitset |= partBitset
+	}
+	return bitset, nil
+}
+
+func parsePart(part string, bounds fieldBounds) (bitset uint64, err error) {
+	rangeString, stepString, hasStep := strings.Cut(part, "/")
+	step := uint(1)
+	if hasStep {
+		step, err = parseValue(stepString)
+		if err != nil || step == 0 {
+			return 0, fmt.Errorf("%w: %q", ErrStepNotValid, stepString)
+		}
+	}
+
+	var start, end uint
+	switch {
+	case rangeString == "*":
+		start, end = bounds.min, bounds.max
+	case strings.Contains(rangeString, "-"):
+		startString, endString, _ := strings.Cut(rangeString, "-")
+		start, err = parseBoundedValue(startString, bounds)
+		if err != nil {
+			return 0, err
+		}
+		end, err = parseBoundedValue(endString, bounds)
+		if err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("%w: %q", ErrRangeNotValid, rangeString)
+		}
+	default:
+		start, err = parseBoundedValue(rangeString, bounds)
+		if err != nil {
+			return 0, err
+		}
+		end = start
+		if hasStep {
+			end = bounds.max
+		}
+	}
+
+	for value := start; value <= end; value += step {
+		bitset |= 1 << value
+	}
+	return bitset, nil
+}
+
+func parseBoundedValue(s string, bounds fieldBounds) (value uint, err error) {
+	value, err = parseValue(s)
+	if err != nil {
+		return 0, err
+	}
+	if value < bounds.min || value > bounds.max {
+		return 0, fmt.Errorf("%w: %d must be between %d and %d",
+			ErrValueOutOfBounds, value, bounds.min, bounds.max)
+	}
+	return value, nil
+}
+
+func parseValue(s string) (value uint, err error) {
+	const base, bitSize = 10, 8
+	parsed, err := strconv.ParseUint(s, base, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrFieldNotValid, s)
+	}
+	return uint(parsed), nil
+}
+
+// Next returns the first activation time of the schedule strictly
+// after the time given, with the location of the time given.
+// It returns the zero time if no activation time is found within
+// the next 5 years, for example for the 30th of February.
+func (s Schedule) Next(t time.Time) (next time.Time) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	const maxYears = 5
+	yearLimit := t.Year() + maxYears
+
+	for t.Year() <= yearLimit {
+		switch {
+		case !has(s.months, t.Month()):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hours, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minutes, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	dayMatches := has(s.days, t.Day())
+	weekdayMatches := has(s.weekdays, t.Weekday())
+	if s.daysRestricted && s.weekdaysRestricted {
+		return dayMatches || weekdayMatches
+	}
+	return dayMatches && weekdayMatches
+}
+
+func has[T ~int](bitset uint64, value T) bool {
+	return bitset&(1<<uint(value)) != 0
+}
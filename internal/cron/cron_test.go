@@ -0,0 +1,131 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		expression string
+		errWrapped error
+		errMessage string
+	}{
+		"empty": {
+			errWrapped: ErrFieldsCountNotValid,
+			errMessage: "number of fields is not valid: expected 5 fields but got 0",
+		},
+		"malformed value": {
+			expression: "a * * * *",
+			errWrapped: ErrFieldNotValid,
+			errMessage: `minute field: field is not valid: "a"`,
+		},
+		"value out of bounds": {
+			expression: "0 24 * * *",
+			errWrapped: ErrValueOutOfBounds,
+			errMessage: "hour field: value is out of bounds: 24 must be between 0 and 23",
+		},
+		"zero step": {
+			expression: "*/0 * * * *",
+			errWrapped: ErrStepNotValid,
+			errMessage: `minute field: step is not valid: "0"`,
+		},
+		"reversed range": {
+			expression: "0 0 * * 5-1",
+			errWrapped: ErrRangeNotValid,
+			errMessage: `day of week field: range is not valid: "5-1"`,
+		},
+		"valid": {
+			expression: "*/15 1-5,22 1 */2 0,7",
+		},
+		"descriptor": {
+			expression: "@daily",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(testCase.expression)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+func Test_Schedule_Next(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		expression string
+		time       time.Time
+		next       time.Time
+	}{
+		"every minute": {
+			expression: "* * * * *",
+			time:       time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC),
+			next:       time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC),
+		},
+		"strictly after": {
+			expression: "30 10 * * *",
+			time:       time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC),
+			next:       time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC),
+		},
+		"step": {
+			expression: "*/20 * * * *",
+			time:       time.Date(2024, 1, 1, 10, 41, 0, 0, time.UTC),
+			next:       time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+		},
+		"month and year rollover": {
+			expression: "0 4 1 * *",
+			time:       time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2025, 1, 1, 4, 0, 0, 0, time.UTC),
+		},
+		"day of week": {
+			expression: "0 0 * * 1",
+			time:       time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), // Wednesday
+			next:       time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+		},
+		"day of week 7 is sunday": {
+			expression: "0 0 * * 7",
+			time:       time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+		"day of month or day of week": {
+			expression: "0 0 15 * 1",
+			time:       time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), // Tuesday
+			next:       time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+		"leap day": {
+			expression: "0 0 29 2 *",
+			time:       time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			next:       time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		"impossible date": {
+			expression: "0 0 30 2 *",
+			time:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := Parse(testCase.expression)
+			require.NoError(t, err)
+
+			next := schedule.Next(testCase.time)
+
+			assert.Equal(t, testCase.next, next)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package blocklist
+
+import (
+	"context"
+	"net/http"
+)
+
+// Result is the result of fetching and parsing a single block list.
+type Result struct {
+	// Source is the URL or file path of the block list.
+	Source string
+	// Hostnames are the unique hostnames parsed from the block list.
+	Hostnames []string
+	// ParseErrors are the errors for lines which could not be parsed.
+	ParseErrors []error
+	// Err is set if the block list could not be fetched.
+	Err error
+}
+
+// Build fetches and parses each of the block list sources given,
+// which can be HTTP(S) URLs or local file paths.
+// The results are returned in the same order as the sources.
+func Build(ctx context.Context, client *http.Client,
+	sources []string,
+) (results []Result) {
+	results = make([]Result, len(sources))
+	done := make(chan struct{})
+	for i, source := range sources {
+		go func(result *Result) {
+			defer func() { done <- struct{}{} }()
+			result.Source = source
+			content, err := fetch(ctx, client, source)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			hostnames, parseErrors := Parse(content)
+			result.Hostnames = deduplicate(hostnames)
+			result.ParseErrors = parseErrors
+		}(&results[i])
+	}
+
+	for range sources {
+		<-done
+	}
+
+	return results
+}
+
+func deduplicate(hostnames []string) (unique []string) {
+	seen := make(map[string]struct{}, len(hostnames))
+	unique = make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		if _, ok := seen[hostname]; ok {
+			continue
+		}
+		seen[hostname] = struct{}{}
+		unique = append(unique, hostname)
+	}
+	return unique
+}
@@ -0,0 +1,57 @@
+package blocklist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Build(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			_, _ = w.Write([]byte("||a.com^\n||a.com^\nb.com\n||c.com^$important\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	filePath := filepath.Join(t.TempDir(), "hosts")
+	err := os.WriteFile(filePath, []byte("0.0.0.0 d.com e.com\n"), 0o600)
+	require.NoError(t, err)
+
+	sources := []string{
+		server.URL + "/list.txt",
+		filePath,
+		server.URL + "/missing.txt",
+	}
+
+	results := Build(context.Background(), server.Client(), sources)
+
+	require.Len(t, results, len(sources))
+
+	assert.Equal(t, sources[0], results[0].Source)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, []string{"a.com", "b.com"}, results[0].Hostnames)
+	require.Len(t, results[0].ParseErrors, 1)
+	assert.EqualError(t, results[0].ParseErrors[0],
+		"line 4: AdBlock rule is not supported: ||c.com^$important")
+
+	assert.Equal(t, sources[1], results[1].Source)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, []string{"d.com", "e.com"}, results[1].Hostnames)
+	assert.Empty(t, results[1].ParseErrors)
+
+	assert.Equal(t, sources[2], results[2].Source)
+	assert.ErrorIs(t, results[2].Err, ErrBadStatusCode)
+	assert.Empty(t, results[2].Hostnames)
+}
@@ -0,0 +1,51 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var ErrBadStatusCode = errors.New("bad HTTP status code")
+
+// IsURL returns true if the block list source is an HTTP(S) URL
+// and false if it is a local file path.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") ||
+		strings.HasPrefix(source, "https://")
+}
+
+func fetch(ctx context.Context, client *http.Client, source string) (
+	content []byte, err error,
+) {
+	if !IsURL(source) {
+		return os.ReadFile(source)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d %s", ErrBadStatusCode,
+			response.StatusCode, response.Status)
+	}
+
+	content, err = io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,157 @@
+package blocklist
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrHostnameNotValid       = errors.New("hostname is not valid")
+	ErrHostsLineNotValid      = errors.New("hosts line is not valid")
+	ErrAdBlockRuleUnsupported = errors.New("AdBlock rule is not supported")
+)
+
+// ParseError is an error for a single line of a block list.
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (p *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Err)
+}
+
+func (p *ParseError) Unwrap() error {
+	return p.Err
+}
+
+// Parse parses the block list content and returns the hostnames found.
+// Each line can be in the hosts file format (for example `0.0.0.0 example.com`),
+// the plain domain format (for example `example.com`) or the AdBlock
+// format (for example `||example.com^`).
+// Empty lines and comments are ignored, and lines which cannot be parsed
+// are returned as errors of type *ParseError.
+func Parse(content []byte) (hostnames []string, errs []error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	const maxLineLength = 64 * 1024
+	scanner.Buffer(make([]byte, 0, maxLineLength), maxLineLength)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		lineHostnames, err := parseLine(scanner.Text())
+		if err != nil {
+			errs = append(errs, &ParseError{Line: lineNumber, Err: err})
+			continue
+		}
+		hostnames = append(hostnames, lineHostnames...)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("reading line %d: %w", lineNumber+1, err))
+	}
+
+	return hostnames, errs
+}
+
+func parseLine(line string) (hostnames []string, err error) {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "",
+		strings.HasPrefix(line, "#"),
+		strings.HasPrefix(line, "!"), // AdBlock comment
+		strings.HasPrefix(line, "["): // AdBlock header such as [Adblock Plus 2.0]
+		return nil, nil
+	case strings.HasPrefix(line, "@@"), // AdBlock exception rule
+		strings.Contains(line, "##"),  // AdBlock cosmetic rule
+		strings.Contains(line, "#@#"): // AdBlock cosmetic exception rule
+		return nil, fmt.Errorf("%w: %s", ErrAdBlockRuleUnsupported, line)
+	case strings.HasPrefix(line, "||"):
+		hostname, err := parseAdBlockRule(line)
+		if err != nil {
+			return nil, err
+		}
+		return []string{hostname}, nil
+	}
+
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if strings.HasPrefix(field, "#") { // inline comment
+			fields = fields[:i]
+			break
+		}
+	}
+	if len(fields) == 1 {
+		hostname, err := normalizeHostname(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		return []string{hostname}, nil
+	}
+	return parseHostsLine(fields)
+}
+
+func parseAdBlockRule(rule string) (hostname string, err error) {
+	hostname = strings.TrimPrefix(rule, "||")
+	hostname, ok := strings.CutSuffix(hostname, "^")
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAdBlockRuleUnsupported, rule)
+	}
+	hostname, err = normalizeHostname(hostname)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrAdBlockRuleUnsupported, rule)
+	}
+	return hostname, nil
+}
+
+// ignoredHostsHostnames are hostnames commonly found in
+// hosts files which must not be blocked.
+var ignoredHostsHostnames = map[string]struct{}{ //nolint:gochecknoglobals
+	"localhost":             {},
+	"localhost.localdomain": {},
+	"local":                 {},
+	"broadcasthost":         {},
+	"ip6-localhost":         {},
+	"ip6-loopback":          {},
+	"ip6-localnet":          {},
+	"ip6-mcastprefix":       {},
+	"ip6-allnodes":          {},
+	"ip6-allrouters":        {},
+	"ip6-allhosts":          {},
+	"0.0.0.0":               {},
+}
+
+func parseHostsLine(fields []string) (hostnames []string, err error) {
+	_, err = netip.ParseAddr(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrHostsLineNotValid, strings.Join(fields, " "))
+	}
+
+	hostnames = make([]string, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		if _, ignored := ignoredHostsHostnames[strings.ToLower(field)]; ignored {
+			continue
+		}
+		hostname, err := normalizeHostname(field)
+		if err != nil {
+			return nil, err
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	return hostnames, nil
+}
+
+var hostnameRegex = regexp.MustCompile(`^([a-z0-9]|[a-z0-9_][a-z0-9\-_]{0,61}[a-z0-9_])(\.([a-z0-9]|[a-z0-9_][a-z0-9\-_]{0,61}[a-z0-9]))*$`) //nolint:lll
+
+func normalizeHostname(s string) (hostname string, err error) {
+	hostname = strings.TrimSuffix(strings.ToLower(s), ".")
+	if !hostnameRegex.MatchString(hostname) {
+		return "", fmt.Errorf("%w: %s", ErrHostnameNotValid, s)
+	}
+	return hostname, nil
+}
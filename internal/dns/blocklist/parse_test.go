@@ -0,0 +1,69 @@
+package blocklist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content   string
+		hostnames []string
+		errs      []string
+	}{
+		"empty": {},
+		"comments_and_headers": {
+			content: "# comment\n! AdBlock comment\n[Adblock Plus 2.0]\n\n",
+		},
+		"plain_domains": {
+			content:   "example.com\nAds.Example.NET.\n",
+			hostnames: []string{"example.com", "ads.example.net"},
+		},
+		"hosts_format": {
+			content: "127.0.0.1 localhost\n" +
+				"0.0.0.0 a.com b.com # inline comment\n" +
+				"::1 ip6-localhost\n" +
+				"0.0.0.0 0.0.0.0\n",
+			hostnames: []string{"a.com", "b.com"},
+		},
+		"adblock_format": {
+			content:   "||tracker.com^\n||Sub.Tracker.com^\n",
+			hostnames: []string{"tracker.com", "sub.tracker.com"},
+		},
+		"mixed_with_errors": {
+			content: "example.com\n" +
+				"||tracker.com^$third-party\n" +
+				"notanip a.com\n" +
+				"example.com##.banner\n" +
+				"0.0.0.0 bad..host\n" +
+				"@@||allowed.com^\n" +
+				"||good.com^\n",
+			hostnames: []string{"example.com", "good.com"},
+			errs: []string{
+				"line 2: AdBlock rule is not supported: ||tracker.com^$third-party",
+				"line 3: hosts line is not valid: notanip a.com",
+				"line 4: AdBlock rule is not supported: example.com##.banner",
+				"line 5: hostname is not valid: bad..host",
+				"line 6: AdBlock rule is not supported: @@||allowed.com^",
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hostnames, errs := Parse([]byte(testCase.content))
+
+			assert.Equal(t, testCase.hostnames, hostnames)
+			require.Len(t, errs, len(testCase.errs))
+			for i, err := range errs {
+				assert.EqualError(t, err, testCase.errs[i])
+			}
+		})
+	}
+}
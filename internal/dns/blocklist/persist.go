@@ -0,0 +1,129 @@
+package blocklist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lists contains the blocked hostnames, IP addresses and IP prefixes
+// built from all the block lists.
+type Lists struct {
+	Hostnames  []string       `json:"hostnames"`
+	IPs        []netip.Addr   `json:"ips"`
+	IPPrefixes []netip.Prefix `json:"ip_prefixes"`
+}
+
+type persisted struct {
+	// FetchedAt is the time the lists were fetched at.
+	FetchedAt time.Time `json:"fetched_at"`
+	// SettingsChecksum is the checksum of the settings
+	// used to build the lists.
+	SettingsChecksum string `json:"settings_checksum"`
+	// Checksum is the SHA256 checksum of the lists JSON encoding.
+	Checksum string          `json:"checksum"`
+	Lists    json.RawMessage `json:"lists"`
+}
+
+var (
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrSettingsChanged  = errors.New("block lists settings changed")
+)
+
+// Checksum returns the hex encoded SHA256 checksum
+// of the JSON encoding of the value given.
+func Checksum(value any) (checksum string, err error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("encoding to JSON: %w", err)
+	}
+	return checksumData(data), nil
+}
+
+func checksumData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save writes the lists to the file path given, together with their
+// checksum, the fetch time and the checksum of the settings used to
+// build them. The file is written atomically.
+func Save(path string, lists Lists, fetchedAt time.Time,
+	settingsChecksum string,
+) (err error) {
+	listsData, err := json.Marshal(lists)
+	if err != nil {
+		return fmt.Errorf("encoding lists: %w", err)
+	}
+
+	data, err := json.Marshal(persisted{
+		FetchedAt:        fetchedAt,
+		SettingsChecksum: settingsChecksum,
+		Checksum:         checksumData(listsData),
+		Lists:            listsData,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding file content: %w", err)
+	}
+
+	const dirPermission = 0o755
+	err = os.MkdirAll(filepath.Dir(path), dirPermission)
+	if err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	temporaryPath := path + ".tmp"
+	const filePermission = 0o600
+	err = os.WriteFile(temporaryPath, data, filePermission)
+	if err != nil {
+		return fmt.Errorf("writing file: %w", err)
+	}
+
+	err = os.Rename(temporaryPath, path)
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return fmt.Errorf("renaming file: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the lists from the file path given. It returns an error
+// wrapping os.ErrNotExist if the file does not exist, ErrChecksumMismatch
+// if the lists are corrupted and ErrSettingsChanged if the lists were
+// built with different settings.
+func Load(path, settingsChecksum string) (lists Lists,
+	fetchedAt time.Time, err error,
+) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Lists{}, time.Time{}, err
+	}
+
+	var content persisted
+	err = json.Unmarshal(data, &content)
+	if err != nil {
+		return Lists{}, time.Time{}, fmt.Errorf("decoding file content: %w", err)
+	}
+
+	if checksumData(content.Lists) != content.Checksum {
+		return Lists{}, time.Time{}, fmt.Errorf("%w", ErrChecksumMismatch)
+	}
+
+	if content.SettingsChecksum != settingsChecksum {
+		return Lists{}, time.Time{}, fmt.Errorf("%w", ErrSettingsChanged)
+	}
+
+	err = json.Unmarshal(content.Lists, &lists)
+	if err != nil {
+		return Lists{}, time.Time{}, fmt.Errorf("decoding lists: %w", err)
+	}
+
+	return lists, content.FetchedAt, nil
+}
@@ -0,0 +1,56 @@
+package blocklist
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Save_Load(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "dns", "blocklists.json")
+	lists := Lists{
+		Hostnames:  []string{"a.com", "b.com"},
+		IPs:        []netip.Addr{netip.MustParseAddr("1.2.3.4")},
+		IPPrefixes: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+	fetchedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	_, _, err := Load(path, "settings")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	err = Save(path, lists, fetchedAt, "settings")
+	require.NoError(t, err)
+
+	loaded, loadedFetchedAt, err := Load(path, "settings")
+	require.NoError(t, err)
+	assert.Equal(t, lists, loaded)
+	assert.Equal(t, fetchedAt, loadedFetchedAt)
+
+	_, _, err = Load(path, "other settings")
+	assert.ErrorIs(t, err, ErrSettingsChanged)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data = []byte(strings.Replace(string(data), "a.com", "x.com", 1))
+	err = os.WriteFile(path, data, 0o600)
+	require.NoError(t, err)
+
+	_, _, err = Load(path, "settings")
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+func Test_Checksum(t *testing.T) {
+	t.Parallel()
+
+	checksum, err := Checksum([]string{"a"})
+	require.NoError(t, err)
+	assert.Equal(t, "0eb5b8d6f81bc677da8a08567cc4fa9a06a57e9ec8da85ed73a7f62727996002", checksum)
+}
@@ -12,33 +12,51 @@ import (
 var ErrNoBypassResolver = errors.New("no bypass resolver could be determined")
 
 type BypassConfig struct {
-	Resolver      netip.Addr   // DNS server to use for bypass domains
-	Domains       []string     // User-specified domains to bypass DoT
-	SearchDomains []string     // Search domains from resolv.conf (informational only)
-	Ndots         int          // Ndots value from resolv.conf
-	Timeout       int          // Timeout in seconds from resolv.conf
-	Attempts      int          // Number of attempts from resolv.conf
+	Resolver      netip.Addr // DNS server to use for bypass domains
+	Domains       []string   // User-specified domains to bypass DoT
+	SearchDomains []string   // Search domains from resolv.conf used to expand short names
+	Ndots         int        // Ndots value from resolv.conf
+	Timeout       int        // Timeout in seconds from resolv.conf
+	Attempts      int        // Number of attempts from resolv.conf
 }
 
-func DetectBypassConfig(userDomains []string, userResolver netip.Addr) (*BypassConfig, error) {
-	if len(userDomains) == 0 {
+// DetectBypassConfig builds the bypass configuration from the user
+// domains and resolver, and from the original /etc/resolv.conf.
+// If addSearchDomains is true, the search domains found in resolv.conf
+// are added to the bypass domains.
+func DetectBypassConfig(userDomains []string, userResolver netip.Addr,
+	addSearchDomains bool,
+) (*BypassConfig, error) {
+	return detectBypassConfig(userDomains, userResolver, addSearchDomains, "/etc/resolv.conf")
+}
+
+func detectBypassConfig(userDomains []string, userResolver netip.Addr,
+	addSearchDomains bool, resolvConfPath string,
+) (*BypassConfig, error) {
+	if len(userDomains) == 0 && !addSearchDomains {
 		return nil, nil //nolint:nilnil
 	}
 
 	config := &BypassConfig{
 		Domains:  normalizeDomains(userDomains),
 		Resolver: userResolver,
+		Ndots:    1,
 	}
 
-	// If no resolver specified, try to detect from original resolv.conf
-	if !config.Resolver.IsValid() {
-		// Use miekg/dns built-in resolv.conf parser
-		clientConfig, err := dns.ClientConfigFromFile("/etc/resolv.conf")
-		if err != nil {
-			return nil, fmt.Errorf("parsing resolv.conf: %w", err)
-		}
+	// Use miekg/dns built-in resolv.conf parser
+	clientConfig, err := dns.ClientConfigFromFile(resolvConfPath)
+	switch {
+	case err != nil && !config.Resolver.IsValid():
+		return nil, fmt.Errorf("parsing resolv.conf: %w", err)
+	case err == nil:
+		// Capture search domains and ndots from resolv.conf to expand
+		// short names the same way the system resolver would.
+		config.SearchDomains = normalizeDomains(clientConfig.Search)
+		config.Ndots = clientConfig.Ndots
+	}
 
-		// Use the first nameserver if available
+	// If no resolver specified, use the first nameserver of resolv.conf
+	if !config.Resolver.IsValid() {
 		if len(clientConfig.Servers) > 0 {
 			addr, err := netip.ParseAddr(clientConfig.Servers[0])
 			if err == nil {
@@ -46,18 +64,19 @@ func DetectBypassConfig(userDomains []string, userResolver netip.Addr) (*BypassC
 			}
 		}
 
-		// Capture search domains from resolv.conf (for informational purposes)
-		// but DO NOT automatically add them to bypass list
-		if len(clientConfig.Search) > 0 {
-			config.SearchDomains = clientConfig.Search
-		}
-
 		// Capture other important resolv.conf settings
-		config.Ndots = clientConfig.Ndots       // Number of dots to trigger absolute lookup
 		config.Timeout = clientConfig.Timeout   // Query timeout
 		config.Attempts = clientConfig.Attempts // Number of attempts
 	}
 
+	if addSearchDomains {
+		config.Domains = appendMissingDomains(config.Domains, config.SearchDomains)
+	}
+
+	if len(config.Domains) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
 	if !config.Resolver.IsValid() {
 		return nil, ErrNoBypassResolver
 	}
@@ -77,3 +96,18 @@ func normalizeDomains(domains []string) []string {
 	}
 	return normalized
 }
+
+func appendMissingDomains(domains, toAdd []string) []string {
+	existing := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		existing[domain] = struct{}{}
+	}
+	for _, domain := range toAdd {
+		if _, ok := existing[domain]; ok {
+			continue
+		}
+		existing[domain] = struct{}{}
+		domains = append(domains, domain)
+	}
+	return domains
+}
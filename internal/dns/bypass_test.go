@@ -2,6 +2,8 @@ package dns
 
 import (
 	"net/netip"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -58,7 +60,7 @@ func TestDetectBypassConfig(t *testing.T) {
 
 	t.Run("no domains returns nil", func(t *testing.T) {
 		t.Parallel()
-		config, err := DetectBypassConfig([]string{}, netip.Addr{})
+		config, err := DetectBypassConfig([]string{}, netip.Addr{}, false)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -72,7 +74,7 @@ func TestDetectBypassConfig(t *testing.T) {
 		resolver := netip.MustParseAddr("10.0.0.1")
 		domains := []string{"cluster.local", "consul.service"}
 
-		config, err := DetectBypassConfig(domains, resolver)
+		config, err := DetectBypassConfig(domains, resolver, false)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -92,9 +94,80 @@ func TestDetectBypassConfig(t *testing.T) {
 		domains := []string{"cluster.local"}
 
 		// This will fail because we can't read /etc/resolv.conf in tests
-		config, err := DetectBypassConfig(domains, netip.Addr{})
+		config, err := DetectBypassConfig(domains, netip.Addr{}, false)
 		if err == nil && config != nil && config.Resolver.IsValid() {
 			t.Log("resolver auto-detected from resolv.conf")
 		}
 	})
 }
+
+func TestDetectBypassConfig_searchDomains(t *testing.T) {
+	t.Parallel()
+
+	resolvConfPath := filepath.Join(t.TempDir(), "resolv.conf")
+	const resolvConf = "nameserver 10.96.0.10\n" +
+		"search default.svc.cluster.local svc.cluster.local cluster.local\n" +
+		"options ndots:5\n"
+	err := os.WriteFile(resolvConfPath, []byte(resolvConf), 0o600)
+	if err != nil {
+		t.Fatalf("writing resolv.conf: %v", err)
+	}
+
+	t.Run("search domains used for expansion only", func(t *testing.T) {
+		t.Parallel()
+		config, err := detectBypassConfig([]string{"consul.service"},
+			netip.Addr{}, false, resolvConfPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config.Resolver != netip.MustParseAddr("10.96.0.10") {
+			t.Errorf("resolver = %v, want 10.96.0.10", config.Resolver)
+		}
+		if config.Ndots != 5 {
+			t.Errorf("ndots = %d, want 5", config.Ndots)
+		}
+		if len(config.SearchDomains) != 3 {
+			t.Errorf("expected 3 search domains, got %v", config.SearchDomains)
+		}
+		if len(config.Domains) != 1 {
+			t.Errorf("expected 1 domain, got %v", config.Domains)
+		}
+	})
+
+	t.Run("search domains added to bypass domains", func(t *testing.T) {
+		t.Parallel()
+		config, err := detectBypassConfig([]string{"cluster.local"},
+			netip.MustParseAddr("10.0.0.1"), true, resolvConfPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{"cluster.local", "default.svc.cluster.local", "svc.cluster.local"}
+		if len(config.Domains) != len(expected) {
+			t.Fatalf("domains = %v, want %v", config.Domains, expected)
+		}
+		for i := range expected {
+			if config.Domains[i] != expected[i] {
+				t.Errorf("domains[%d] = %s, want %s", i, config.Domains[i], expected[i])
+			}
+		}
+		if config.Resolver != netip.MustParseAddr("10.0.0.1") {
+			t.Errorf("resolver = %v, want 10.0.0.1", config.Resolver)
+		}
+	})
+
+	t.Run("no domains and no search domains", func(t *testing.T) {
+		t.Parallel()
+		emptyResolvConfPath := filepath.Join(t.TempDir(), "resolv.conf")
+		err := os.WriteFile(emptyResolvConfPath, []byte("nameserver 10.96.0.10\n"), 0o600)
+		if err != nil {
+			t.Fatalf("writing resolv.conf: %v", err)
+		}
+		config, err := detectBypassConfig(nil, netip.Addr{}, true, emptyResolvConfPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config != nil {
+			t.Errorf("expected nil config, got %+v", config)
+		}
+	})
+}
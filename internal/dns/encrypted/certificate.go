@@ -0,0 +1,119 @@
+package encrypted
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrCertificateOrKeyMissing = errors.New("only one of the certificate and key files exists")
+
+// LoadOrCreateCertificate loads the TLS certificate and key from the
+// PEM encoded files given. If neither file exists, a self-signed
+// certificate is generated for the hostnames and IP addresses given,
+// and written to the files so it stays the same across restarts.
+func LoadOrCreateCertificate(certificatePath, keyPath string,
+	hostnames []string, ips []netip.Addr, now time.Time,
+) (certificate tls.Certificate, created bool, err error) {
+	certificate, err = tls.LoadX509KeyPair(certificatePath, keyPath)
+	switch {
+	case err == nil:
+		return certificate, false, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return tls.Certificate{}, false, fmt.Errorf("loading certificate: %w", err)
+	}
+
+	for _, path := range []string{certificatePath, keyPath} {
+		_, err = os.Stat(path)
+		if err == nil {
+			return tls.Certificate{}, false, fmt.Errorf("loading certificate: %w: %s and %s",
+				ErrCertificateOrKeyMissing, certificatePath, keyPath)
+		}
+	}
+
+	certificatePEM, keyPEM, err := generateSelfSigned(hostnames, ips, now)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("generating self-signed certificate: %w", err)
+	}
+
+	const certificatePerms, keyPerms = 0o644, 0o600
+	err = writeFile(certificatePath, certificatePEM, certificatePerms)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("writing certificate: %w", err)
+	}
+	err = writeFile(keyPath, keyPEM, keyPerms)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("writing key: %w", err)
+	}
+
+	certificate, err = tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, false, fmt.Errorf("parsing generated certificate: %w", err)
+	}
+	return certificate, true, nil
+}
+
+func generateSelfSigned(hostnames []string, ips []netip.Addr, now time.Time) (
+	certificatePEM, keyPEM []byte, err error,
+) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	const serialNumberBits = 128
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating serial number: %w", err)
+	}
+
+	const validity = 10 * 365 * 24 * time.Hour
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "gluetun DNS server"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              hostnames,
+	}
+	for _, ip := range ips {
+		template.IPAddresses = append(template.IPAddresses, net.IP(ip.AsSlice()))
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding key: %w", err)
+	}
+
+	certificatePEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certificatePEM, keyPEM, nil
+}
+
+func writeFile(path string, data []byte, perms os.FileMode) (err error) {
+	const dirPerms = 0o700
+	err = os.MkdirAll(filepath.Dir(path), dirPerms)
+	if err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+	return os.WriteFile(path, data, perms)
+}
@@ -0,0 +1,49 @@
+package encrypted
+
+import (
+	"crypto/x509"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadOrCreateCertificate(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	certificatePath := filepath.Join(directory, "dns", "server.crt")
+	keyPath := filepath.Join(directory, "dns", "server.key")
+	now := time.Unix(1700000000, 0)
+
+	certificate, created, err := LoadOrCreateCertificate(certificatePath, keyPath,
+		[]string{"dns.home.arpa"}, []netip.Addr{netip.MustParseAddr("192.168.1.2")}, now)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dns.home.arpa"}, parsed.DNSNames)
+	require.Len(t, parsed.IPAddresses, 1)
+	assert.Equal(t, "192.168.1.2", parsed.IPAddresses[0].String())
+	assert.NoError(t, parsed.VerifyHostname("dns.home.arpa"))
+
+	info, err := os.Stat(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, created, err := LoadOrCreateCertificate(certificatePath, keyPath,
+		nil, nil, now)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, certificate.Certificate, loaded.Certificate)
+
+	err = os.Remove(keyPath)
+	require.NoError(t, err)
+	_, _, err = LoadOrCreateCertificate(certificatePath, keyPath, nil, nil, now)
+	assert.ErrorIs(t, err, ErrCertificateOrKeyMissing)
+}
@@ -0,0 +1,148 @@
+package encrypted
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/miekg/dns"
+)
+
+const dnsMessageContentType = "application/dns-message"
+
+// dohHandler serves DNS over HTTPS requests as described in RFC 8484,
+// using the DNS handler given.
+type dohHandler struct {
+	handler dns.Handler
+}
+
+func newDoHHandler(handler dns.Handler) *dohHandler {
+	return &dohHandler{handler: handler}
+}
+
+func (h *dohHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var wire []byte
+	switch r.Method {
+	case http.MethodGet:
+		var err error
+		wire, err = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("dns"))
+		if err != nil || len(wire) == 0 {
+			http.Error(w, "dns query parameter is not valid", http.StatusBadRequest)
+			return
+		}
+	case http.MethodPost:
+		if r.Header.Get("Content-Type") != dnsMessageContentType {
+			http.Error(w, "content type must be "+dnsMessageContentType,
+				http.StatusUnsupportedMediaType)
+			return
+		}
+		const maxMessageSize = dns.MaxMsgSize
+		var err error
+		wire, err = io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		if err != nil {
+			http.Error(w, "reading body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	request := new(dns.Msg)
+	err := request.Unpack(wire)
+	if err != nil {
+		http.Error(w, "DNS message is not valid: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer := &responseWriter{
+		localAddr:  addrFromString(r.Context().Value(http.LocalAddrContextKey)),
+		remoteAddr: addrFromString(r.RemoteAddr),
+	}
+	h.handler.ServeDNS(writer, request)
+	if writer.response == nil {
+		http.Error(w, "no DNS response", http.StatusInternalServerError)
+		return
+	}
+
+	packed, err := writer.response.Pack()
+	if err != nil {
+		http.Error(w, "packing DNS response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", dnsMessageContentType)
+	w.Header().Set("Cache-Control", "max-age="+strconv.FormatUint(uint64(minTTL(writer.response)), 10))
+	_, _ = w.Write(packed)
+}
+
+// minTTL returns the minimum TTL of the response records,
+// ignoring the EDNS0 OPT record, or 0 if there is no record.
+func minTTL(response *dns.Msg) (ttl uint32) {
+	found := false
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range rrs {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+			if !found || header.Ttl < ttl {
+				ttl = header.Ttl
+				found = true
+			}
+		}
+	}
+	return ttl
+}
+
+// addrFromString returns a TCP address from an address value,
+// or nil if it cannot be parsed.
+func addrFromString(value any) (address net.Addr) {
+	switch typedValue := value.(type) {
+	case net.Addr:
+		return typedValue
+	case string:
+		addrPort, err := netip.ParseAddrPort(typedValue)
+		if err != nil {
+			return nil
+		}
+		return net.TCPAddrFromAddrPort(addrPort)
+	default:
+		return nil
+	}
+}
+
+// responseWriter is a DNS response writer capturing the response
+// written by the DNS handler.
+type responseWriter struct {
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	response   *dns.Msg
+}
+
+func (w *responseWriter) LocalAddr() net.Addr  { return w.localAddr }
+func (w *responseWriter) RemoteAddr() net.Addr { return w.remoteAddr }
+
+func (w *responseWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func (w *responseWriter) Write(wire []byte) (n int, err error) {
+	response := new(dns.Msg)
+	err = response.Unpack(wire)
+	if err != nil {
+		return 0, err
+	}
+	w.response = response
+	return len(wire), nil
+}
+
+func (w *responseWriter) Close() error        { return nil }
+func (w *responseWriter) TsigStatus() error   { return nil }
+func (w *responseWriter) TsigTimersOnly(bool) {}
+func (w *responseWriter) Hijack()             {}
@@ -0,0 +1,88 @@
+package encrypted
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dohHandler(t *testing.T) {
+	t.Parallel()
+
+	var remoteAddr string
+	dnsHandler := dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		remoteAddr = w.RemoteAddr().String()
+		response := new(dns.Msg).SetReply(request)
+		rr, _ := dns.NewRR(request.Question[0].Name + " 300 IN A 1.2.3.4")
+		response.Answer = []dns.RR{rr}
+		_ = w.WriteMsg(response)
+	})
+	handler := newDoHHandler(dnsHandler)
+
+	query := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	query.Id = 0
+	wire, err := query.Pack()
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		request    *http.Request
+		statusCode int
+	}{
+		"get": {
+			request: httptest.NewRequest(http.MethodGet,
+				"/dns-query?dns="+base64.RawURLEncoding.EncodeToString(wire), nil),
+			statusCode: http.StatusOK,
+		},
+		"post": {
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(wire))
+				request.Header.Set("Content-Type", dnsMessageContentType)
+				return request
+			}(),
+			statusCode: http.StatusOK,
+		},
+		"post_bad_content_type": {
+			request:    httptest.NewRequest(http.MethodPost, "/dns-query", bytes.NewReader(wire)),
+			statusCode: http.StatusUnsupportedMediaType,
+		},
+		"get_missing_query": {
+			request:    httptest.NewRequest(http.MethodGet, "/dns-query", nil),
+			statusCode: http.StatusBadRequest,
+		},
+		"put": {
+			request:    httptest.NewRequest(http.MethodPut, "/dns-query", nil),
+			statusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, testCase.request)
+			result := recorder.Result()
+			defer result.Body.Close()
+
+			require.Equal(t, testCase.statusCode, result.StatusCode)
+			if testCase.statusCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, dnsMessageContentType, result.Header.Get("Content-Type"))
+			assert.Equal(t, "max-age=300", result.Header.Get("Cache-Control"))
+			assert.Equal(t, testCase.request.RemoteAddr, remoteAddr)
+			body, err := io.ReadAll(result.Body)
+			require.NoError(t, err)
+			response := new(dns.Msg)
+			require.NoError(t, response.Unpack(body))
+			require.Len(t, response.Answer, 1)
+			assert.Equal(t, "1.2.3.4", response.Answer[0].(*dns.A).A.String())
+		})
+	}
+}
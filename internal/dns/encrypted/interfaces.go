@@ -0,0 +1,5 @@
+package encrypted
+
+type Logger interface {
+	Info(message string)
+}
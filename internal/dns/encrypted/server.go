@@ -0,0 +1,168 @@
+package encrypted
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Protocol is the encrypted DNS protocol served.
+type Protocol string
+
+const (
+	ProtocolDoT Protocol = "DNS over TLS"
+	ProtocolDoH Protocol = "DNS over HTTPS"
+)
+
+type Settings struct {
+	// Protocol is the protocol to serve, and must be set.
+	Protocol Protocol
+	// ListeningAddress is the server listening address, and must be set.
+	ListeningAddress string
+	// Path is the HTTP path to serve DNS over HTTPS on, and
+	// must be set for the DNS over HTTPS protocol.
+	Path string
+	// Certificate is the TLS certificate to use, and must be set.
+	Certificate tls.Certificate
+	// Handler is the DNS handler serving the queries, and must be set.
+	// It is typically the handler of the plain DNS server, so queries
+	// go through the same middlewares and upstream DNS over TLS dialer.
+	Handler dns.Handler
+	// Logger is the logger to use, and must be set.
+	Logger Logger
+}
+
+var (
+	ErrProtocolNotValid     = errors.New("protocol is not valid")
+	ErrPathNotSet           = errors.New("path is not set")
+	ErrCertificateNotSet    = errors.New("certificate is not set")
+	ErrHandlerNotSet        = errors.New("handler is not set")
+	ErrLoggerNotSet         = errors.New("logger is not set")
+	ErrServerAlreadyRunning = errors.New("server is already running")
+)
+
+// Server serves DNS over TLS or DNS over HTTPS, and implements
+// the goservices.Service interface.
+type Server struct {
+	settings Settings
+	logger   Logger
+
+	startStopMutex sync.Mutex
+	cancel         context.CancelFunc
+	shutdown       func() error
+	done           <-chan struct{}
+}
+
+func New(settings Settings) (server *Server, err error) {
+	switch {
+	case settings.Protocol != ProtocolDoT && settings.Protocol != ProtocolDoH:
+		return nil, fmt.Errorf("%w: %s", ErrProtocolNotValid, settings.Protocol)
+	case settings.Protocol == ProtocolDoH && settings.Path == "":
+		return nil, fmt.Errorf("%w", ErrPathNotSet)
+	case len(settings.Certificate.Certificate) == 0:
+		return nil, fmt.Errorf("%w", ErrCertificateNotSet)
+	case settings.Handler == nil:
+		return nil, fmt.Errorf("%w", ErrHandlerNotSet)
+	case settings.Logger == nil:
+		return nil, fmt.Errorf("%w", ErrLoggerNotSet)
+	}
+
+	return &Server{
+		settings: settings,
+		logger:   settings.Logger,
+	}, nil
+}
+
+func (s *Server) String() string {
+	return string(s.settings.Protocol) + " server"
+}
+
+func (s *Server) Start(ctx context.Context) (runError <-chan error, err error) {
+	s.startStopMutex.Lock()
+	defer s.startStopMutex.Unlock()
+
+	if s.cancel != nil {
+		return nil, fmt.Errorf("%s: %w", s, ErrServerAlreadyRunning)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{s.settings.Certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if s.settings.Protocol == ProtocolDoH {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	listenConfig := net.ListenConfig{}
+	tcpListener, err := listenConfig.Listen(ctx, "tcp", s.settings.ListeningAddress)
+	if err != nil {
+		return nil, fmt.Errorf("listening: %w", err)
+	}
+	listener := tls.NewListener(tcpListener, tlsConfig)
+
+	// The stop context is canceled when the server is stopped,
+	// and not when the start context is canceled.
+	stopCtx, cancel := context.WithCancel(context.Background())
+	handler := s.settings.Handler
+
+	var serve func() error
+	switch s.settings.Protocol {
+	case ProtocolDoT:
+		dnsServer := &dns.Server{Listener: listener, Handler: handler}
+		serve = dnsServer.ActivateAndServe
+		s.shutdown = dnsServer.Shutdown
+	case ProtocolDoH:
+		mux := http.NewServeMux()
+		mux.Handle(s.settings.Path, newDoHHandler(handler))
+		const readHeaderTimeout = time.Second
+		httpServer := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+		serve = func() error { return httpServer.Serve(listener) }
+		s.shutdown = func() error {
+			const shutdownTimeout = 3 * time.Second
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return httpServer.Shutdown(shutdownCtx)
+		}
+	}
+
+	runErrorCh := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := serve()
+		if stopCtx.Err() != nil { // server stopped
+			return
+		}
+		runErrorCh <- err
+	}()
+
+	s.cancel = cancel
+	s.done = done
+	s.logger.Info(s.String() + " listening on " + tcpListener.Addr().String())
+	return runErrorCh, nil
+}
+
+func (s *Server) Stop() (err error) {
+	s.startStopMutex.Lock()
+	defer s.startStopMutex.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	err = s.shutdown()
+	<-s.done
+	s.cancel = nil
+	return err
+}
@@ -0,0 +1,29 @@
+package expansion
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Target returns the expanded name of a response built for
+// a name expanded with a search domain, which starts with a CNAME record
+// from the question name to the question name followed by the search
+// domain. It returns false if the response is not such a response.
+func Target(response *dns.Msg) (target string, ok bool) {
+	if len(response.Question) == 0 || len(response.Answer) == 0 {
+		return "", false
+	}
+
+	cname, ok := response.Answer[0].(*dns.CNAME)
+	name := response.Question[0].Name
+	if !ok || !strings.EqualFold(cname.Hdr.Name, name) {
+		return "", false
+	}
+
+	prefix := strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+	if prefix == "." || !strings.HasPrefix(strings.ToLower(cname.Target), prefix) {
+		return "", false
+	}
+	return cname.Target, true
+}
@@ -0,0 +1,61 @@
+package expansion
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func Test_Target(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		question string
+		answers  []string
+		target   string
+		ok       bool
+	}{
+		"no_answer": {
+			question: "myservice.",
+		},
+		"not_cname": {
+			question: "myservice.",
+			answers:  []string{"myservice. 60 IN A 10.96.1.1"},
+		},
+		"expansion": {
+			question: "myservice.",
+			answers: []string{
+				"myservice. 60 IN CNAME myservice.svc.cluster.local.",
+				"myservice.svc.cluster.local. 60 IN A 10.96.1.1",
+			},
+			target: "myservice.svc.cluster.local.",
+			ok:     true,
+		},
+		"cname_to_other_name": {
+			question: "attacker.com.",
+			answers: []string{
+				"attacker.com. 60 IN CNAME myservice.svc.cluster.local.",
+				"myservice.svc.cluster.local. 60 IN A 10.96.1.1",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			response := new(dns.Msg).SetQuestion(tc.question, dns.TypeA)
+			for _, answer := range tc.answers {
+				rr, err := dns.NewRR(answer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				response.Answer = append(response.Answer, rr)
+			}
+
+			target, ok := Target(response)
+			if target != tc.target || ok != tc.ok {
+				t.Errorf("Target() = %q, %t, want %q, %t", target, ok, tc.target, tc.ok)
+			}
+		})
+	}
+}
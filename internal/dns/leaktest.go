@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"context"
+	"net"
+
+	"github.com/qdm12/gluetun/internal/dns/leaktest"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// RunLeakTest runs the DNS leak test if it is enabled, comparing the
+// DNS resolvers seen by the leak test service with the VPN public IP
+// address information given.
+func (l *Loop) RunLeakTest(ctx context.Context, vpnPublicIP models.PublicIP) {
+	settings := l.GetSettings().LeakTest
+	if !*settings.Enabled {
+		return
+	}
+
+	if !vpnPublicIP.IP.IsValid() {
+		l.logger.Warn("skipping DNS leak test: VPN public IP address is unknown")
+		return
+	}
+
+	checker, err := leaktest.New(leaktest.Settings{
+		URL:      *settings.URL,
+		Client:   l.client,
+		Resolver: net.DefaultResolver,
+		Logger:   l.logger,
+	})
+	if err != nil {
+		l.logger.Error("creating DNS leak test checker: " + err.Error())
+		return
+	}
+
+	result := checker.Run(ctx, vpnPublicIP)
+	if ctx.Err() != nil {
+		return
+	}
+
+	l.leakTestMutex.Lock()
+	l.leakTestResult = result
+	l.leakTestMutex.Unlock()
+}
+
+// GetLeakTestResult returns the result of the last DNS leak test.
+func (l *Loop) GetLeakTestResult() (result leaktest.Result) {
+	l.leakTestMutex.RLock()
+	defer l.leakTestMutex.RUnlock()
+	return l.leakTestResult
+}
@@ -0,0 +1,263 @@
+package leaktest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// Checker runs DNS leak tests against a leak test service using the
+// bash.ws API: it gets a test identifier, resolves unique subdomains
+// of the identifier, and fetches the DNS resolvers the service saw
+// resolving these subdomains.
+type Checker struct {
+	baseURL  string
+	domain   string
+	queries  int
+	client   *http.Client
+	resolver Resolver
+	logger   Logger
+	timeNow  func() time.Time
+}
+
+type Settings struct {
+	// URL is the base URL of the leak test service, and must be set.
+	URL string
+	// Domain is the domain to resolve subdomains of, and defaults
+	// to the host of the URL.
+	Domain string
+	// Queries is the number of unique subdomains to resolve,
+	// and defaults to 6.
+	Queries int
+	// Client is the HTTP client to query the service with, and must be set.
+	Client *http.Client
+	// Resolver is the resolver to resolve subdomains with, and must be set.
+	Resolver Resolver
+	// Logger is the logger to log results with, and must be set.
+	Logger Logger
+}
+
+var (
+	ErrURLNotValid      = errors.New("leak test service URL is not valid")
+	ErrClientNotSet     = errors.New("HTTP client is not set")
+	ErrResolverNotSet   = errors.New("resolver is not set")
+	ErrLoggerNotSet     = errors.New("logger is not set")
+	ErrBadStatusCode    = errors.New("bad HTTP status code")
+	ErrTestIDNotValid   = errors.New("test identifier is not valid")
+	ErrResultsMalformed = errors.New("results are malformed")
+)
+
+func New(settings Settings) (checker *Checker, err error) {
+	parsedURL, err := url.Parse(settings.URL)
+	if err != nil || parsedURL.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrURLNotValid, settings.URL)
+	}
+	switch {
+	case settings.Client == nil:
+		return nil, fmt.Errorf("%w", ErrClientNotSet)
+	case settings.Resolver == nil:
+		return nil, fmt.Errorf("%w", ErrResolverNotSet)
+	case settings.Logger == nil:
+		return nil, fmt.Errorf("%w", ErrLoggerNotSet)
+	}
+
+	domain := settings.Domain
+	if domain == "" {
+		domain = parsedURL.Hostname()
+	}
+
+	const defaultQueries = 6
+	queries := settings.Queries
+	if queries == 0 {
+		queries = defaultQueries
+	}
+
+	return &Checker{
+		baseURL:  strings.TrimSuffix(settings.URL, "/"),
+		domain:   domain,
+		queries:  queries,
+		client:   settings.Client,
+		resolver: settings.Resolver,
+		logger:   settings.Logger,
+		timeNow:  time.Now,
+	}, nil
+}
+
+// Run runs a DNS leak test, comparing the DNS resolvers seen with
+// the VPN public IP address information given. The result is logged
+// and returned.
+func (c *Checker) Run(ctx context.Context, vpnPublicIP models.PublicIP) (result Result) {
+	result = Result{
+		Time: c.timeNow(),
+		VPN: Location{
+			IP:      vpnPublicIP.IP,
+			Country: vpnPublicIP.Country,
+			ASN:     vpnPublicIP.Organization,
+		},
+	}
+
+	observedIP, resolvers, err := c.test(ctx)
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	} else {
+		result.ObservedIP = observedIP
+		result.Resolvers = resolvers
+		result.evaluate()
+	}
+
+	c.log(result)
+	return result
+}
+
+func (c *Checker) test(ctx context.Context) (observedIP netip.Addr,
+	resolvers []Resolution, err error,
+) {
+	id, err := c.fetchTestID(ctx)
+	if err != nil {
+		return netip.Addr{}, nil, fmt.Errorf("getting test identifier: %w", err)
+	}
+
+	for range c.queries {
+		label, err := randomLabel()
+		if err != nil {
+			return netip.Addr{}, nil, fmt.Errorf("generating subdomain: %w", err)
+		}
+		// Resolution errors are ignored since the leak test service
+		// only needs to see the queries.
+		_, _ = c.resolver.LookupHost(ctx, label+"."+id+"."+c.domain)
+	}
+
+	observedIP, resolvers, err = c.fetchResults(ctx, id)
+	if err != nil {
+		return netip.Addr{}, nil, fmt.Errorf("getting test results: %w", err)
+	}
+	return observedIP, resolvers, nil
+}
+
+func (c *Checker) fetchTestID(ctx context.Context) (id string, err error) {
+	body, err := c.get(ctx, c.baseURL+"/id")
+	if err != nil {
+		return "", err
+	}
+
+	id = strings.TrimSpace(string(body))
+	if id == "" || strings.ContainsAny(id, "./ \t\n") {
+		return "", fmt.Errorf("%w: %q", ErrTestIDNotValid, id)
+	}
+	return id, nil
+}
+
+type resultEntry struct {
+	IP          string `json:"ip"`
+	CountryName string `json:"country_name"`
+	ASN         string `json:"asn"`
+	Type        string `json:"type"`
+}
+
+func (c *Checker) fetchResults(ctx context.Context, id string) (
+	observedIP netip.Addr, resolvers []Resolution, err error,
+) {
+	body, err := c.get(ctx, c.baseURL+"/dnsleak/test/"+url.PathEscape(id)+"?json")
+	if err != nil {
+		return netip.Addr{}, nil, err
+	}
+
+	var entries []resultEntry
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return netip.Addr{}, nil, fmt.Errorf("%w: %w", ErrResultsMalformed, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Type != "ip" && entry.Type != "dns" {
+			continue
+		}
+		ip, err := netip.ParseAddr(entry.IP)
+		if err != nil {
+			return netip.Addr{}, nil, fmt.Errorf("%w: %w", ErrResultsMalformed, err)
+		}
+		if entry.Type == "ip" {
+			observedIP = ip
+			continue
+		}
+		resolvers = append(resolvers, Resolution{
+			Location: Location{
+				IP:      ip,
+				Country: entry.CountryName,
+				ASN:     entry.ASN,
+			},
+		})
+	}
+	return observedIP, resolvers, nil
+}
+
+func (c *Checker) get(ctx context.Context, url string) (body []byte, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d %s", ErrBadStatusCode,
+			response.StatusCode, response.Status)
+	}
+
+	const maxBodySize = 1 << 20
+	body, err = io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return body, nil
+}
+
+func (c *Checker) log(result Result) {
+	switch result.Status {
+	case StatusPassed:
+		c.logger.Info(fmt.Sprintf("DNS leak test passed: %d resolver(s) all matching "+
+			"the VPN country %s or network %s", len(result.Resolvers),
+			result.VPN.Country, result.VPN.ASN))
+	case StatusLeak:
+		if result.ObservedIP.IsValid() && result.ObservedIP != result.VPN.IP {
+			c.logger.Warn(fmt.Sprintf("DNS leak test: public IP address seen is %s "+
+				"instead of the VPN public IP address %s", result.ObservedIP, result.VPN.IP))
+		}
+		for _, resolver := range result.Resolvers {
+			if resolver.MatchesVPN {
+				continue
+			}
+			c.logger.Warn(fmt.Sprintf("DNS leak test: resolver %s in %s (%s) does not match "+
+				"the VPN country %s or network %s", resolver.IP, resolver.Country,
+				resolver.ASN, result.VPN.Country, result.VPN.ASN))
+		}
+	case StatusError:
+		c.logger.Warn("DNS leak test failed: " + result.Error)
+	}
+}
+
+func randomLabel() (label string, err error) {
+	const size = 8
+	b := make([]byte, size)
+	_, err = rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,116 @@
+package leaktest
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Info(string) {}
+func (noopLogger) Warn(string) {}
+
+func Test_Checker_Run(t *testing.T) {
+	t.Parallel()
+
+	localhost := netip.MustParseAddr("127.0.0.1")
+	vpnIP := netip.MustParseAddr("1.2.3.4")
+
+	testCases := map[string]struct {
+		resolverLocation Location
+		observedIP       netip.Addr
+		vpn              models.PublicIP
+		status           Status
+		matchesVPN       bool
+	}{
+		"same_country": {
+			resolverLocation: Location{Country: "Netherlands", ASN: "AS13335 Cloudflare, Inc."},
+			observedIP:       vpnIP,
+			vpn:              models.PublicIP{IP: vpnIP, Country: "netherlands", Organization: "AS9009 M247 Ltd"},
+			status:           StatusPassed,
+			matchesVPN:       true,
+		},
+		"same_asn": {
+			resolverLocation: Location{Country: "Belgium", ASN: "AS9009 M247 Europe"},
+			vpn:              models.PublicIP{IP: vpnIP, Country: "Netherlands", Organization: "AS9009 M247 Ltd"},
+			status:           StatusPassed,
+			matchesVPN:       true,
+		},
+		"resolver_leak": {
+			resolverLocation: Location{Country: "France", ASN: "AS3215 Orange"},
+			observedIP:       vpnIP,
+			vpn:              models.PublicIP{IP: vpnIP, Country: "Netherlands", Organization: "AS9009 M247 Ltd"},
+			status:           StatusLeak,
+		},
+		"public_ip_leak": {
+			resolverLocation: Location{Country: "Netherlands"},
+			observedIP:       netip.MustParseAddr("5.6.7.8"),
+			vpn:              models.PublicIP{IP: vpnIP, Country: "Netherlands"},
+			status:           StatusLeak,
+			matchesVPN:       true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			standIn, err := NewStandIn(map[netip.Addr]Location{
+				localhost: testCase.resolverLocation,
+			}, testCase.observedIP)
+			require.NoError(t, err)
+			t.Cleanup(standIn.Close)
+
+			checker, err := New(Settings{
+				URL:      standIn.URL,
+				Domain:   standIn.Domain,
+				Client:   http.DefaultClient,
+				Resolver: standIn.Resolver(),
+				Logger:   noopLogger{},
+			})
+			require.NoError(t, err)
+
+			result := checker.Run(context.Background(), testCase.vpn)
+
+			assert.Equal(t, testCase.status, result.Status, result.Error)
+			require.Len(t, result.Resolvers, 1)
+			assert.Equal(t, localhost, result.Resolvers[0].IP)
+			assert.Equal(t, testCase.matchesVPN, result.Resolvers[0].MatchesVPN)
+		})
+	}
+}
+
+func Test_Checker_Run_serviceDown(t *testing.T) {
+	t.Parallel()
+
+	standIn, err := NewStandIn(nil, netip.Addr{})
+	require.NoError(t, err)
+	standIn.Close()
+
+	checker, err := New(Settings{
+		URL:      standIn.URL,
+		Client:   http.DefaultClient,
+		Resolver: standIn.Resolver(),
+		Logger:   noopLogger{},
+	})
+	require.NoError(t, err)
+
+	result := checker.Run(context.Background(), models.PublicIP{})
+	assert.Equal(t, StatusError, result.Status)
+	assert.NotEmpty(t, result.Error)
+}
+
+func Test_sameASN(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, sameASN("AS9009 M247 Ltd", "as9009 M247 Europe SRL"))
+	assert.False(t, sameASN("AS9009 M247 Ltd", "AS13335 Cloudflare"))
+	assert.True(t, sameASN("M247 Ltd", "AS9009 M247 Ltd"))
+	assert.False(t, sameASN("", "AS9009 M247 Ltd"))
+}
@@ -0,0 +1,12 @@
+package leaktest
+
+import "context"
+
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+type Logger interface {
+	Info(message string)
+	Warn(message string)
+}
@@ -0,0 +1,103 @@
+package leaktest
+
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Status is the status of a DNS leak test.
+type Status string
+
+const (
+	StatusPassed Status = "passed"
+	StatusLeak   Status = "leak"
+	StatusError  Status = "error"
+)
+
+// Result is the result of a DNS leak test.
+type Result struct {
+	Time   time.Time `json:"time"`
+	Status Status    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	// VPN is the public IP address information of the VPN connection.
+	VPN Location `json:"vpn"`
+	// ObservedIP is the public IP address seen by the leak test
+	// service, and is invalid if the service did not report it.
+	ObservedIP netip.Addr `json:"observed_ip,omitempty"`
+	// Resolvers are the DNS resolvers seen by the leak test service.
+	Resolvers []Resolution `json:"resolvers"`
+}
+
+// Location is the country and autonomous system of an IP address.
+type Location struct {
+	IP      netip.Addr `json:"ip"`
+	Country string     `json:"country,omitempty"`
+	// ASN is the autonomous system number and/or organization,
+	// for example "AS9009 M247 Ltd".
+	ASN string `json:"asn,omitempty"`
+}
+
+// Resolution is a DNS resolver seen by the leak test service.
+type Resolution struct {
+	Location
+	// MatchesVPN is true if the resolver is in the same country
+	// or autonomous system as the VPN public IP address.
+	MatchesVPN bool `json:"matches_vpn"`
+}
+
+// Empty returns true if no leak test was run.
+func (r Result) Empty() bool {
+	return r.Time.IsZero()
+}
+
+// evaluate sets the resolvers matching the VPN location and
+// the status of the result.
+func (r *Result) evaluate() {
+	if len(r.Resolvers) == 0 {
+		r.Status = StatusError
+		r.Error = "no DNS resolver observed by the leak test service"
+		return
+	}
+
+	r.Status = StatusPassed
+	if r.ObservedIP.IsValid() && r.VPN.IP.IsValid() && r.ObservedIP != r.VPN.IP {
+		r.Status = StatusLeak
+	}
+
+	for i, resolver := range r.Resolvers {
+		r.Resolvers[i].MatchesVPN = sameCountry(resolver.Country, r.VPN.Country) ||
+			sameASN(resolver.ASN, r.VPN.ASN)
+		if !r.Resolvers[i].MatchesVPN {
+			r.Status = StatusLeak
+		}
+	}
+}
+
+func sameCountry(a, b string) bool {
+	return a != "" && strings.EqualFold(a, b)
+}
+
+var asnRegex = regexp.MustCompile(`(?i)^AS([0-9]+)\b`)
+
+// sameASN returns true if both autonomous system strings have the
+// same AS number, or if one organization name contains the other one
+// when an AS number is missing.
+func sameASN(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	aMatch, bMatch := asnRegex.FindStringSubmatch(a), asnRegex.FindStringSubmatch(b)
+	if aMatch != nil && bMatch != nil {
+		return aMatch[1] == bMatch[1]
+	}
+
+	a = strings.ToLower(strings.TrimSpace(asnRegex.ReplaceAllString(a, "")))
+	b = strings.ToLower(strings.TrimSpace(asnRegex.ReplaceAllString(b, "")))
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
@@ -0,0 +1,174 @@
+package leaktest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// StandIn is a local leak test service implementing the same API as
+// bash.ws, to be used in tests. It reports the source IP addresses of
+// the DNS queries it receives as the DNS resolvers seen.
+type StandIn struct {
+	// URL is the base URL of the stand-in HTTP server.
+	URL string
+	// Domain is the domain the stand-in DNS server is authoritative for.
+	Domain string
+	// DNSAddress is the UDP address of the stand-in DNS server.
+	DNSAddress string
+
+	httpServer *httptest.Server
+	dnsServer  *dns.Server
+
+	mutex      sync.Mutex
+	lastID     int
+	idToIPs    map[string][]netip.Addr
+	locations  map[netip.Addr]Location
+	observedIP netip.Addr
+}
+
+// NewStandIn starts a stand-in leak test service. The locations map
+// sets the country and ASN reported for resolver IP addresses, and the
+// observed IP is reported as the client public IP address if valid.
+func NewStandIn(locations map[netip.Addr]Location, observedIP netip.Addr) (
+	standIn *StandIn, err error,
+) {
+	standIn = &StandIn{
+		Domain:     "leaktest.test",
+		idToIPs:    make(map[string][]netip.Addr),
+		locations:  locations,
+		observedIP: observedIP,
+	}
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for DNS: %w", err)
+	}
+	standIn.DNSAddress = packetConn.LocalAddr().String()
+
+	started := make(chan struct{})
+	standIn.dnsServer = &dns.Server{
+		PacketConn:        packetConn,
+		Handler:           dns.HandlerFunc(standIn.serveDNS),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = standIn.dnsServer.ActivateAndServe() }()
+	<-started
+
+	standIn.httpServer = httptest.NewServer(http.HandlerFunc(standIn.serveHTTP))
+	standIn.URL = standIn.httpServer.URL
+	return standIn, nil
+}
+
+// Close stops the stand-in servers.
+func (s *StandIn) Close() {
+	s.httpServer.Close()
+	_ = s.dnsServer.Shutdown()
+}
+
+// Resolver returns a resolver sending all queries to the stand-in DNS server.
+func (s *StandIn) Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, "udp", s.DNSAddress)
+		},
+	}
+}
+
+func (s *StandIn) serveDNS(w dns.ResponseWriter, request *dns.Msg) {
+	response := new(dns.Msg).SetReply(request)
+	response.Authoritative = true
+	if len(request.Question) == 0 {
+		_ = w.WriteMsg(response)
+		return
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(request.Question[0].Name), ".")
+	labels := strings.Split(strings.TrimSuffix(name, "."+s.Domain), ".")
+	const subdomainLabels = 2 // random label and test identifier
+	if !strings.HasSuffix(name, "."+s.Domain) || len(labels) != subdomainLabels {
+		response.Rcode = dns.RcodeNameError
+		_ = w.WriteMsg(response)
+		return
+	}
+
+	clientIP := netip.MustParseAddrPort(w.RemoteAddr().String()).Addr()
+	id := labels[1]
+	s.mutex.Lock()
+	if _, ok := s.idToIPs[id]; ok && !containsIP(s.idToIPs[id], clientIP) {
+		s.idToIPs[id] = append(s.idToIPs[id], clientIP)
+	}
+	s.mutex.Unlock()
+
+	if request.Question[0].Qtype == dns.TypeA {
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{
+				Name: request.Question[0].Name, Rrtype: dns.TypeA,
+				Class: dns.ClassINET, Ttl: 0,
+			},
+			A: net.IPv4(127, 0, 0, 1),
+		}}
+	}
+	_ = w.WriteMsg(response)
+}
+
+func (s *StandIn) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.URL.Path == "/id":
+		s.lastID++
+		id := strconv.Itoa(s.lastID)
+		s.idToIPs[id] = nil
+		_, _ = w.Write([]byte(id))
+	case strings.HasPrefix(r.URL.Path, "/dnsleak/test/"):
+		id := strings.TrimPrefix(r.URL.Path, "/dnsleak/test/")
+		ips, ok := s.idToIPs[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		entries := make([]resultEntry, 0, len(ips)+1)
+		if s.observedIP.IsValid() {
+			entries = append(entries, s.entry(s.observedIP, "ip"))
+		}
+		for _, ip := range ips {
+			entries = append(entries, s.entry(ip, "dns"))
+		}
+		entries = append(entries, resultEntry{Type: "conclusion"})
+		_ = json.NewEncoder(w).Encode(entries)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *StandIn) entry(ip netip.Addr, entryType string) resultEntry {
+	location := s.locations[ip]
+	return resultEntry{
+		IP:          ip.String(),
+		CountryName: location.Country,
+		ASN:         location.ASN,
+		Type:        entryType,
+	}
+}
+
+func containsIP(ips []netip.Addr, ip netip.Addr) bool {
+	for _, existing := range ips {
+		if existing == ip {
+			return true
+		}
+	}
+	return false
+}
@@ -4,22 +4,26 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/qdm12/dns/v2/pkg/middlewares/filter/mapfilter"
-	"github.com/qdm12/dns/v2/pkg/server"
 	"github.com/qdm12/gluetun/internal/configuration/settings"
 	"github.com/qdm12/gluetun/internal/constants"
+	"github.com/qdm12/gluetun/internal/dns/leaktest"
+	"github.com/qdm12/gluetun/internal/dns/middleware/querylog"
+	"github.com/qdm12/gluetun/internal/dns/rules"
 	"github.com/qdm12/gluetun/internal/dns/state"
 	"github.com/qdm12/gluetun/internal/loopstate"
 	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/goservices"
 )
 
 type Loop struct {
 	statusManager *loopstate.State
 	state         *state.State
-	server        *server.Server
-	filter        *mapfilter.Filter
+	server        *goservices.Group
+	filter        *rules.Filter
 	resolvConf    string
 	client        *http.Client
 	logger        Logger
@@ -33,6 +37,22 @@ type Loop struct {
 	timeNow       func() time.Time
 	timeSince     func(time.Time) time.Duration
 	bypassConfig  *BypassConfig
+	queryLog      *querylog.Store
+
+	// updateFilesMutex prevents the block lists from being
+	// updated concurrently by the ticker and the startup refresh.
+	updateFilesMutex sync.Mutex
+	// refreshCancel and refreshDone are set while the block lists
+	// loaded from disk are being refreshed in the background.
+	refreshCancel context.CancelFunc
+	refreshDone   <-chan struct{}
+
+	// profileFilters maps filter profile names to their filter.
+	profileFilters      map[string]*rules.Filter
+	profileFiltersMutex sync.Mutex
+
+	leakTestResult leaktest.Result
+	leakTestMutex  sync.RWMutex
 }
 
 const defaultBackoffTime = 10 * time.Second
@@ -49,40 +69,57 @@ func NewLoop(settings settings.DNS,
 	statusManager := loopstate.New(constants.Stopped, start, running, stop, stopped)
 	state := state.New(statusManager, settings, updateTicker)
 
-	filter, err := mapfilter.New(mapfilter.Settings{})
+	mapFilter, err := mapfilter.New(mapfilter.Settings{})
 	if err != nil {
 		return nil, fmt.Errorf("creating map filter: %w", err)
 	}
+	filter := rules.NewFilter(mapFilter)
 
 	// Configure DNS bypass for specified domains
 	var bypassConfig *BypassConfig
-	if len(settings.BypassDomains) > 0 {
-		bypassConfig, err = DetectBypassConfig(settings.BypassDomains, settings.BypassResolver)
-		if err != nil {
+	if len(settings.BypassDomains) > 0 || *settings.BypassSearchDomains {
+		bypassConfig, err = DetectBypassConfig(settings.BypassDomains,
+			settings.BypassResolver, *settings.BypassSearchDomains)
+		switch {
+		case err != nil:
 			logger.Warn(fmt.Sprintf("failed to configure DNS bypass: %v", err))
-		} else {
+		case bypassConfig == nil:
+			logger.Warn("no DNS bypass domain found, DNS bypass is disabled")
+		default:
 			logger.Info(fmt.Sprintf("DNS bypass configured for domains: %v", bypassConfig.Domains))
+			if len(bypassConfig.SearchDomains) > 0 {
+				logger.Info(fmt.Sprintf("DNS bypass expanding names with search domains %v and ndots %d",
+					bypassConfig.SearchDomains, bypassConfig.Ndots))
+			}
 		}
 	}
 
+	queryLog := querylog.NewStore(querylog.StoreSettings{
+		MaxEntries: *settings.DoT.QueryLog.MaxEntries,
+		Filepath:   *settings.DoT.QueryLog.Filepath,
+		Logger:     logger,
+	})
+
 	return &Loop{
-		statusManager: statusManager,
-		state:         state,
-		server:        nil,
-		filter:        filter,
-		resolvConf:    "/etc/resolv.conf",
-		client:        client,
-		logger:        logger,
-		userTrigger:   true,
-		start:         start,
-		running:       running,
-		stop:          stop,
-		stopped:       stopped,
-		updateTicker:  updateTicker,
-		backoffTime:   defaultBackoffTime,
-		timeNow:       time.Now,
-		timeSince:     time.Since,
-		bypassConfig:  bypassConfig,
+		statusManager:  statusManager,
+		state:          state,
+		server:         nil,
+		filter:         filter,
+		resolvConf:     "/etc/resolv.conf",
+		client:         client,
+		logger:         logger,
+		userTrigger:    true,
+		start:          start,
+		running:        running,
+		stop:           stop,
+		stopped:        stopped,
+		updateTicker:   updateTicker,
+		backoffTime:    defaultBackoffTime,
+		timeNow:        time.Now,
+		timeSince:      time.Since,
+		bypassConfig:   bypassConfig,
+		queryLog:       queryLog,
+		profileFilters: make(map[string]*rules.Filter),
 	}, nil
 }
 
@@ -113,3 +150,28 @@ func (l *Loop) signalOrSetStatus(status models.LoopStatus) {
 		l.statusManager.SetStatus(status)
 	}
 }
+
+// getProfileFilters returns the filters of the profiles given,
+// creating the filters of new profiles.
+func (l *Loop) getProfileFilters(profiles []settings.DNSFilterProfile) (
+	filters map[string]*rules.Filter,
+) {
+	l.profileFiltersMutex.Lock()
+	defer l.profileFiltersMutex.Unlock()
+
+	filters = make(map[string]*rules.Filter, len(profiles))
+	for _, profile := range profiles {
+		filter, ok := l.profileFilters[profile.Name]
+		if !ok {
+			mapFilter, err := mapfilter.New(mapfilter.Settings{})
+			if err != nil {
+				// an empty map filter settings is always valid
+				panic(fmt.Sprintf("creating map filter: %s", err))
+			}
+			filter = rules.NewFilter(mapFilter)
+			l.profileFilters[profile.Name] = filter
+		}
+		filters[profile.Name] = filter
+	}
+	return filters
+}
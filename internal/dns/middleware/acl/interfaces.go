@@ -0,0 +1,5 @@
+package acl
+
+type Logger interface {
+	Debug(message string)
+}
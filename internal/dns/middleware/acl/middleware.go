@@ -0,0 +1,85 @@
+package acl
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/clientip"
+)
+
+// Middleware refuses queries from clients outside the allowed subnets.
+// Queries from loopback clients are always allowed.
+type Middleware struct {
+	allowedSubnets []netip.Prefix
+	logger         Logger
+}
+
+type Settings struct {
+	// AllowedSubnets are the client subnets allowed, and
+	// must contain at least one subnet.
+	AllowedSubnets []netip.Prefix
+	// Logger is the logger to log refused clients at the debug level,
+	// and must be set.
+	Logger Logger
+}
+
+var (
+	ErrAllowedSubnetsEmpty = errors.New("allowed subnets are empty")
+	ErrLoggerNotSet        = errors.New("logger is not set")
+)
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	switch {
+	case len(settings.AllowedSubnets) == 0:
+		return nil, fmt.Errorf("%w", ErrAllowedSubnetsEmpty)
+	case settings.Logger == nil:
+		return nil, fmt.Errorf("%w", ErrLoggerNotSet)
+	}
+
+	return &Middleware{
+		allowedSubnets: settings.AllowedSubnets,
+		logger:         settings.Logger,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "client access control"
+}
+
+func (m *Middleware) Stop() (err error) {
+	return nil
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		clientIP := clientip.FromAddr(w.RemoteAddr())
+		if m.IsAllowed(clientIP) {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		m.logger.Debug("refusing query from client " + clientIP.String())
+		response := new(dns.Msg).SetRcode(request, dns.RcodeRefused)
+		_ = w.WriteMsg(response)
+	})
+}
+
+// IsAllowed returns true if the client IP address is a loopback
+// address or is contained in one of the allowed subnets.
+func (m *Middleware) IsAllowed(clientIP netip.Addr) bool {
+	if !clientIP.IsValid() {
+		return false
+	}
+	clientIP = clientIP.Unmap()
+	if clientIP.IsLoopback() {
+		return true
+	}
+	for _, subnet := range m.allowedSubnets {
+		if subnet.Contains(clientIP) {
+			return true
+		}
+	}
+	return false
+}
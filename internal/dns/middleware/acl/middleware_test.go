@@ -0,0 +1,83 @@
+package acl
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	dns.ResponseWriter
+	remoteAddr net.Addr
+	response   *dns.Msg
+}
+
+func (w *testWriter) RemoteAddr() net.Addr { return w.remoteAddr }
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string) {}
+
+func Test_Middleware_Wrap(t *testing.T) {
+	t.Parallel()
+
+	middleware, err := New(Settings{
+		AllowedSubnets: []netip.Prefix{
+			netip.MustParsePrefix("192.168.1.0/24"),
+			netip.MustParsePrefix("fd00::/8"),
+		},
+		Logger: noopLogger{},
+	})
+	require.NoError(t, err)
+
+	next := dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		_ = w.WriteMsg(new(dns.Msg).SetReply(request))
+	})
+	handler := middleware.Wrap(next)
+
+	testCases := map[string]struct {
+		remoteAddr net.Addr
+		rcode      int
+	}{
+		"allowed_ipv4_udp": {
+			remoteAddr: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 5000},
+			rcode:      dns.RcodeSuccess,
+		},
+		"allowed_ipv6_tcp": {
+			remoteAddr: &net.TCPAddr{IP: net.ParseIP("fd12::1"), Port: 5000},
+			rcode:      dns.RcodeSuccess,
+		},
+		"loopback": {
+			remoteAddr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000},
+			rcode:      dns.RcodeSuccess,
+		},
+		"refused": {
+			remoteAddr: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000},
+			rcode:      dns.RcodeRefused,
+		},
+		"no_remote_address": {
+			rcode: dns.RcodeRefused,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+			writer := &testWriter{remoteAddr: testCase.remoteAddr}
+			handler.ServeDNS(writer, request)
+			require.NotNil(t, writer.response)
+			assert.Equal(t, testCase.rcode, writer.response.Rcode)
+		})
+	}
+}
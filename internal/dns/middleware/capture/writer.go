@@ -0,0 +1,29 @@
+package capture
+
+import (
+	"github.com/miekg/dns"
+)
+
+// Writer is a response writer keeping the response written
+// instead of sending it to the client. Its other methods are
+// the ones of the parent response writer given to New.
+type Writer struct {
+	dns.ResponseWriter
+	Response *dns.Msg
+}
+
+// New returns a capture writer wrapping the parent response writer,
+// which can be nil if the request does not come from a client.
+func New(parent dns.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: parent}
+}
+
+func (w *Writer) WriteMsg(response *dns.Msg) error {
+	w.Response = response
+	return nil
+}
+
+// Unwrap returns the parent response writer.
+func (w *Writer) Unwrap() dns.ResponseWriter { //nolint:ireturn
+	return w.ResponseWriter
+}
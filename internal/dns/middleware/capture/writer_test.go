@@ -0,0 +1,34 @@
+package capture
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type parentWriter struct {
+	dns.ResponseWriter
+	written *dns.Msg
+}
+
+func (p *parentWriter) WriteMsg(response *dns.Msg) error {
+	p.written = response
+	return nil
+}
+
+func Test_Writer(t *testing.T) {
+	t.Parallel()
+
+	parent := &parentWriter{}
+	writer := New(parent)
+	response := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+	err := writer.WriteMsg(response)
+
+	require.NoError(t, err)
+	assert.Same(t, response, writer.Response)
+	assert.Nil(t, parent.written)
+	assert.Same(t, parent, writer.Unwrap())
+}
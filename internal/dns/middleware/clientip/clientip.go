@@ -0,0 +1,28 @@
+package clientip
+
+import (
+	"net"
+	"net/netip"
+)
+
+// FromAddr returns the IP address of the client address given,
+// with any IPv4-mapped IPv6 address unmapped. It returns an invalid
+// address if the address is nil or cannot be parsed.
+func FromAddr(address net.Addr) (ip netip.Addr) {
+	var addrPort netip.AddrPort
+	switch typedAddress := address.(type) {
+	case *net.UDPAddr:
+		addrPort = typedAddress.AddrPort()
+	case *net.TCPAddr:
+		addrPort = typedAddress.AddrPort()
+	case nil:
+		return netip.Addr{}
+	default:
+		var err error
+		addrPort, err = netip.ParseAddrPort(address.String())
+		if err != nil {
+			return netip.Addr{}
+		}
+	}
+	return addrPort.Addr().Unmap()
+}
@@ -0,0 +1,50 @@
+package clientip
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stringAddr string
+
+func (s stringAddr) Network() string { return "test" }
+func (s stringAddr) String() string  { return string(s) }
+
+func Test_FromAddr(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		address net.Addr
+		ip      netip.Addr
+	}{
+		"nil": {},
+		"udp": {
+			address: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 53},
+			ip:      netip.MustParseAddr("192.168.1.5"),
+		},
+		"tcp_ipv6": {
+			address: &net.TCPAddr{IP: net.ParseIP("fd00::1"), Port: 853},
+			ip:      netip.MustParseAddr("fd00::1"),
+		},
+		"string": {
+			address: stringAddr("[::ffff:10.0.0.1]:443"),
+			ip:      netip.MustParseAddr("10.0.0.1"),
+		},
+		"malformed_string": {
+			address: stringAddr("malformed"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ip := FromAddr(testCase.address)
+
+			assert.Equal(t, testCase.ip, ip)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package dnssec
+
+import (
+	"fmt"
+
+	"github.com/miekg/dns"
+)
+
+// rootTrustAnchors are the DS records of the root zone key signing keys,
+// as published by IANA at https://data.iana.org/root-anchors/root-anchors.xml
+//
+//nolint:gochecknoglobals
+var rootTrustAnchors = []string{
+	// KSK-2017
+	". 0 IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	// KSK-2024
+	". 0 IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// RootTrustAnchors returns the built-in root zone trust anchors.
+func RootTrustAnchors() (anchors []*dns.DS) {
+	anchors = make([]*dns.DS, len(rootTrustAnchors))
+	for i, s := range rootTrustAnchors {
+		var err error
+		anchors[i], err = ParseTrustAnchor(s)
+		if err != nil {
+			panic(err) // hardcoded trust anchors must be valid
+		}
+	}
+	return anchors
+}
+
+// ParseTrustAnchor parses a DS record in its presentation format,
+// for example `example.com. IN DS 12345 13 2 ABCDEF...`.
+func ParseTrustAnchor(s string) (ds *dns.DS, err error) {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing DS record: %w", err)
+	}
+
+	ds, ok := rr.(*dns.DS)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTrustAnchorNotDS, dns.TypeToString[rr.Header().Rrtype])
+	}
+	return ds, nil
+}
@@ -0,0 +1,160 @@
+package dnssec
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type dsDenialResult uint8
+
+const (
+	denialNotProven dsDenialResult = iota
+	// denialNoZoneCut is when the name is proven not to be a zone cut.
+	denialNoZoneCut
+	// denialInsecureDelegation is when the name is proven to be an
+	// unsigned delegation.
+	denialInsecureDelegation
+)
+
+// dsDenial returns what the authority records prove about
+// the name, for a DS query without DS records in its answer.
+func dsDenial(name string, authority []dns.RR) (result dsDenialResult) {
+	for _, nsec := range extractType[*dns.NSEC](authority) {
+		if dns.CanonicalName(nsec.Hdr.Name) == name {
+			return denialFromTypes(nsec.TypeBitMap)
+		}
+	}
+
+	if nsecCovers(extractType[*dns.NSEC](authority), name) {
+		return denialNoZoneCut
+	}
+
+	nsec3s := extractType[*dns.NSEC3](authority)
+	for _, nsec3 := range nsec3s {
+		if nsec3.Match(name) {
+			return denialFromTypes(nsec3.TypeBitMap)
+		}
+	}
+
+	for _, nsec3 := range nsec3s {
+		if nsec3.Cover(name) {
+			if nsec3.Flags&optOutFlag != 0 {
+				// An unsigned delegation may exist within an opt-out span.
+				return denialInsecureDelegation
+			}
+			return denialNoZoneCut
+		}
+	}
+
+	return denialNotProven
+}
+
+const optOutFlag = 1
+
+func denialFromTypes(types []uint16) dsDenialResult {
+	switch {
+	case containsType(types, dns.TypeDS):
+		return denialNotProven
+	case containsType(types, dns.TypeNS) && !containsType(types, dns.TypeSOA):
+		return denialInsecureDelegation
+	default:
+		return denialNoZoneCut
+	}
+}
+
+// nsecCovers returns true if one of the NSEC records
+// proves the name does not exist.
+func nsecCovers(nsecs []*dns.NSEC, name string) bool {
+	for _, nsec := range nsecs {
+		owner := dns.CanonicalName(nsec.Hdr.Name)
+		next := dns.CanonicalName(nsec.NextDomain)
+		afterOwner := canonicalCompare(owner, name) < 0
+		beforeNext := canonicalCompare(name, next) < 0
+		if canonicalCompare(owner, next) < 0 {
+			if afterOwner && beforeNext {
+				return true
+			}
+		} else if afterOwner || beforeNext { // last NSEC record of the zone
+			return true
+		}
+	}
+	return false
+}
+
+func nsecProvesNoData(nsecs []*dns.NSEC, name string, qtype uint16) bool {
+	for _, nsec := range nsecs {
+		if dns.CanonicalName(nsec.Hdr.Name) != name {
+			continue
+		}
+		return !containsType(nsec.TypeBitMap, qtype) &&
+			!containsType(nsec.TypeBitMap, dns.TypeCNAME)
+	}
+	return false
+}
+
+// nsec3ProvesNameError returns true if the NSEC3 records contain
+// a closest encloser proof for the name.
+func nsec3ProvesNameError(nsec3s []*dns.NSEC3, name string) bool {
+	nextCloser := name
+	for closestEncloser := parentName(name); closestEncloser != ""; closestEncloser = parentName(closestEncloser) {
+		if nsec3Matches(nsec3s, closestEncloser) {
+			return nsec3CoversName(nsec3s, nextCloser)
+		}
+		nextCloser = closestEncloser
+	}
+	return false
+}
+
+func nsec3ProvesNoData(nsec3s []*dns.NSEC3, name string, qtype uint16) bool {
+	for _, nsec3 := range nsec3s {
+		if nsec3.Match(name) {
+			return !containsType(nsec3.TypeBitMap, qtype) &&
+				!containsType(nsec3.TypeBitMap, dns.TypeCNAME)
+		}
+	}
+
+	if qtype != dns.TypeDS {
+		return false
+	}
+
+	// A DS query for an unsigned delegation within an opt-out span
+	// is proven by an opt-out NSEC3 record covering the name.
+	for _, nsec3 := range nsec3s {
+		if nsec3.Flags&optOutFlag != 0 && nsec3.Cover(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func nsec3Matches(nsec3s []*dns.NSEC3, name string) bool {
+	for _, nsec3 := range nsec3s {
+		if nsec3.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func nsec3CoversName(nsec3s []*dns.NSEC3, name string) bool {
+	for _, nsec3 := range nsec3s {
+		if nsec3.Cover(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalCompare compares two fully qualified domain names
+// using the canonical DNS name order defined in RFC 4034 section 6.1.
+func canonicalCompare(a, b string) int {
+	aLabels := dns.SplitDomainName(strings.ToLower(a))
+	bLabels := dns.SplitDomainName(strings.ToLower(b))
+	for i, j := len(aLabels)-1, len(bLabels)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(aLabels[i], bLabels[j]); c != 0 {
+			return c
+		}
+	}
+	return len(aLabels) - len(bLabels)
+}
@@ -0,0 +1,5 @@
+package dnssec
+
+type Warner interface {
+	Warn(message string)
+}
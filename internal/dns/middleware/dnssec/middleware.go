@@ -0,0 +1,177 @@
+package dnssec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+// Middleware validates DNSSEC signed responses from the next handler.
+// Validated responses have their AD bit set, and bogus responses are
+// replaced with a SERVFAIL response.
+type Middleware struct {
+	anchors map[string][]*dns.DS
+	skip    func(name string) bool
+	logger  Warner
+	zones   *zoneCache
+	timeNow func() time.Time
+}
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	err = settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("validating settings: %w", err)
+	}
+
+	anchors := make(map[string][]*dns.DS)
+	trustAnchors := append(RootTrustAnchors(), settings.TrustAnchors...) //nolint:gocritic
+	for _, anchor := range trustAnchors {
+		zone := dns.CanonicalName(anchor.Hdr.Name)
+		anchors[zone] = append(anchors[zone], anchor)
+	}
+
+	return &Middleware{
+		anchors: anchors,
+		skip:    settings.Skip,
+		logger:  settings.Logger,
+		zones:   newZoneCache(),
+		timeNow: time.Now,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "dnssec"
+}
+
+func (m *Middleware) Stop() (err error) {
+	return nil
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		if len(request.Question) == 0 || request.CheckingDisabled ||
+			(m.skip != nil && m.skip(request.Question[0].Name)) {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		// The request is modified in place, instead of copied, so the
+		// middlewares further down the chain still see the same request.
+		clientEDNS, clientDO := setDO(request)
+		writer := capture.New(w)
+		next.ServeDNS(writer, request)
+		unsetDO(request, clientEDNS, clientDO)
+
+		response := writer.Response
+		if response == nil {
+			dns.HandleFailed(w, request)
+			return
+		}
+
+		result := insecure
+		var err error
+		if !m.skipResponse(response) {
+			v := &validator{
+				next:    next,
+				anchors: m.anchors,
+				zones:   m.zones,
+				now:     m.timeNow(),
+			}
+			result, err = v.validateResponse(request.Question[0], response)
+		}
+
+		if result == bogus {
+			question := request.Question[0]
+			m.logger.Warn(fmt.Sprintf("DNSSEC validation failed for %s %s: %s",
+				question.Name, dns.TypeToString[question.Qtype], err))
+			_ = w.WriteMsg(new(dns.Msg).SetRcode(request, dns.RcodeServerFailure))
+			return
+		}
+
+		response = response.Copy()
+		response.AuthenticatedData = result == secure
+		if !clientDO {
+			stripDNSSECRecords(response, clientEDNS)
+		}
+		_ = w.WriteMsg(response)
+	})
+}
+
+// skipResponse returns true if one of the answers is for a name to skip,
+// for example for a short name expanded to a bypass domain further down
+// the middleware chain.
+func (m *Middleware) skipResponse(response *dns.Msg) bool {
+	if m.skip == nil {
+		return false
+	}
+	for _, answer := range response.Answer {
+		if m.skip(answer.Header().Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setDO sets the DNSSEC OK bit on the request, adding an EDNS0 OPT record
+// if needed. It returns whether the request had an EDNS0 OPT record and
+// the DO bit set, in order to restore the request with unsetDO.
+func setDO(request *dns.Msg) (hadEDNS, hadDO bool) {
+	opt := request.IsEdns0()
+	if opt == nil {
+		const udpSize = 1232
+		request.SetEdns0(udpSize, true)
+		return false, false
+	}
+	hadDO = opt.Do()
+	opt.SetDo()
+	return true, hadDO
+}
+
+func unsetDO(request *dns.Msg, hadEDNS, hadDO bool) {
+	if !hadEDNS {
+		request.Extra = removeOPT(request.Extra)
+		return
+	}
+	request.IsEdns0().SetDo(hadDO)
+}
+
+func removeOPT(rrs []dns.RR) (filtered []dns.RR) {
+	filtered = make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		if rr.Header().Rrtype != dns.TypeOPT {
+			filtered = append(filtered, rr)
+		}
+	}
+	return filtered
+}
+
+// stripDNSSECRecords removes the DNSSEC records the client did not ask for.
+func stripDNSSECRecords(response *dns.Msg, clientEDNS bool) {
+	response.Answer = removeTypes(response.Answer, dns.TypeRRSIG)
+	response.Ns = removeTypes(response.Ns, dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3)
+	response.Extra = removeTypes(response.Extra, dns.TypeRRSIG)
+	if !clientEDNS {
+		response.Extra = removeOPT(response.Extra)
+	}
+}
+
+func removeTypes(rrs []dns.RR, types ...uint16) (filtered []dns.RR) {
+	filtered = make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		if !containsType(types, rr.Header().Rrtype) {
+			filtered = append(filtered, rr)
+		}
+	}
+	return filtered
+}
+
+func containsType(types []uint16, rrType uint16) bool {
+	for _, t := range types {
+		if t == rrType {
+			return true
+		}
+	}
+	return false
+}
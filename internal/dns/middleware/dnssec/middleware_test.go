@@ -0,0 +1,325 @@
+package dnssec
+
+import (
+	"crypto"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testZone struct {
+	name   string
+	key    *dns.DNSKEY
+	signer crypto.Signer
+}
+
+func newTestZone(t *testing.T, name string) *testZone {
+	t.Helper()
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	const bits = 256
+	privateKey, err := key.Generate(bits)
+	require.NoError(t, err)
+	signer, ok := privateKey.(crypto.Signer)
+	require.True(t, ok)
+	return &testZone{name: name, key: key, signer: signer}
+}
+
+func (z *testZone) ds() *dns.DS {
+	return z.key.ToDS(dns.SHA256)
+}
+
+func (z *testZone) sign(t *testing.T, rrset ...dns.RR) []dns.RR {
+	t.Helper()
+	header := rrset[0].Header()
+	now := time.Now()
+	signature := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: header.Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: header.Ttl},
+		Inception:  uint32(now.Add(-time.Hour).Unix()),
+		Expiration: uint32(now.Add(time.Hour).Unix()),
+		KeyTag:     z.key.KeyTag(),
+		SignerName: z.name,
+		Algorithm:  z.key.Algorithm,
+	}
+	err := signature.Sign(z.signer, rrset)
+	require.NoError(t, err)
+	return append(rrset, signature)
+}
+
+func newA(name, ip string) *dns.A {
+	return &dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 300},
+		A:   netip.MustParseAddr(ip).AsSlice(),
+	}
+}
+
+func newNSEC(name, next string, types ...uint16) *dns.NSEC {
+	return &dns.NSEC{
+		Hdr:        dns.RR_Header{Name: name, Rrtype: dns.TypeNSEC, Class: dns.ClassINET, Ttl: 300},
+		NextDomain: next,
+		TypeBitMap: types,
+	}
+}
+
+type testResponse struct {
+	rcode  int
+	answer []dns.RR
+	ns     []dns.RR
+}
+
+// newTestUpstream returns a handler acting as an upstream resolver
+// for the locally signed zones below:
+//   - example. signed, and a trust anchor
+//   - secure.example. signed, and securely delegated from example.
+//   - insecure.example. unsigned, and securely delegated from example.
+func newTestUpstream(t *testing.T) (upstream dns.Handler, anchor *dns.DS) { //nolint:ireturn
+	t.Helper()
+
+	example := newTestZone(t, "example.")
+	secureChild := newTestZone(t, "secure.example.")
+
+	badA := example.sign(t, newA("bad.example.", "192.0.2.2"))
+	badA[0].(*dns.A).A = netip.MustParseAddr("192.0.2.3").AsSlice() //nolint:forcetypeassert
+
+	secureDS := secureChild.ds()
+	secureDS.Hdr.Ttl = 300
+
+	nameNSECs := example.sign(t, newNSEC("insecure.example.", "secure.example.",
+		dns.TypeNS, dns.TypeRRSIG, dns.TypeNSEC))
+
+	responses := map[string]testResponse{
+		"example. DNSKEY":        {answer: example.sign(t, example.key)},
+		"www.example. DS":        {ns: example.sign(t, newNSEC("www.example.", "example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))},
+		"www.example. A":         {answer: example.sign(t, newA("www.example.", "192.0.2.1"))},
+		"www.example. AAAA":      {ns: example.sign(t, newNSEC("www.example.", "example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))},
+		"bad.example. DS":        {ns: example.sign(t, newNSEC("bad.example.", "insecure.example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))},
+		"bad.example. A":         {answer: badA},
+		"nosig.example. DS":      {ns: example.sign(t, newNSEC("nosig.example.", "secure.example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))},
+		"nosig.example. A":       {answer: []dns.RR{newA("nosig.example.", "192.0.2.4")}},
+		"nothere.example. DS":    {rcode: dns.RcodeNameError, ns: nameNSECs},
+		"nothere.example. A":     {rcode: dns.RcodeNameError, ns: nameNSECs},
+		"zzz.example. DS":        {rcode: dns.RcodeNameError, ns: nameNSECs},
+		"zzz.example. A":         {rcode: dns.RcodeNameError, ns: nameNSECs},
+		"secure.example. DS":     {answer: example.sign(t, secureDS)},
+		"secure.example. DNSKEY": {answer: secureChild.sign(t, secureChild.key)},
+		"host.secure.example. DS": {ns: secureChild.sign(t,
+			newNSEC("host.secure.example.", "secure.example.", dns.TypeA, dns.TypeRRSIG, dns.TypeNSEC))},
+		"host.secure.example. A": {answer: secureChild.sign(t, newA("host.secure.example.", "192.0.2.10"))},
+		"insecure.example. DS":   {ns: nameNSECs},
+		"short. A": {answer: []dns.RR{
+			&dns.CNAME{
+				Hdr:    dns.RR_Header{Name: "short.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+				Target: "nosig.example.",
+			},
+			newA("nosig.example.", "192.0.2.4"),
+		}},
+		"host.insecure.example. A": {answer: []dns.RR{newA("host.insecure.example.", "192.0.2.20")}},
+	}
+
+	upstream = dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		question := request.Question[0]
+		key := question.Name + " " + dns.TypeToString[question.Qtype]
+		testResponse, ok := responses[key]
+		if !ok {
+			_ = w.WriteMsg(new(dns.Msg).SetRcode(request, dns.RcodeServerFailure))
+			return
+		}
+		response := new(dns.Msg).SetRcode(request, testResponse.rcode)
+		response.Answer = testResponse.answer
+		response.Ns = testResponse.ns
+		if opt := request.IsEdns0(); opt != nil {
+			response.SetEdns0(opt.UDPSize(), opt.Do())
+		}
+		_ = w.WriteMsg(response)
+	})
+
+	anchor = example.ds()
+	return upstream, anchor
+}
+
+type testWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+type testWarner struct {
+	warnings []string
+}
+
+func (w *testWarner) Warn(message string) {
+	w.warnings = append(w.warnings, message)
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	upstream, anchor := newTestUpstream(t)
+
+	testCases := map[string]struct {
+		name          string
+		qtype         uint16
+		clientDO      bool
+		skip          func(name string) bool
+		timeOffset    time.Duration
+		rcode         int
+		authenticated bool
+		answers       int
+		warning       string
+	}{
+		"signed answer": {
+			name:          "www.example.",
+			qtype:         dns.TypeA,
+			rcode:         dns.RcodeSuccess,
+			authenticated: true,
+			answers:       1,
+		},
+		"signed answer with DNSSEC records": {
+			name:          "www.example.",
+			qtype:         dns.TypeA,
+			clientDO:      true,
+			rcode:         dns.RcodeSuccess,
+			authenticated: true,
+			answers:       2,
+		},
+		"signed answer in securely delegated zone": {
+			name:          "host.secure.example.",
+			qtype:         dns.TypeA,
+			rcode:         dns.RcodeSuccess,
+			authenticated: true,
+			answers:       1,
+		},
+		"unsigned answer in insecure delegated zone": {
+			name:    "host.insecure.example.",
+			qtype:   dns.TypeA,
+			rcode:   dns.RcodeSuccess,
+			answers: 1,
+		},
+		"signed no data": {
+			name:          "www.example.",
+			qtype:         dns.TypeAAAA,
+			rcode:         dns.RcodeSuccess,
+			authenticated: true,
+		},
+		"signed name error": {
+			name:          "nothere.example.",
+			qtype:         dns.TypeA,
+			rcode:         dns.RcodeNameError,
+			authenticated: true,
+		},
+		"name error not proven": {
+			name:    "zzz.example.",
+			qtype:   dns.TypeA,
+			rcode:   dns.RcodeServerFailure,
+			warning: "DNSSEC validation failed for zzz.example. A: zone of zzz.example.: absence of DS records is not proven",
+		},
+		"tampered answer": {
+			name:    "bad.example.",
+			qtype:   dns.TypeA,
+			rcode:   dns.RcodeServerFailure,
+			warning: "DNSSEC validation failed for bad.example. A: answer bad.example. A: signer example.: dns: bad signature",
+		},
+		"missing signature": {
+			name:    "nosig.example.",
+			qtype:   dns.TypeA,
+			rcode:   dns.RcodeServerFailure,
+			warning: "DNSSEC validation failed for nosig.example. A: answer nosig.example. A: missing signature",
+		},
+		"expired signatures": {
+			name:       "www.example.",
+			qtype:      dns.TypeA,
+			timeOffset: 2 * time.Hour,
+			rcode:      dns.RcodeServerFailure,
+			warning: "DNSSEC validation failed for www.example. A: answer www.example. A: " +
+				"zone of www.example.: zone of example.: verifying DNSKEY records of example.: " +
+				"signer example.: signature is not in its validity period",
+		},
+		"skipped answer name": {
+			name:    "short.",
+			qtype:   dns.TypeA,
+			skip:    func(name string) bool { return name == "nosig.example." },
+			rcode:   dns.RcodeSuccess,
+			answers: 2,
+		},
+		"skipped name": {
+			name:    "nosig.example.",
+			qtype:   dns.TypeA,
+			skip:    func(name string) bool { return strings.HasPrefix(name, "nosig.") },
+			rcode:   dns.RcodeSuccess,
+			answers: 1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			warner := &testWarner{}
+			middleware, err := New(Settings{
+				TrustAnchors: []*dns.DS{anchor},
+				Skip:         testCase.skip,
+				Logger:       warner,
+			})
+			require.NoError(t, err)
+			middleware.timeNow = func() time.Time {
+				return time.Now().Add(testCase.timeOffset)
+			}
+
+			request := new(dns.Msg).SetQuestion(testCase.name, testCase.qtype)
+			if testCase.clientDO {
+				request.SetEdns0(dns.DefaultMsgSize, true)
+			}
+			writer := &testWriter{}
+			middleware.Wrap(upstream).ServeDNS(writer, request)
+
+			response := writer.response
+			require.NotNil(t, response)
+			assert.Equal(t, dns.RcodeToString[testCase.rcode], dns.RcodeToString[response.Rcode])
+			assert.Equal(t, testCase.authenticated, response.AuthenticatedData)
+			assert.Len(t, response.Answer, testCase.answers)
+			assert.Equal(t, testCase.clientDO, response.IsEdns0() != nil)
+			assert.Equal(t, testCase.clientDO, request.IsEdns0() != nil,
+				"request must be restored")
+			if testCase.warning == "" {
+				assert.Empty(t, warner.warnings)
+			} else {
+				assert.Equal(t, []string{testCase.warning}, warner.warnings)
+			}
+		})
+	}
+}
+
+func Test_RootTrustAnchors(t *testing.T) {
+	t.Parallel()
+
+	anchors := RootTrustAnchors()
+	require.Len(t, anchors, 2)
+	for _, anchor := range anchors {
+		assert.Equal(t, ".", anchor.Hdr.Name)
+		assert.Equal(t, dns.RSASHA256, anchor.Algorithm)
+	}
+}
+
+func Test_canonicalCompare(t *testing.T) {
+	t.Parallel()
+
+	ordered := []string{"example.", "a.example.", "yljkjljk.a.example.",
+		"Z.a.example.", "zABC.a.EXAMPLE.", "z.example.", "*.z.example."}
+	for i := 0; i < len(ordered)-1; i++ {
+		assert.Negative(t, canonicalCompare(ordered[i], ordered[i+1]),
+			"%s < %s", ordered[i], ordered[i+1])
+	}
+}
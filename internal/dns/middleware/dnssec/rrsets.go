@@ -0,0 +1,87 @@
+package dnssec
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type rrsetKey struct {
+	name   string
+	rrType uint16
+}
+
+// groupRRsets groups the resource records, excluding signatures,
+// by owner name and type, keeping the order of first appearance.
+func groupRRsets(rrs []dns.RR) (rrsets [][]dns.RR) {
+	indexes := make(map[rrsetKey]int)
+	for _, rr := range rrs {
+		header := rr.Header()
+		if header.Rrtype == dns.TypeRRSIG || header.Rrtype == dns.TypeOPT {
+			continue
+		}
+		key := rrsetKey{name: dns.CanonicalName(header.Name), rrType: header.Rrtype}
+		index, ok := indexes[key]
+		if !ok {
+			index = len(rrsets)
+			indexes[key] = index
+			rrsets = append(rrsets, nil)
+		}
+		rrsets[index] = append(rrsets[index], rr)
+	}
+	return rrsets
+}
+
+// signaturesFor returns the signatures covering the RRset with
+// the given owner name and type.
+func signaturesFor(rrs []dns.RR, name string, rrType uint16) (signatures []*dns.RRSIG) {
+	for _, rr := range rrs {
+		signature, ok := rr.(*dns.RRSIG)
+		if !ok || signature.TypeCovered != rrType ||
+			!strings.EqualFold(signature.Hdr.Name, name) {
+			continue
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures
+}
+
+func hasSignatures(rrs []dns.RR) bool {
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == dns.TypeRRSIG {
+			return true
+		}
+	}
+	return false
+}
+
+func extractType[T dns.RR](rrs []dns.RR) (extracted []T) {
+	for _, rr := range rrs {
+		typed, ok := rr.(T)
+		if ok {
+			extracted = append(extracted, typed)
+		}
+	}
+	return extracted
+}
+
+func minTTL(rrs []dns.RR) (ttl uint32) {
+	ttl = ^uint32(0)
+	for _, rr := range rrs {
+		ttl = min(ttl, rr.Header().Ttl)
+	}
+	return ttl
+}
+
+// parentName returns the parent domain name of the given
+// fully qualified name, or the empty string for the root.
+func parentName(name string) (parent string) {
+	if name == "." {
+		return ""
+	}
+	_, parent, found := strings.Cut(name, ".")
+	if !found || parent == "" {
+		return "."
+	}
+	return parent
+}
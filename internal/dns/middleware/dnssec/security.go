@@ -0,0 +1,14 @@
+package dnssec
+
+type security uint8
+
+const (
+	// insecure is for responses which cannot be validated,
+	// because no trust anchor covers them or because they
+	// belong to a zone securely delegated as unsigned.
+	insecure security = iota
+	// secure is for responses validated up to a trust anchor.
+	secure
+	// bogus is for responses failing validation.
+	bogus
+)
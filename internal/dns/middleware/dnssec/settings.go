@@ -0,0 +1,31 @@
+package dnssec
+
+import (
+	"errors"
+
+	"github.com/miekg/dns"
+)
+
+type Settings struct {
+	// TrustAnchors are DS records of additional trusted zones,
+	// on top of the built-in root zone trust anchors.
+	TrustAnchors []*dns.DS
+	// Skip returns true if validation should be skipped for the
+	// given query name, or for a response containing an answer for
+	// that name. It can be left nil to validate all queries.
+	Skip func(name string) bool
+	// Logger is used to log bogus responses and must be set.
+	Logger Warner
+}
+
+var (
+	ErrTrustAnchorNotDS = errors.New("trust anchor is not a DS record")
+	ErrLoggerNotSet     = errors.New("logger is not set")
+)
+
+func (s Settings) validate() (err error) {
+	if s.Logger == nil {
+		return ErrLoggerNotSet
+	}
+	return nil
+}
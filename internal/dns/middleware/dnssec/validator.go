@@ -0,0 +1,383 @@
+package dnssec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+var (
+	errNoResponse           = errors.New("no response received")
+	errQueryFailed          = errors.New("query failed")
+	errMissingSignature     = errors.New("missing signature")
+	errSignatureExpired     = errors.New("signature is not in its validity period")
+	errNoValidSignature     = errors.New("no valid signature found")
+	errNoTrustedKey         = errors.New("no DNSKEY matches the DS records")
+	errDSDenialNotProven    = errors.New("absence of DS records is not proven")
+	errNameErrorNotProven   = errors.New("name error is not proven")
+	errNoDataNotProven      = errors.New("absence of data is not proven")
+	errMissingDenialRecords = errors.New("missing denial of existence records")
+)
+
+// validator validates a single response, and is not safe for
+// concurrent use. Its zone cache is shared between validators.
+type validator struct {
+	next    dns.Handler
+	anchors map[string][]*dns.DS
+	zones   *zoneCache
+	now     time.Time
+}
+
+// validateResponse validates the answer RRsets of the response and,
+// for negative responses, the proof of denial of existence.
+func (v *validator) validateResponse(question dns.Question, response *dns.Msg) (
+	result security, err error,
+) {
+	switch response.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+	default:
+		return insecure, nil
+	}
+
+	result = secure
+	answerRRsets := groupRRsets(response.Answer)
+	for _, rrset := range answerRRsets {
+		rrsetSecurity, err := v.validateRRset(rrset, response.Answer)
+		switch rrsetSecurity {
+		case bogus:
+			header := rrset[0].Header()
+			return bogus, fmt.Errorf("answer %s %s: %w",
+				header.Name, dns.TypeToString[header.Rrtype], err)
+		case insecure:
+			result = insecure
+		case secure:
+		}
+	}
+
+	negative := response.Rcode == dns.RcodeNameError || len(answerRRsets) == 0
+	if !negative {
+		return result, nil
+	}
+
+	// The name denied is the last target of the CNAME chain, if any.
+	name := dns.CanonicalName(question.Name)
+	for _, cname := range extractType[*dns.CNAME](response.Answer) {
+		if dns.CanonicalName(cname.Hdr.Name) == name {
+			name = dns.CanonicalName(cname.Target)
+		}
+	}
+
+	zoneName := name
+	if question.Qtype == dns.TypeDS {
+		zoneName = parentName(name)
+	}
+	state, err := v.zoneOf(zoneName)
+	if state.security != secure {
+		return state.security, err
+	}
+
+	err = v.validateDenial(name, question.Qtype, response.Rcode, response.Ns, state)
+	if err != nil {
+		return bogus, err
+	}
+
+	return result, nil
+}
+
+// validateRRset validates the RRset using the signatures found in the
+// section records given.
+func (v *validator) validateRRset(rrset, sectionRRs []dns.RR) (
+	result security, err error,
+) {
+	header := rrset[0].Header()
+	zoneName := dns.CanonicalName(header.Name)
+	if header.Rrtype == dns.TypeDS {
+		// DS records are signed by the parent zone
+		zoneName = parentName(zoneName)
+	}
+
+	state, err := v.zoneOf(zoneName)
+	if state.security != secure {
+		return state.security, err
+	}
+
+	signatures := signaturesFor(sectionRRs, header.Name, header.Rrtype)
+	err = v.verify(rrset, signatures, state)
+	if err != nil {
+		return bogus, err
+	}
+	return secure, nil
+}
+
+// verify verifies the RRset has at least one valid signature from
+// one of the keys of the zone.
+func (v *validator) verify(rrset []dns.RR, signatures []*dns.RRSIG,
+	state zoneState,
+) (err error) {
+	if len(signatures) == 0 {
+		return fmt.Errorf("%w", errMissingSignature)
+	}
+
+	err = errNoValidSignature
+	for _, signature := range signatures {
+		if dns.CanonicalName(signature.SignerName) != state.zone {
+			continue
+		}
+
+		if !signature.ValidityPeriod(v.now) {
+			err = errSignatureExpired
+			continue
+		}
+
+		for _, key := range state.keys {
+			if key.KeyTag() != signature.KeyTag || key.Algorithm != signature.Algorithm {
+				continue
+			}
+			verifyErr := signature.Verify(key, rrset)
+			if verifyErr == nil {
+				return nil
+			}
+			err = verifyErr
+		}
+	}
+	return fmt.Errorf("signer %s: %w", state.zone, err)
+}
+
+// zoneOf returns the state of the zone containing the name, walking
+// the chain of trust from the closest trust anchor down to the name.
+func (v *validator) zoneOf(name string) (state zoneState, err error) {
+	name = dns.CanonicalName(name)
+	state, ok := v.zones.get(name, v.now)
+	if ok {
+		return state, nil
+	}
+
+	if anchors, ok := v.anchors[name]; ok {
+		const anchorTTL = ^uint32(0)
+		state, err = v.zoneFromDS(name, anchors, anchorTTL)
+	} else {
+		parent := parentName(name)
+		if parent == "" { // root zone without trust anchor
+			return zoneState{zone: name, security: insecure}, nil
+		}
+
+		state, err = v.zoneOf(parent)
+		if err == nil && state.security == secure {
+			state, err = v.delegation(name, state)
+		}
+	}
+
+	if err != nil {
+		return zoneState{security: bogus}, fmt.Errorf("zone of %s: %w", name, err)
+	}
+
+	v.zones.set(name, state)
+	return state, nil
+}
+
+// zoneFromDS fetches the DNSKEY RRset of the zone and validates it
+// using the DS records given.
+func (v *validator) zoneFromDS(zone string, dsRecords []*dns.DS, dsTTL uint32) (
+	state zoneState, err error,
+) {
+	response, err := v.query(zone, dns.TypeDNSKEY)
+	if err != nil {
+		return zoneState{}, err
+	}
+
+	var keys, trustedKeys []*dns.DNSKEY
+	var keysRRset []dns.RR
+	for _, key := range extractType[*dns.DNSKEY](response.Answer) {
+		if dns.CanonicalName(key.Hdr.Name) != zone {
+			continue
+		}
+		keysRRset = append(keysRRset, key)
+		if key.Flags&dns.ZONE == 0 {
+			continue
+		}
+		keys = append(keys, key)
+		if matchesDS(key, dsRecords) {
+			trustedKeys = append(trustedKeys, key)
+		}
+	}
+
+	if len(trustedKeys) == 0 {
+		return zoneState{}, fmt.Errorf("%w: for zone %s", errNoTrustedKey, zone)
+	}
+
+	signatures := signaturesFor(response.Answer, zone, dns.TypeDNSKEY)
+	err = v.verify(keysRRset, signatures, zoneState{zone: zone, keys: trustedKeys})
+	if err != nil {
+		return zoneState{}, fmt.Errorf("verifying DNSKEY records of %s: %w", zone, err)
+	}
+
+	ttl := min(dsTTL, minTTL(keysRRset))
+	return zoneState{
+		zone:     zone,
+		keys:     keys,
+		security: secure,
+		expiry:   v.now.Add(time.Duration(ttl) * time.Second),
+	}, nil
+}
+
+func matchesDS(key *dns.DNSKEY, dsRecords []*dns.DS) bool {
+	keyTag := key.KeyTag()
+	for _, ds := range dsRecords {
+		if ds.KeyTag != keyTag || ds.Algorithm != key.Algorithm {
+			continue
+		}
+		keyDS := key.ToDS(ds.DigestType)
+		if keyDS != nil && strings.EqualFold(keyDS.Digest, ds.Digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegation determines the zone state of the name given the state of
+// its parent name, which must be secure. The name is either a securely
+// delegated zone, an insecurely delegated zone or a name of the parent
+// zone.
+func (v *validator) delegation(name string, parent zoneState) (
+	state zoneState, err error,
+) {
+	response, err := v.query(name, dns.TypeDS)
+	if err != nil {
+		return zoneState{}, err
+	}
+
+	var dsRRset []dns.RR
+	var dsRecords []*dns.DS
+	for _, ds := range extractType[*dns.DS](response.Answer) {
+		if dns.CanonicalName(ds.Hdr.Name) == name {
+			dsRRset = append(dsRRset, ds)
+			dsRecords = append(dsRecords, ds)
+		}
+	}
+
+	if len(dsRecords) > 0 {
+		signatures := signaturesFor(response.Answer, name, dns.TypeDS)
+		err = v.verify(dsRRset, signatures, parent)
+		if err != nil {
+			return zoneState{}, fmt.Errorf("verifying DS records of %s: %w", name, err)
+		}
+		return v.zoneFromDS(name, dsRecords, minTTL(dsRRset))
+	}
+
+	// A CNAME cannot coexist with a zone cut, so the name belongs to the parent zone.
+	for _, cname := range extractType[*dns.CNAME](response.Answer) {
+		if dns.CanonicalName(cname.Hdr.Name) != name {
+			continue
+		}
+		signatures := signaturesFor(response.Answer, cname.Hdr.Name, dns.TypeCNAME)
+		err = v.verify([]dns.RR{cname}, signatures, parent)
+		if err != nil {
+			return zoneState{}, fmt.Errorf("verifying CNAME record of %s: %w", name, err)
+		}
+		return parent, nil
+	}
+
+	// The parent zone must prove the absence of DS records.
+	err = v.verifyAuthority(response.Ns, parent)
+	if err != nil {
+		return zoneState{}, fmt.Errorf("DS denial for %s: %w", name, err)
+	}
+
+	ttl := minTTL(response.Ns)
+	expiry := v.now.Add(time.Duration(ttl) * time.Second)
+	switch dsDenial(name, response.Ns) {
+	case denialNoZoneCut:
+		state = parent
+		if expiry.Before(state.expiry) {
+			state.expiry = expiry
+		}
+		return state, nil
+	case denialInsecureDelegation:
+		return zoneState{
+			zone:     name,
+			security: insecure,
+			expiry:   expiry,
+		}, nil
+	default:
+		return zoneState{}, fmt.Errorf("%w", errDSDenialNotProven)
+	}
+}
+
+// verifyAuthority verifies the SOA, NSEC and NSEC3 RRsets of the
+// authority section are signed by the zone.
+func (v *validator) verifyAuthority(authority []dns.RR, state zoneState) (err error) {
+	verified := 0
+	for _, rrset := range groupRRsets(authority) {
+		header := rrset[0].Header()
+		switch header.Rrtype {
+		case dns.TypeSOA, dns.TypeNSEC, dns.TypeNSEC3:
+		default:
+			continue
+		}
+
+		signatures := signaturesFor(authority, header.Name, header.Rrtype)
+		err = v.verify(rrset, signatures, state)
+		if err != nil {
+			return fmt.Errorf("verifying %s %s: %w", header.Name,
+				dns.TypeToString[header.Rrtype], err)
+		}
+		verified++
+	}
+
+	if verified == 0 {
+		return fmt.Errorf("%w", errMissingDenialRecords)
+	}
+	return nil
+}
+
+// validateDenial validates the proof of non existence of the name, for a
+// name error, or of the query type, for a no data response.
+func (v *validator) validateDenial(name string, qtype uint16, rcode int,
+	authority []dns.RR, state zoneState,
+) (err error) {
+	err = v.verifyAuthority(authority, state)
+	if err != nil {
+		return err
+	}
+
+	nsecs := extractType[*dns.NSEC](authority)
+	nsec3s := extractType[*dns.NSEC3](authority)
+
+	if rcode == dns.RcodeNameError {
+		if nsecCovers(nsecs, name) || nsec3ProvesNameError(nsec3s, name) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", errNameErrorNotProven, name)
+	}
+
+	if nsecProvesNoData(nsecs, name, qtype) || nsec3ProvesNoData(nsec3s, name, qtype) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s %s", errNoDataNotProven, name, dns.TypeToString[qtype])
+}
+
+// query sends a query for the name and type through the next handler,
+// asking for DNSSEC records.
+func (v *validator) query(name string, qtype uint16) (response *dns.Msg, err error) {
+	request := new(dns.Msg).SetQuestion(name, qtype)
+	const udpSize = 1232
+	request.SetEdns0(udpSize, true)
+	request.CheckingDisabled = true
+
+	writer := capture.New(nil)
+	v.next.ServeDNS(writer, request)
+	response = writer.Response
+	switch {
+	case response == nil:
+		return nil, fmt.Errorf("%w: for %s %s", errNoResponse,
+			name, dns.TypeToString[qtype])
+	case response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError:
+		return nil, fmt.Errorf("%w: %s %s: %s", errQueryFailed, name,
+			dns.TypeToString[qtype], dns.RcodeToString[response.Rcode])
+	}
+	return response, nil
+}
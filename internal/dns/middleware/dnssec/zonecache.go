@@ -0,0 +1,57 @@
+package dnssec
+
+import (
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// zoneState is the result of walking the chain of trust
+// down to a domain name.
+type zoneState struct {
+	// zone is the name of the zone containing the domain name.
+	zone     string
+	keys     []*dns.DNSKEY
+	security security
+	expiry   time.Time
+}
+
+// zoneCache caches the zone state of domain names to avoid
+// walking the chain of trust for every query.
+type zoneCache struct {
+	states map[string]zoneState
+	mutex  sync.Mutex
+}
+
+func newZoneCache() *zoneCache {
+	return &zoneCache{
+		states: make(map[string]zoneState),
+	}
+}
+
+func (c *zoneCache) get(name string, now time.Time) (state zoneState, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	state, ok = c.states[name]
+	if !ok {
+		return zoneState{}, false
+	}
+	if now.After(state.expiry) {
+		delete(c.states, name)
+		return zoneState{}, false
+	}
+	return state, true
+}
+
+func (c *zoneCache) set(name string, state zoneState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	const maxEntries = 10000
+	if len(c.states) >= maxEntries {
+		// Clearing the whole cache keeps it bounded in a simple
+		// way, and entries are cheap to rebuild from the DNS cache.
+		c.states = make(map[string]zoneState)
+	}
+	c.states[name] = state
+}
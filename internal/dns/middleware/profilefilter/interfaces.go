@@ -0,0 +1,8 @@
+package profilefilter
+
+import "github.com/miekg/dns"
+
+type Filter interface {
+	FilterRequest(request *dns.Msg) (blocked bool)
+	FilterResponse(response *dns.Msg) (blocked bool)
+}
@@ -0,0 +1,109 @@
+package profilefilter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+	"github.com/qdm12/gluetun/internal/dns/middleware/clientip"
+)
+
+// Middleware refuses requests and responses blocked by the filter of
+// the profile matching the client IP address, or by the default filter
+// if no profile matches.
+type Middleware struct {
+	defaultFilter Filter
+	profiles      []Profile
+}
+
+type Settings struct {
+	// Default is the filter to use for clients not matching any
+	// profile, and must be set.
+	Default Filter
+	// Profiles are the filtering profiles to select from, the first
+	// profile matching the client IP address being used.
+	Profiles []Profile
+}
+
+// Profile is a filter used for clients in its subnets.
+type Profile struct {
+	Name    string
+	Subnets []netip.Prefix
+	Filter  Filter
+}
+
+var (
+	ErrDefaultFilterNotSet = errors.New("default filter is not set")
+	ErrProfileFilterNotSet = errors.New("profile filter is not set")
+)
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	if settings.Default == nil {
+		return nil, fmt.Errorf("%w", ErrDefaultFilterNotSet)
+	}
+	for _, profile := range settings.Profiles {
+		if profile.Filter == nil {
+			return nil, fmt.Errorf("%w: for profile %s", ErrProfileFilterNotSet, profile.Name)
+		}
+	}
+
+	return &Middleware{
+		defaultFilter: settings.Default,
+		profiles:      settings.Profiles,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "filter"
+}
+
+func (m *Middleware) Stop() (err error) {
+	return nil
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		filter := m.filterFor(w.RemoteAddr())
+		if filter.FilterRequest(request) {
+			_ = w.WriteMsg(new(dns.Msg).SetRcode(request, dns.RcodeRefused))
+			return
+		}
+
+		writer := capture.New(w)
+		// Note the next handler might retrieve a response from the cache.
+		next.ServeDNS(writer, request)
+		response := writer.Response
+		if response == nil {
+			return
+		}
+
+		if filter.FilterResponse(response) {
+			response = new(dns.Msg).SetRcode(request, dns.RcodeRefused)
+		}
+		_ = w.WriteMsg(response)
+	})
+}
+
+// filterFor returns the filter of the first profile whose subnets
+// contain the client address, or the default filter.
+func (m *Middleware) filterFor(clientAddress net.Addr) (filter Filter) {
+	if len(m.profiles) == 0 {
+		return m.defaultFilter
+	}
+
+	clientIP := clientip.FromAddr(clientAddress)
+	if !clientIP.IsValid() {
+		return m.defaultFilter
+	}
+	for _, profile := range m.profiles {
+		for _, subnet := range profile.Subnets {
+			if subnet.Contains(clientIP) {
+				return profile.Filter
+			}
+		}
+	}
+	return m.defaultFilter
+}
@@ -0,0 +1,93 @@
+package profilefilter
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	dns.ResponseWriter
+	remoteAddr net.Addr
+	response   *dns.Msg
+}
+
+func (w *testWriter) RemoteAddr() net.Addr { return w.remoteAddr }
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+// blockFilter blocks requests for the name set.
+type blockFilter struct {
+	name string
+}
+
+func (f blockFilter) FilterRequest(request *dns.Msg) bool {
+	return request.Question[0].Name == f.name
+}
+
+func (f blockFilter) FilterResponse(*dns.Msg) bool { return false }
+
+func Test_Middleware_Wrap(t *testing.T) {
+	t.Parallel()
+
+	middleware, err := New(Settings{
+		Default: blockFilter{name: "malware.com."},
+		Profiles: []Profile{{
+			Name:    "kids",
+			Subnets: []netip.Prefix{netip.MustParsePrefix("192.168.2.0/24")},
+			Filter:  blockFilter{name: "ads.com."},
+		}},
+	})
+	require.NoError(t, err)
+
+	next := dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		_ = w.WriteMsg(new(dns.Msg).SetReply(request))
+	})
+	handler := middleware.Wrap(next)
+
+	testCases := map[string]struct {
+		clientIP net.IP
+		name     string
+		rcode    int
+	}{
+		"default_blocked": {
+			clientIP: net.IPv4(192, 168, 1, 2),
+			name:     "malware.com.",
+			rcode:    dns.RcodeRefused,
+		},
+		"default_allowed": {
+			clientIP: net.IPv4(192, 168, 1, 2),
+			name:     "ads.com.",
+			rcode:    dns.RcodeSuccess,
+		},
+		"profile_blocked": {
+			clientIP: net.IPv4(192, 168, 2, 2),
+			name:     "ads.com.",
+			rcode:    dns.RcodeRefused,
+		},
+		"profile_allowed": {
+			clientIP: net.IPv4(192, 168, 2, 2),
+			name:     "malware.com.",
+			rcode:    dns.RcodeSuccess,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			request := new(dns.Msg).SetQuestion(testCase.name, dns.TypeA)
+			writer := &testWriter{remoteAddr: &net.UDPAddr{IP: testCase.clientIP, Port: 1234}}
+			handler.ServeDNS(writer, request)
+			require.NotNil(t, writer.response)
+			assert.Equal(t, testCase.rcode, writer.response.Rcode)
+		})
+	}
+}
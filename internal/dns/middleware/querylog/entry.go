@@ -0,0 +1,66 @@
+package querylog
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/clientip"
+)
+
+// Entry is a DNS query log entry.
+type Entry struct {
+	Time     time.Time  `json:"time"`
+	ClientIP netip.Addr `json:"client_ip"`
+	Name     string     `json:"name"`
+	Type     string     `json:"type"`
+	Rcode    string     `json:"rcode"`
+	// Blocked is true if the query was blocked by the filter.
+	Blocked bool `json:"blocked"`
+	// Cached is true if the response was served from the cache.
+	Cached bool `json:"cached"`
+	// Bypassed is true if the query was sent to the bypass resolver.
+	Bypassed bool `json:"bypassed"`
+	// UpstreamLatency is the time taken by the upstream resolver,
+	// or by the bypass resolver if the query was bypassed.
+	// It is zero if no resolver was queried.
+	UpstreamLatency time.Duration `json:"upstream_latency_ns"`
+}
+
+func makeEntry(start time.Time, clientAddr net.Addr,
+	request, response *dns.Msg, record *record,
+) (entry Entry) {
+	entry = Entry{
+		Time:     start,
+		ClientIP: clientip.FromAddr(clientAddr),
+	}
+
+	if len(request.Question) > 0 {
+		question := request.Question[0]
+		entry.Name = question.Name
+		entry.Type = dns.TypeToString[question.Qtype]
+	}
+
+	rcode := dns.RcodeServerFailure
+	if response != nil {
+		rcode = response.Rcode
+	}
+	entry.Rcode = dns.RcodeToString[rcode]
+
+	entry.Blocked = rcode == dns.RcodeRefused &&
+		(!record.filterPassed || record.filterPassedRcode != dns.RcodeRefused)
+
+	switch {
+	case entry.Blocked && !record.filterPassed:
+	case record.upstreamReached:
+		entry.UpstreamLatency = record.upstreamLatency
+	case record.bypassReached:
+		entry.Bypassed = true
+		entry.UpstreamLatency = record.bypassLatency
+	case record.filterPassed:
+		entry.Cached = true
+	}
+
+	return entry
+}
@@ -0,0 +1,5 @@
+package querylog
+
+type Warner interface {
+	Warn(message string)
+}
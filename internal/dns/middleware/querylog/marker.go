@@ -0,0 +1,100 @@
+package querylog
+
+import (
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+// Stage is a stage of the middleware chain a marker records.
+type Stage uint8
+
+const (
+	// StageFiltered is the stage right after the filter middleware,
+	// and is reached by queries not blocked by the filter.
+	StageFiltered Stage = iota
+	// StageBypass is the stage right before the bypass middleware.
+	StageBypass
+	// StageUpstream is the stage right before the upstream resolver.
+	StageUpstream
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageFiltered:
+		return "filtered"
+	case StageBypass:
+		return "bypass"
+	case StageUpstream:
+		return "upstream"
+	default:
+		panic("unknown stage")
+	}
+}
+
+// Marker is a middleware recording the query reached a stage
+// of the middleware chain.
+type Marker struct {
+	middleware *Middleware
+	stage      Stage
+}
+
+// Marker returns a marker middleware for the given stage.
+func (m *Middleware) Marker(stage Stage) *Marker {
+	return &Marker{
+		middleware: m,
+		stage:      stage,
+	}
+}
+
+func (m *Marker) String() string {
+	return "query log " + m.stage.String() + " marker"
+}
+
+func (m *Marker) Stop() (err error) {
+	return nil
+}
+
+func (m *Marker) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		record := findRecord(w)
+		if record == nil {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		switch m.stage {
+		case StageFiltered:
+			record.mutex.Lock()
+			record.filterPassed = true
+			record.mutex.Unlock()
+			writer := capture.New(w)
+			next.ServeDNS(writer, request)
+			if writer.Response != nil {
+				record.mutex.Lock()
+				record.filterPassedRcode = writer.Response.Rcode
+				record.mutex.Unlock()
+				_ = w.WriteMsg(writer.Response)
+			}
+		case StageBypass:
+			record.mutex.Lock()
+			record.bypassReached = true
+			record.mutex.Unlock()
+			start := m.middleware.timeNow()
+			next.ServeDNS(w, request)
+			latency := m.middleware.timeNow().Sub(start)
+			record.mutex.Lock()
+			record.bypassLatency = latency
+			record.mutex.Unlock()
+		case StageUpstream:
+			record.mutex.Lock()
+			record.upstreamReached = true
+			record.mutex.Unlock()
+			start := m.middleware.timeNow()
+			next.ServeDNS(w, request)
+			latency := m.middleware.timeNow().Sub(start)
+			record.mutex.Lock()
+			record.upstreamLatency = latency
+			record.mutex.Unlock()
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package querylog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+// Middleware records each DNS query in a store. It should be the outer
+// most middleware, and its markers should be placed in the middleware chain
+// to detect if the query was blocked, cached or bypassed.
+type Middleware struct {
+	store   *Store
+	timeNow func() time.Time
+}
+
+type Settings struct {
+	// Store is the store to record queries to, and must be set.
+	Store *Store
+}
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	if settings.Store == nil {
+		return nil, ErrStoreNotSet
+	}
+
+	return &Middleware{
+		store:   settings.Store,
+		timeNow: time.Now,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "query log"
+}
+
+func (m *Middleware) Stop() (err error) {
+	return nil
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		record := new(record)
+		start := m.timeNow()
+		writer := capture.New(&recordWriter{ResponseWriter: w, record: record})
+		next.ServeDNS(writer, request)
+		if writer.Response != nil {
+			_ = w.WriteMsg(writer.Response)
+		}
+		record.mutex.Lock()
+		entry := makeEntry(start, w.RemoteAddr(), request, writer.Response, record)
+		record.mutex.Unlock()
+		m.store.Add(entry)
+	})
+}
+
+// record is filled in by the markers as the request goes through
+// the middleware chain. It is protected by a mutex since a middleware
+// such as the cache middleware can keep on resolving the request in
+// the background after the response is written.
+type record struct {
+	mutex             sync.Mutex
+	filterPassed      bool
+	filterPassedRcode int
+	bypassReached     bool
+	bypassLatency     time.Duration
+	upstreamReached   bool
+	upstreamLatency   time.Duration
+}
+
+// recordWriter carries the record of the request through the middleware
+// chain, so the markers can find it from their response writer. This is
+// needed since middlewares may copy the request, which is thus not
+// suitable to identify it.
+type recordWriter struct {
+	dns.ResponseWriter
+	record *record
+}
+
+// findRecord returns the record carried by the response writer, unwrapping
+// the response writers wrapping it. It returns nil if no record is found,
+// for example for requests made by a middleware itself.
+func findRecord(w dns.ResponseWriter) (r *record) {
+	for w != nil {
+		switch typedWriter := w.(type) {
+		case *recordWriter:
+			return typedWriter.record
+		case interface{ Unwrap() dns.ResponseWriter }:
+			w = typedWriter.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
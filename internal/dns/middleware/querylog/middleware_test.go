@@ -0,0 +1,169 @@
+package querylog
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 5353}
+}
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func answerHandler(rcode int) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, rcode))
+	})
+}
+
+// refuseMiddleware simulates the filter middleware blocking requests
+// or responses.
+type refuseMiddleware struct {
+	blockRequest bool
+}
+
+func (m refuseMiddleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		if m.blockRequest {
+			_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeRefused))
+			return
+		}
+		next.ServeDNS(capture.New(w), r)
+		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeRefused))
+	})
+}
+
+// cacheMiddleware simulates a cache hit.
+type cacheMiddleware struct{}
+
+func (cacheMiddleware) Wrap(_ dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		_ = w.WriteMsg(new(dns.Msg).SetRcode(r, dns.RcodeSuccess))
+	})
+}
+
+// copyMiddleware simulates a cache miss of a cache middleware resolving
+// a copy of the request with its own response writer.
+type copyMiddleware struct{}
+
+func (copyMiddleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		writer := capture.New(w)
+		next.ServeDNS(writer, r.Copy())
+		_ = w.WriteMsg(writer.Response)
+	})
+}
+
+type wrapper interface {
+	Wrap(next dns.Handler) dns.Handler
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		// middlewares from the innermost to the outermost,
+		// the query log middleware is added last.
+		middlewares func(m *Middleware) []wrapper
+		rcode       int
+		entry       Entry
+	}{
+		"upstream": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageUpstream), m.Marker(StageBypass), m.Marker(StageFiltered)}
+			},
+			rcode: dns.RcodeSuccess,
+			entry: Entry{Rcode: "NOERROR", UpstreamLatency: time.Second},
+		},
+		"bypassed": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageBypass), m.Marker(StageFiltered)}
+			},
+			rcode: dns.RcodeNameError,
+			entry: Entry{Rcode: "NXDOMAIN", Bypassed: true, UpstreamLatency: time.Second},
+		},
+		"cached": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageUpstream), cacheMiddleware{}, m.Marker(StageFiltered)}
+			},
+			rcode: dns.RcodeSuccess,
+			entry: Entry{Rcode: "NOERROR", Cached: true},
+		},
+		"copied request": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageUpstream), copyMiddleware{}, m.Marker(StageFiltered)}
+			},
+			rcode: dns.RcodeSuccess,
+			entry: Entry{Rcode: "NOERROR", UpstreamLatency: time.Second},
+		},
+		"blocked request": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageUpstream), m.Marker(StageFiltered),
+					refuseMiddleware{blockRequest: true}}
+			},
+			rcode: dns.RcodeSuccess,
+			entry: Entry{Rcode: "REFUSED", Blocked: true},
+		},
+		"blocked response": {
+			middlewares: func(m *Middleware) []wrapper {
+				return []wrapper{m.Marker(StageUpstream), m.Marker(StageFiltered),
+					refuseMiddleware{}}
+			},
+			rcode: dns.RcodeSuccess,
+			entry: Entry{Rcode: "REFUSED", Blocked: true, UpstreamLatency: time.Second},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			store := NewStore(StoreSettings{MaxEntries: 1, Logger: noopWarner{}})
+			middleware, err := New(Settings{Store: store})
+			require.NoError(t, err)
+			now := time.Unix(0, 0)
+			middleware.timeNow = func() time.Time {
+				now = now.Add(time.Second)
+				return now
+			}
+
+			handler := answerHandler(testCase.rcode)
+			for _, m := range testCase.middlewares(middleware) {
+				handler = m.Wrap(handler)
+			}
+			handler = middleware.Wrap(handler)
+
+			request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+			writer := &testWriter{}
+			handler.ServeDNS(writer, request)
+
+			entries := store.Entries()
+			require.Len(t, entries, 1)
+			entry := entries[0]
+			assert.Equal(t, time.Unix(1, 0), entry.Time)
+			entry.Time = time.Time{}
+			expected := testCase.entry
+			expected.ClientIP = netip.MustParseAddr("192.168.1.5")
+			expected.Name = "example.com."
+			expected.Type = "A"
+			assert.Equal(t, expected, entry)
+			assert.Equal(t, writer.response.Rcode, dns.StringToRcode[entry.Rcode])
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package querylog
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Totals contains counters of all the queries recorded
+// since the store was created.
+type Totals struct {
+	Queries  uint64 `json:"queries"`
+	Blocked  uint64 `json:"blocked"`
+	Cached   uint64 `json:"cached"`
+	Bypassed uint64 `json:"bypassed"`
+}
+
+func (t *Totals) add(entry Entry) {
+	t.Queries++
+	if entry.Blocked {
+		t.Blocked++
+	}
+	if entry.Cached {
+		t.Cached++
+	}
+	if entry.Bypassed {
+		t.Bypassed++
+	}
+}
+
+// Stats contains the statistics of the DNS queries.
+type Stats struct {
+	// Totals are counters since the store was created.
+	Totals Totals `json:"totals"`
+	// Entries is the number of entries in memory the
+	// fields below are aggregated from.
+	Entries int `json:"entries"`
+	// Clients are the top clients, sorted by number of
+	// blocked queries and then by number of queries.
+	Clients []ClientStats `json:"clients"`
+	// Domains are the most queried domains.
+	Domains []DomainCount `json:"domains"`
+	// BlockedDomains are the most blocked domains.
+	BlockedDomains []DomainCount `json:"blocked_domains"`
+}
+
+type ClientStats struct {
+	IP       netip.Addr `json:"ip"`
+	Queries  uint       `json:"queries"`
+	Blocked  uint       `json:"blocked"`
+	Cached   uint       `json:"cached"`
+	Bypassed uint       `json:"bypassed"`
+	// AverageUpstreamLatency is the average upstream latency
+	// of the queries which reached a resolver.
+	AverageUpstreamLatency time.Duration `json:"average_upstream_latency_ns"`
+	// TopBlockedDomains are the most blocked domains for this client.
+	TopBlockedDomains []DomainCount `json:"top_blocked_domains,omitempty"`
+
+	upstreamQueries uint
+	upstreamLatency time.Duration
+	blockedDomains  map[string]uint
+}
+
+type DomainCount struct {
+	Name  string `json:"name"`
+	Count uint   `json:"count"`
+}
+
+// Stats returns the totals and the top n clients and domains
+// aggregated from the entries in memory.
+func (s *Store) Stats(n int) (stats Stats) {
+	s.mutex.RLock()
+	stats.Totals = s.totals
+	entries := s.entriesNoLock()
+	s.mutex.RUnlock()
+
+	stats.Entries = len(entries)
+
+	clients := make(map[netip.Addr]*ClientStats)
+	domains := make(map[string]uint)
+	blockedDomains := make(map[string]uint)
+	for _, entry := range entries {
+		client, ok := clients[entry.ClientIP]
+		if !ok {
+			client = &ClientStats{
+				IP:             entry.ClientIP,
+				blockedDomains: make(map[string]uint),
+			}
+			clients[entry.ClientIP] = client
+		}
+
+		client.Queries++
+		domains[entry.Name]++
+		switch {
+		case entry.Blocked:
+			client.Blocked++
+			client.blockedDomains[entry.Name]++
+			blockedDomains[entry.Name]++
+		case entry.Cached:
+			client.Cached++
+		case entry.Bypassed:
+			client.Bypassed++
+		}
+
+		if entry.UpstreamLatency > 0 {
+			client.upstreamQueries++
+			client.upstreamLatency += entry.UpstreamLatency
+		}
+	}
+
+	stats.Clients = make([]ClientStats, 0, len(clients))
+	for _, client := range clients {
+		if client.upstreamQueries > 0 {
+			client.AverageUpstreamLatency = client.upstreamLatency /
+				time.Duration(client.upstreamQueries) //nolint:gosec
+		}
+		client.TopBlockedDomains = topDomains(client.blockedDomains, n)
+		stats.Clients = append(stats.Clients, *client)
+	}
+	slices.SortFunc(stats.Clients, func(a, b ClientStats) int {
+		switch {
+		case a.Blocked != b.Blocked:
+			return compareDescending(a.Blocked, b.Blocked)
+		case a.Queries != b.Queries:
+			return compareDescending(a.Queries, b.Queries)
+		default:
+			return a.IP.Compare(b.IP)
+		}
+	})
+	if len(stats.Clients) > n {
+		stats.Clients = stats.Clients[:n]
+	}
+
+	stats.Domains = topDomains(domains, n)
+	stats.BlockedDomains = topDomains(blockedDomains, n)
+
+	return stats
+}
+
+func topDomains(counts map[string]uint, n int) (top []DomainCount) {
+	top = make([]DomainCount, 0, len(counts))
+	for name, count := range counts {
+		top = append(top, DomainCount{Name: name, Count: count})
+	}
+	slices.SortFunc(top, func(a, b DomainCount) int {
+		if a.Count != b.Count {
+			return compareDescending(a.Count, b.Count)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+func compareDescending(a, b uint) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,195 @@
+package querylog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrStoreNotSet = errors.New("query log store is not set")
+
+// Store keeps the last DNS query log entries in memory, and optionally
+// appends every entry to a file in the JSON lines format.
+type Store struct {
+	// Configuration
+	maxEntries int
+	filepath   string
+
+	// State
+	entries []Entry // ring buffer, allocated on the first entry added
+	next    int     // index of the next entry to write in the ring buffer
+	full    bool
+	totals  Totals
+	// fileEntries is the channel of entries to write to the file,
+	// created on the first entry added if the file path is set.
+	fileEntries chan Entry
+	fileDone    <-chan struct{}
+	fileBusy    bool // true if entries are dropped because the writer is busy
+	closed      bool
+	mutex       sync.RWMutex
+
+	// File writer state, only accessed by the file writer goroutine.
+	file    *os.File
+	fileErr error // set if the file could not be opened
+
+	// External objects
+	logger Warner
+}
+
+type StoreSettings struct {
+	// MaxEntries is the maximum number of entries to keep in memory.
+	// It must be strictly positive.
+	MaxEntries uint
+	// Filepath is the path of the file to append entries to.
+	// It can be left empty to not write to a file.
+	Filepath string
+	// Logger is used to log file writing errors.
+	Logger Warner
+}
+
+// NewStore creates a new store. Its memory and file writer goroutine
+// are only set up on the first entry added, so it costs nothing if
+// the query log is disabled.
+func NewStore(settings StoreSettings) *Store {
+	return &Store{
+		maxEntries: int(settings.MaxEntries), //nolint:gosec
+		filepath:   settings.Filepath,
+		logger:     settings.Logger,
+	}
+}
+
+// Add adds an entry to the store, removing the oldest entry
+// if the store is full. If the file path is set, the entry is
+// handed to the file writer goroutine, and dropped if the writer
+// is too busy to keep up.
+func (s *Store) Add(entry Entry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.entries == nil {
+		s.entries = make([]Entry, s.maxEntries)
+	}
+	s.entries[s.next] = entry
+	s.next = (s.next + 1) % s.maxEntries
+	if s.next == 0 {
+		s.full = true
+	}
+
+	s.totals.add(entry)
+
+	if s.filepath == "" || s.closed {
+		return
+	}
+
+	if s.fileEntries == nil {
+		const fileEntriesBuffer = 1024
+		s.fileEntries = make(chan Entry, fileEntriesBuffer)
+		done := make(chan struct{})
+		s.fileDone = done
+		go s.writeEntries(s.fileEntries, done)
+	}
+
+	select {
+	case s.fileEntries <- entry:
+		s.fileBusy = false
+	default:
+		if !s.fileBusy {
+			s.fileBusy = true
+			s.logger.Warn("query log file writing is too slow, dropping entries")
+		}
+	}
+}
+
+func (s *Store) writeEntries(entries <-chan Entry, done chan<- struct{}) {
+	defer close(done)
+	for entry := range entries {
+		s.writeToFile(entry)
+	}
+}
+
+func (s *Store) writeToFile(entry Entry) {
+	if s.fileErr != nil {
+		return
+	}
+
+	if s.file == nil {
+		const dirPerms os.FileMode = 0o755
+		err := os.MkdirAll(filepath.Dir(s.filepath), dirPerms)
+		if err != nil {
+			s.fileErr = fmt.Errorf("creating query log file directory: %w", err)
+			s.logger.Warn(s.fileErr.Error())
+			return
+		}
+
+		const filePerms os.FileMode = 0o644
+		s.file, err = os.OpenFile(s.filepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerms)
+		if err != nil {
+			s.fileErr = fmt.Errorf("opening query log file: %w", err)
+			s.logger.Warn(s.fileErr.Error())
+			return
+		}
+	}
+
+	// Encoding a single JSON line in one write so entries
+	// are never interleaved in the file.
+	data, err := json.Marshal(entry)
+	if err != nil {
+		s.logger.Warn("encoding query log entry: " + err.Error())
+		return
+	}
+	data = append(data, '\n')
+	_, err = s.file.Write(data)
+	if err != nil {
+		s.logger.Warn("writing query log entry: " + err.Error())
+	}
+}
+
+// Entries returns a copy of the entries in memory,
+// from the oldest to the newest.
+func (s *Store) Entries() (entries []Entry) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.entriesNoLock()
+}
+
+func (s *Store) entriesNoLock() (entries []Entry) {
+	if !s.full {
+		entries = make([]Entry, s.next)
+		copy(entries, s.entries[:s.next])
+		return entries
+	}
+
+	entries = make([]Entry, 0, s.maxEntries)
+	entries = append(entries, s.entries[s.next:]...)
+	entries = append(entries, s.entries[:s.next]...)
+	return entries
+}
+
+// Close stops the file writer goroutine once it has written the
+// entries it was handed, and closes the query log file if it was opened.
+func (s *Store) Close() (err error) {
+	s.mutex.Lock()
+	s.closed = true
+	fileEntries, fileDone := s.fileEntries, s.fileDone
+	s.fileEntries, s.fileDone = nil, nil
+	s.mutex.Unlock()
+
+	if fileEntries == nil {
+		return nil
+	}
+	close(fileEntries)
+	<-fileDone
+
+	if s.file == nil {
+		return nil
+	}
+	err = s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("closing query log file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopWarner struct{}
+
+func (noopWarner) Warn(string) {}
+
+func Test_Store_Entries(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(StoreSettings{MaxEntries: 2, Logger: noopWarner{}})
+	assert.Empty(t, store.Entries())
+	assert.Nil(t, store.entries)
+
+	store.Add(Entry{Name: "a."})
+	assert.Equal(t, []Entry{{Name: "a."}}, store.Entries())
+
+	store.Add(Entry{Name: "b."})
+	store.Add(Entry{Name: "c."})
+	assert.Equal(t, []Entry{{Name: "b."}, {Name: "c."}}, store.Entries())
+
+	stats := store.Stats(1)
+	assert.Equal(t, Totals{Queries: 3}, stats.Totals)
+	assert.Equal(t, 2, stats.Entries)
+}
+
+func Test_Store_Stats(t *testing.T) {
+	t.Parallel()
+
+	clientA := netip.MustParseAddr("192.168.1.10")
+	clientB := netip.MustParseAddr("192.168.1.20")
+
+	store := NewStore(StoreSettings{MaxEntries: 10, Logger: noopWarner{}})
+	store.Add(Entry{ClientIP: clientA, Name: "example.com.", UpstreamLatency: 10 * time.Millisecond})
+	store.Add(Entry{ClientIP: clientA, Name: "example.com.", Cached: true})
+	store.Add(Entry{ClientIP: clientB, Name: "ads.com.", Blocked: true})
+	store.Add(Entry{ClientIP: clientB, Name: "ads.com.", Blocked: true})
+	store.Add(Entry{ClientIP: clientB, Name: "tracker.com.", Blocked: true})
+	store.Add(Entry{ClientIP: clientB, Name: "svc.cluster.local.", Bypassed: true,
+		UpstreamLatency: 2 * time.Millisecond})
+
+	stats := store.Stats(1)
+
+	expected := Stats{
+		Totals: Totals{
+			Queries:  6,
+			Blocked:  3,
+			Cached:   1,
+			Bypassed: 1,
+		},
+		Entries: 6,
+		Clients: []ClientStats{{
+			IP:                     clientB,
+			Queries:                4,
+			Blocked:                3,
+			Bypassed:               1,
+			AverageUpstreamLatency: 2 * time.Millisecond,
+			TopBlockedDomains:      []DomainCount{{Name: "ads.com.", Count: 2}},
+		}},
+		Domains:        []DomainCount{{Name: "ads.com.", Count: 2}},
+		BlockedDomains: []DomainCount{{Name: "ads.com.", Count: 2}},
+	}
+	// ignore unexported aggregation fields
+	for i := range stats.Clients {
+		stats.Clients[i].upstreamQueries = 0
+		stats.Clients[i].upstreamLatency = 0
+		stats.Clients[i].blockedDomains = nil
+	}
+	assert.Equal(t, expected, stats)
+}
+
+func Test_Store_file(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sub", "queries.jsonl")
+	store := NewStore(StoreSettings{MaxEntries: 1, Filepath: path, Logger: noopWarner{}})
+
+	entries := []Entry{
+		{Name: "a.", Type: "A", Rcode: "NOERROR", ClientIP: netip.MustParseAddr("10.0.0.1")},
+		{Name: "b.", Type: "AAAA", Rcode: "REFUSED", Blocked: true},
+	}
+	for _, entry := range entries {
+		store.Add(entry)
+	}
+	err := store.Close()
+	require.NoError(t, err)
+	// Entries added after closing are not written to the file.
+	store.Add(Entry{Name: "c."})
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = file.Close()
+	})
+
+	var written []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		require.NoError(t, err)
+		written = append(written, entry)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, entries, written)
+}
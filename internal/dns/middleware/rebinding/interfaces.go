@@ -0,0 +1,6 @@
+package rebinding
+
+type Logger interface {
+	Debug(message string)
+	Info(message string)
+}
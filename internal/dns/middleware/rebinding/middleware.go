@@ -0,0 +1,177 @@
+package rebinding
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/expansion"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+	"github.com/qdm12/gluetun/internal/dns/rules"
+)
+
+// Middleware protects against DNS rebinding attacks, where a public
+// domain resolves to a private IP address, by stripping or refusing
+// A and AAAA answers in the protected IP prefixes.
+type Middleware struct {
+	prefixes []netip.Prefix
+	allowed  *rules.Matcher
+	skip     func(name string) bool
+	action   string
+	logger   Logger
+}
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	settings.setDefaults()
+	err = settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("validating settings: %w", err)
+	}
+
+	allowed, _ := rules.NewMatcher(settings.AllowedHosts)
+
+	return &Middleware{
+		prefixes: settings.Prefixes,
+		allowed:  allowed,
+		skip:     settings.Skip,
+		action:   settings.Action,
+		logger:   settings.Logger,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "rebinding"
+}
+
+func (m *Middleware) Stop() (err error) {
+	return nil
+}
+
+// Prefixes returns the protected IP prefixes.
+func (m *Middleware) Prefixes() []netip.Prefix {
+	return m.prefixes
+}
+
+// IsExempt returns true if answers for the name given are
+// not checked, because the name is allowed or skipped.
+func (m *Middleware) IsExempt(name string) bool {
+	return m.allowed.Match(name) ||
+		(m.skip != nil && m.skip(name))
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		if len(request.Question) == 0 || m.IsExempt(request.Question[0].Name) {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		writer := capture.New(w)
+		next.ServeDNS(writer, request)
+		response := writer.Response
+		if response == nil {
+			dns.HandleFailed(w, request)
+			return
+		}
+
+		if m.exemptExpansion(response) {
+			_ = w.WriteMsg(response)
+			return
+		}
+
+		protected := m.protectedAnswers(response)
+		if len(protected) == 0 {
+			_ = w.WriteMsg(response)
+			return
+		}
+
+		name := request.Question[0].Name
+		switch m.action {
+		case ActionRefuse:
+			m.logger.Info(fmt.Sprintf("refusing DNS response for %s containing "+
+				"private address %s", name, protected[0]))
+			_ = w.WriteMsg(new(dns.Msg).SetRcode(request, dns.RcodeRefused))
+		default:
+			m.logger.Info(fmt.Sprintf("stripping %d private address(es) from DNS response for %s",
+				len(protected), name))
+			_ = w.WriteMsg(m.strip(response))
+		}
+	})
+}
+
+// exemptExpansion returns true if the response is for a short name
+// expanded with a search domain to an exempt name, by the split middleware
+// further down the middleware chain. Other answer names are not considered,
+// since a CNAME record from any name to an exempt name would otherwise
+// bypass the protection.
+func (m *Middleware) exemptExpansion(response *dns.Msg) bool {
+	target, ok := expansion.Target(response)
+	return ok && m.IsExempt(target)
+}
+
+func (m *Middleware) protectedAnswers(response *dns.Msg) (ips []netip.Addr) {
+	for _, answer := range response.Answer {
+		ip, ok := answerIP(answer)
+		if ok && containsIP(m.prefixes, ip) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// strip returns a copy of the response without the A and AAAA
+// answers in the protected prefixes, and without the signatures
+// of the record sets modified.
+func (m *Middleware) strip(response *dns.Msg) (stripped *dns.Msg) {
+	stripped = response.Copy()
+	type rrsetKey struct {
+		name   string
+		rrtype uint16
+	}
+	modified := make(map[rrsetKey]struct{})
+	answers := make([]dns.RR, 0, len(stripped.Answer))
+	for _, answer := range stripped.Answer {
+		ip, ok := answerIP(answer)
+		if ok && containsIP(m.prefixes, ip) {
+			header := answer.Header()
+			modified[rrsetKey{name: dns.CanonicalName(header.Name), rrtype: header.Rrtype}] = struct{}{}
+			continue
+		}
+		answers = append(answers, answer)
+	}
+
+	stripped.Answer = answers[:0]
+	for _, answer := range answers {
+		rrsig, ok := answer.(*dns.RRSIG)
+		if ok {
+			key := rrsetKey{name: dns.CanonicalName(rrsig.Hdr.Name), rrtype: rrsig.TypeCovered}
+			if _, isModified := modified[key]; isModified {
+				continue
+			}
+		}
+		stripped.Answer = append(stripped.Answer, answer)
+	}
+	stripped.AuthenticatedData = false
+	return stripped
+}
+
+func answerIP(rr dns.RR) (ip netip.Addr, ok bool) {
+	var netIP net.IP
+	switch record := rr.(type) {
+	case *dns.A:
+		netIP = record.A
+	case *dns.AAAA:
+		netIP = record.AAAA
+	default:
+		return netip.Addr{}, false
+	}
+	ip, ok = netip.AddrFromSlice(netIP)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if ip4 := netIP.To4(); ip4 != nil && rr.Header().Rrtype == dns.TypeA {
+		ip = netip.AddrFrom4([4]byte(ip4))
+	}
+	return ip, true
+}
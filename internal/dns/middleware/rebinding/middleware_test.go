@@ -0,0 +1,181 @@
+package rebinding
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string) {}
+func (noopLogger) Info(string)  {}
+
+func mustRR(t *testing.T, s string) dns.RR { //nolint:ireturn
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	require.NoError(t, err)
+	return rr
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		action  string
+		name    string
+		answers []string
+		rcode   int
+		// expected answers, nil meaning the same as answers.
+		expected []string
+	}{
+		"public_answer": {
+			name:    "public.com.",
+			answers: []string{"public.com. 60 IN A 1.2.3.4"},
+			rcode:   dns.RcodeSuccess,
+		},
+		"private_answers_stripped": {
+			name: "attacker.com.",
+			answers: []string{
+				"attacker.com. 60 IN CNAME rebind.attacker.com.",
+				"rebind.attacker.com. 60 IN A 192.168.1.1",
+				"rebind.attacker.com. 60 IN A 1.2.3.4",
+				"rebind.attacker.com. 60 IN RRSIG A 13 3 60 20300101000000 20200101000000 1 attacker.com. AAAA",
+			},
+			rcode: dns.RcodeSuccess,
+			expected: []string{
+				"attacker.com. 60 IN CNAME rebind.attacker.com.",
+				"rebind.attacker.com. 60 IN A 1.2.3.4",
+			},
+		},
+		"loopback_ipv6_stripped": {
+			name:     "attacker.com.",
+			answers:  []string{"attacker.com. 60 IN AAAA ::1"},
+			rcode:    dns.RcodeSuccess,
+			expected: []string{},
+		},
+		"ipv4_mapped_stripped": {
+			name:     "attacker.com.",
+			answers:  []string{"attacker.com. 60 IN AAAA ::ffff:10.0.0.1"},
+			rcode:    dns.RcodeSuccess,
+			expected: []string{},
+		},
+		"private_answer_refused": {
+			action:   ActionRefuse,
+			name:     "attacker.com.",
+			answers:  []string{"attacker.com. 60 IN A 127.0.0.1"},
+			rcode:    dns.RcodeRefused,
+			expected: []string{},
+		},
+		"allowed_host": {
+			name:    "nas.home.example.com.",
+			answers: []string{"nas.home.example.com. 60 IN A 192.168.1.10"},
+			rcode:   dns.RcodeSuccess,
+		},
+		"bypassed_host": {
+			name:    "service.cluster.local.",
+			answers: []string{"service.cluster.local. 60 IN A 10.96.0.1"},
+			rcode:   dns.RcodeSuccess,
+		},
+		"expanded_to_bypassed_host": {
+			name: "service.",
+			answers: []string{
+				"service. 60 IN CNAME service.cluster.local.",
+				"service.cluster.local. 60 IN A 10.96.0.1",
+			},
+			rcode: dns.RcodeSuccess,
+		},
+		"cname_to_allowed_host_stripped": {
+			name: "attacker.com.",
+			answers: []string{
+				"attacker.com. 60 IN CNAME nas.home.example.com.",
+				"nas.home.example.com. 60 IN A 192.168.1.10",
+			},
+			rcode: dns.RcodeSuccess,
+			expected: []string{
+				"attacker.com. 60 IN CNAME nas.home.example.com.",
+			},
+		},
+		"cname_to_bypassed_host_stripped": {
+			name: "attacker.com.",
+			answers: []string{
+				"attacker.com. 60 IN CNAME service.cluster.local.",
+				"service.cluster.local. 60 IN A 10.96.0.1",
+			},
+			rcode: dns.RcodeSuccess,
+			expected: []string{
+				"attacker.com. 60 IN CNAME service.cluster.local.",
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			middleware, err := New(Settings{
+				AllowedHosts: []string{"*.home.example.com"},
+				Skip: func(name string) bool {
+					return dns.IsSubDomain("cluster.local.", name)
+				},
+				Action: testCase.action,
+				Logger: noopLogger{},
+			})
+			require.NoError(t, err)
+
+			answers := make([]dns.RR, len(testCase.answers))
+			for i, answer := range testCase.answers {
+				answers[i] = mustRR(t, answer)
+			}
+			next := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+				response := new(dns.Msg).SetReply(r)
+				response.Answer = answers
+				_ = w.WriteMsg(response)
+			})
+
+			request := new(dns.Msg).SetQuestion(testCase.name, dns.TypeA)
+			writer := &testWriter{}
+			middleware.Wrap(next).ServeDNS(writer, request)
+
+			require.NotNil(t, writer.response)
+			assert.Equal(t, testCase.rcode, writer.response.Rcode)
+			expected := testCase.expected
+			if expected == nil {
+				expected = testCase.answers
+			}
+			actual := make([]string, len(writer.response.Answer))
+			for i, answer := range writer.response.Answer {
+				actual[i] = answer.String()
+			}
+			expectedStrings := make([]string, len(expected))
+			for i, answer := range expected {
+				expectedStrings[i] = mustRR(t, answer).String()
+			}
+			assert.Equal(t, expectedStrings, actual)
+		})
+	}
+}
+
+func Test_answerIP(t *testing.T) {
+	t.Parallel()
+
+	ip, ok := answerIP(&dns.A{Hdr: dns.RR_Header{Rrtype: dns.TypeA}, A: net.IPv4(10, 0, 0, 1)})
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", ip.String())
+
+	_, ok = answerIP(&dns.TXT{})
+	assert.False(t, ok)
+}
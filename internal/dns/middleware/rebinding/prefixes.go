@@ -0,0 +1,41 @@
+package rebinding
+
+import "net/netip"
+
+// PrivatePrefixes returns the loopback, private (RFC1918), link-local
+// and unique local (ULA) IP prefixes, including their IPv4-mapped
+// IPv6 equivalents.
+func PrivatePrefixes() (prefixes []netip.Prefix) {
+	cidrs := []string{
+		// IPv4
+		"127.0.0.0/8",
+		"10.0.0.0/8",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"169.254.0.0/16",
+		// IPv6
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		// IPv4-mapped IPv6
+		"::ffff:7f00:0/104",
+		"::ffff:a00:0/104",
+		"::ffff:ac10:0/108",
+		"::ffff:c0a8:0/112",
+		"::ffff:a9fe:0/112",
+	}
+	prefixes = make([]netip.Prefix, len(cidrs))
+	for i, cidr := range cidrs {
+		prefixes[i] = netip.MustParsePrefix(cidr)
+	}
+	return prefixes
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package rebinding
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/qdm12/gluetun/internal/dns/rules"
+	"github.com/qdm12/gosettings"
+	"github.com/qdm12/gosettings/validate"
+)
+
+const (
+	// ActionStrip removes the offending answers from the response.
+	ActionStrip = "strip"
+	// ActionRefuse replaces the response with a REFUSED response.
+	ActionRefuse = "refuse"
+)
+
+type Settings struct {
+	// Prefixes are the IP prefixes answers must not be in.
+	// It defaults to PrivatePrefixes() if left empty.
+	Prefixes []netip.Prefix
+	// AllowedHosts are host rules for which answers are not
+	// checked, in the format of the rules package.
+	AllowedHosts []string
+	// Skip returns true if the name given should not be checked,
+	// for example for DNS bypass domains. It can be left nil.
+	Skip func(name string) bool
+	// Action is the action to take for responses containing
+	// answers in the protected prefixes, and can be ActionStrip
+	// or ActionRefuse. It defaults to ActionStrip.
+	Action string
+	// Logger is used to log the actions taken and must be set.
+	Logger Logger
+}
+
+var ErrLoggerNotSet = errors.New("logger is not set")
+
+func (s *Settings) setDefaults() {
+	s.Prefixes = gosettings.DefaultSlice(s.Prefixes, PrivatePrefixes())
+	s.Action = gosettings.DefaultComparable(s.Action, ActionStrip)
+}
+
+func (s Settings) validate() (err error) {
+	err = validate.IsOneOf(s.Action, ActionStrip, ActionRefuse)
+	if err != nil {
+		return fmt.Errorf("action: %w", err)
+	}
+
+	_, err = rules.NewMatcher(s.AllowedHosts)
+	if err != nil {
+		return fmt.Errorf("allowed hosts: %w", err)
+	}
+
+	if s.Logger == nil {
+		return fmt.Errorf("%w", ErrLoggerNotSet)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package split
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+// searchCandidates returns the names obtained by appending each
+// search domain to the given domain, keeping only the ones matching
+// a bypass domain. The candidates are returned fully qualified and in
+// the search list order.
+func (m *Middleware) searchCandidates(domain string) (candidates []string) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if domain == "" {
+		return nil
+	}
+
+	for _, searchDomain := range m.searchDomains {
+		candidate := domain + "." + searchDomain
+		if m.shouldBypass(candidate) {
+			candidates = append(candidates, dns.Fqdn(candidate))
+		}
+	}
+	return candidates
+}
+
+// handleSearch resolves the request following the resolv.conf search
+// list semantics: names with at least ndots dots are first tried as
+// absolute names through the next handler, and then expanded with the
+// search domains. Names with fewer dots are expanded first, and only
+// tried as absolute names if no expanded name resolves.
+func (m *Middleware) handleSearch(w dns.ResponseWriter, request *dns.Msg,
+	candidates []string, next dns.Handler,
+) {
+	name := request.Question[0].Name
+	dots := strings.Count(strings.TrimSuffix(name, "."), ".")
+	if dots < m.ndots {
+		response := m.resolveCandidates(request, candidates)
+		if response != nil {
+			m.writeResponse(w, response)
+			return
+		}
+		next.ServeDNS(w, request)
+		return
+	}
+
+	writer := capture.New(w)
+	next.ServeDNS(writer, request)
+	if writer.Response != nil && writer.Response.Rcode != dns.RcodeNameError {
+		m.writeResponse(w, writer.Response)
+		return
+	}
+
+	response := m.resolveCandidates(request, candidates)
+	if response == nil {
+		response = writer.Response
+	}
+	if response == nil {
+		dns.HandleFailed(w, request)
+		return
+	}
+	m.writeResponse(w, response)
+}
+
+// resolveCandidates queries the bypass resolver for each of the
+// candidate names and returns a response for the original request
+// built from the first candidate response containing answers.
+// It returns nil if no candidate could be resolved.
+func (m *Middleware) resolveCandidates(request *dns.Msg, candidates []string) (
+	response *dns.Msg,
+) {
+	for _, candidate := range candidates {
+		candidateRequest := request.Copy()
+		candidateRequest.Question[0].Name = candidate
+		candidateResponse, err := m.exchange(candidateRequest)
+		if err != nil {
+			m.logger.Warn(fmt.Sprintf("bypass DNS query failed for %s: %v",
+				candidate, err))
+			continue
+		}
+
+		if candidateResponse.Rcode != dns.RcodeSuccess || len(candidateResponse.Answer) == 0 {
+			continue
+		}
+
+		return expandedResponse(request, candidateResponse, candidate)
+	}
+	return nil
+}
+
+// expandedResponse builds a response to the original request using
+// the response obtained for the expanded name. A CNAME record from the
+// original name to the expanded name is prepended to the answers, so
+// clients can follow the records as usual, and expansion.Target can
+// recognize the response.
+func expandedResponse(request, expandedResponse *dns.Msg, expandedName string) (
+	response *dns.Msg,
+) {
+	ttl := expandedResponse.Answer[0].Header().Ttl
+	for _, answer := range expandedResponse.Answer[1:] {
+		ttl = min(ttl, answer.Header().Ttl)
+	}
+
+	cname := &dns.CNAME{
+		Hdr: dns.RR_Header{
+			Name:   request.Question[0].Name,
+			Rrtype: dns.TypeCNAME,
+			Class:  dns.ClassINET,
+			Ttl:    ttl,
+		},
+		Target: expandedName,
+	}
+
+	response = new(dns.Msg)
+	response.SetReply(request)
+	response.RecursionAvailable = expandedResponse.RecursionAvailable
+	response.Answer = make([]dns.RR, 0, 1+len(expandedResponse.Answer))
+	response.Answer = append(response.Answer, cname)
+	response.Answer = append(response.Answer, expandedResponse.Answer...)
+	response.Ns = expandedResponse.Ns
+	return response
+}
+
+func (m *Middleware) writeResponse(w dns.ResponseWriter, response *dns.Msg) {
+	err := w.WriteMsg(response)
+	if err != nil {
+		m.logger.Error(fmt.Sprintf("failed to write DNS response: %v", err))
+	}
+}
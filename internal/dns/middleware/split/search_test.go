@@ -0,0 +1,123 @@
+package split
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestMiddleware_searchCandidates(t *testing.T) {
+	t.Parallel()
+
+	m := &Middleware{
+		bypassDomains: []string{"cluster.local"},
+		searchDomains: []string{"default.svc.cluster.local", "svc.cluster.local", "corp.example"},
+	}
+
+	testCases := []struct {
+		name     string
+		domain   string
+		expected []string
+	}{
+		{
+			name:     "service name",
+			domain:   "myservice.",
+			expected: []string{"myservice.default.svc.cluster.local.", "myservice.svc.cluster.local."},
+		},
+		{
+			name:   "service and namespace",
+			domain: "myservice.mynamespace.",
+			expected: []string{
+				"myservice.mynamespace.default.svc.cluster.local.",
+				"myservice.mynamespace.svc.cluster.local.",
+			},
+		},
+		{
+			name:     "root",
+			domain:   ".",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			candidates := m.searchCandidates(tc.domain)
+			if len(candidates) != len(tc.expected) {
+				t.Fatalf("searchCandidates(%q) = %v, want %v", tc.domain, candidates, tc.expected)
+			}
+			for i := range candidates {
+				if candidates[i] != tc.expected[i] {
+					t.Errorf("searchCandidates(%q)[%d] = %s, want %s",
+						tc.domain, i, candidates[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_expandedResponse(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("myservice.", dns.TypeA)
+	expanded := new(dns.Msg).SetQuestion("myservice.svc.cluster.local.", dns.TypeA)
+	expandedReply := new(dns.Msg).SetReply(expanded)
+	expandedReply.Answer = []dns.RR{
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "myservice.svc.cluster.local.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 30},
+			A:   netip.MustParseAddr("10.96.1.1").AsSlice(),
+		},
+	}
+
+	response := expandedResponse(request, expandedReply, "myservice.svc.cluster.local.")
+
+	if response.Id != request.Id {
+		t.Errorf("response id = %d, want %d", response.Id, request.Id)
+	}
+	if response.Question[0].Name != "myservice." {
+		t.Errorf("question name = %s, want myservice.", response.Question[0].Name)
+	}
+	if len(response.Answer) != 2 {
+		t.Fatalf("expected 2 answers, got %d", len(response.Answer))
+	}
+	cname, ok := response.Answer[0].(*dns.CNAME)
+	if !ok {
+		t.Fatalf("first answer is %T, want *dns.CNAME", response.Answer[0])
+	}
+	if cname.Hdr.Name != "myservice." || cname.Target != "myservice.svc.cluster.local." {
+		t.Errorf("unexpected CNAME record: %s", cname)
+	}
+	if cname.Hdr.Ttl != 30 {
+		t.Errorf("CNAME ttl = %d, want 30", cname.Hdr.Ttl)
+	}
+}
+
+func TestMiddleware_Wrap_absoluteFirst(t *testing.T) {
+	t.Parallel()
+
+	logger := &mockLogger{}
+	m := &Middleware{
+		bypassDomains:  []string{"cluster.local"},
+		searchDomains:  []string{"svc.cluster.local"},
+		ndots:          1,
+		bypassResolver: netip.MustParseAddr("10.0.0.53"),
+		bypassClient:   &dns.Client{},
+		logger:         logger,
+	}
+
+	// The name has at least ndots dots so it is tried as an absolute
+	// name first, and the next handler answering successfully means
+	// no search list expansion takes place.
+	next := &mockHandler{}
+	msg := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	w := &mockResponseWriter{}
+	m.Wrap(next).ServeDNS(w, msg)
+
+	if len(next.called) != 1 || next.called[0] != "example.com." {
+		t.Errorf("next handler called with %v, want [example.com.]", next.called)
+	}
+	if w.msg == nil || w.msg.Rcode != dns.RcodeSuccess {
+		t.Errorf("expected successful response to be written, got %v", w.msg)
+	}
+}
@@ -25,6 +25,8 @@ var (
 type Middleware struct {
 	bypassResolver netip.Addr
 	bypassDomains  []string
+	searchDomains  []string
+	ndots          int
 	bypassClient   *dns.Client
 	logger         interface {
 		Info(s string)
@@ -36,8 +38,16 @@ type Middleware struct {
 type Settings struct {
 	BypassResolver netip.Addr
 	BypassDomains  []string
-	Timeout        time.Duration // Optional timeout override
-	Logger         interface {
+	// SearchDomains are the search domains used to expand
+	// names before matching them against the bypass domains.
+	SearchDomains []string
+	// Ndots is the minimum number of dots a name must have to be
+	// tried as an absolute name before search list expansion.
+	// It defaults to 1 as in resolv.conf if left nil, and can be
+	// set to 0 to always try names as absolute names first.
+	Ndots   *int
+	Timeout time.Duration // Optional timeout override
+	Logger  interface {
 		Info(s string)
 		Error(s string)
 		Warn(s string)
@@ -59,9 +69,16 @@ func New(settings Settings) (*Middleware, error) {
 		timeout = settings.Timeout
 	}
 
+	ndots := 1
+	if settings.Ndots != nil {
+		ndots = *settings.Ndots
+	}
+
 	return &Middleware{
 		bypassResolver: settings.BypassResolver,
 		bypassDomains:  settings.BypassDomains,
+		searchDomains:  settings.SearchDomains,
+		ndots:          ndots,
 		bypassClient: &dns.Client{
 			Net:     "udp",
 			Timeout: timeout,
@@ -93,10 +110,21 @@ func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
 			return
 		}
 
-		next.ServeDNS(w, request)
+		candidates := m.searchCandidates(domain)
+		if len(candidates) == 0 {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		m.handleSearch(w, request, candidates, next)
 	})
 }
 
+// IsBypassed returns true if the domain is sent to the bypass resolver.
+func (m *Middleware) IsBypassed(domain string) bool {
+	return m.shouldBypass(domain)
+}
+
 func (m *Middleware) shouldBypass(domain string) bool {
 	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
 
@@ -115,9 +143,9 @@ func (m *Middleware) shouldBypass(domain string) bool {
 		} else {
 			// Check for exact match, subdomain, or embedded match
 			// This handles search-domain-appended queries generically
-			if domain == bypassDomain || 
-			   strings.HasSuffix(domain, "."+bypassDomain) || 
-			   strings.Contains(domain, bypassDomain+".") {
+			if domain == bypassDomain ||
+				strings.HasSuffix(domain, "."+bypassDomain) ||
+				strings.Contains(domain, bypassDomain+".") {
 				return true
 			}
 		}
@@ -127,9 +155,7 @@ func (m *Middleware) shouldBypass(domain string) bool {
 }
 
 func (m *Middleware) handleBypassDNS(w dns.ResponseWriter, r *dns.Msg) {
-	bypassAddr := net.JoinHostPort(m.bypassResolver.String(), dnsPort)
-
-	response, _, err := m.bypassClient.Exchange(r, bypassAddr)
+	response, err := m.exchange(r)
 	if err != nil {
 		m.logger.Error(fmt.Sprintf("bypass DNS query failed for %s: %v",
 			r.Question[0].Name, err))
@@ -142,3 +168,9 @@ func (m *Middleware) handleBypassDNS(w dns.ResponseWriter, r *dns.Msg) {
 		m.logger.Error(fmt.Sprintf("failed to write DNS response: %v", err))
 	}
 }
+
+func (m *Middleware) exchange(request *dns.Msg) (response *dns.Msg, err error) {
+	bypassAddr := net.JoinHostPort(m.bypassResolver.String(), dnsPort)
+	response, _, err = m.bypassClient.Exchange(request, bypassAddr)
+	return response, err
+}
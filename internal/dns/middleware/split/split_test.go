@@ -226,6 +226,33 @@ func TestNew(t *testing.T) {
 		}
 	})
 
+	t.Run("ndots", func(t *testing.T) {
+		t.Parallel()
+
+		settings := Settings{
+			BypassResolver: netip.MustParseAddr("10.0.0.53"),
+			BypassDomains:  []string{"cluster.local"},
+			Logger:         logger,
+		}
+		m, err := New(settings)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if m.ndots != 1 {
+			t.Errorf("expected default ndots 1, got %d", m.ndots)
+		}
+
+		ndots := 0
+		settings.Ndots = &ndots
+		m, err = New(settings)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if m.ndots != 0 {
+			t.Errorf("expected ndots 0, got %d", m.ndots)
+		}
+	})
+
 	t.Run("invalid resolver", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,5 @@
+package stalecache
+
+type Logger interface {
+	Debug(message string)
+}
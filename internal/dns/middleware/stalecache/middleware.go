@@ -0,0 +1,209 @@
+package stalecache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/gluetun/internal/dns/middleware/capture"
+)
+
+const (
+	// staleTTL is the TTL of stale records served, as recommended
+	// by RFC 8767 section 4.
+	staleTTL = 30
+	// clientResponseTimeout is the time to wait for the upstream
+	// resolution before serving a stale response, as recommended
+	// by RFC 8767 section 5.
+	clientResponseTimeout = 1800 * time.Millisecond
+)
+
+// Middleware is a cache middleware able to serve stale responses
+// when the upstream resolution fails (RFC 8767), and to prefetch
+// popular responses shortly before they expire.
+type Middleware struct {
+	store       *store
+	maxStaleAge time.Duration
+	prefetch    bool
+	logger      Logger
+	stopped     atomic.Bool
+	timeNow     func() time.Time
+	// responseTimeout is the time to wait for the upstream
+	// resolution before serving a stale response.
+	responseTimeout time.Duration
+}
+
+func New(settings Settings) (middleware *Middleware, err error) {
+	settings.setDefaults()
+	err = settings.validate()
+	if err != nil {
+		return nil, fmt.Errorf("validating settings: %w", err)
+	}
+
+	return &Middleware{
+		store:           newStore(settings.MaxEntries),
+		maxStaleAge:     settings.MaxStaleAge,
+		prefetch:        settings.Prefetch,
+		logger:          settings.Logger,
+		timeNow:         time.Now,
+		responseTimeout: clientResponseTimeout,
+	}, nil
+}
+
+func (m *Middleware) String() string {
+	return "cache"
+}
+
+// Stop stops any new background prefetch from starting.
+func (m *Middleware) Stop() (err error) {
+	m.stopped.Store(true)
+	return nil
+}
+
+func (m *Middleware) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	return dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		if len(request.Question) == 0 {
+			next.ServeDNS(w, request)
+			return
+		}
+
+		now := m.timeNow()
+		response, age, ttl := m.store.get(request, now, m.maxStaleAge)
+		switch {
+		case response == nil:
+			response = resolve(next, w, request)
+			m.store.add(request, response, m.timeNow())
+		case age < ttl:
+			decrementTTLs(response, age)
+			m.startPrefetch(next, request, now)
+		default:
+			response = m.resolveOrServeStale(next, request, response)
+		}
+
+		if response == nil {
+			dns.HandleFailed(w, request)
+			return
+		}
+		rcode := response.Rcode
+		response.SetReply(request)
+		response.Rcode = rcode
+		_ = w.WriteMsg(response)
+	})
+}
+
+// resolveOrServeStale refreshes the cache entry for the request in the
+// background, and returns the refreshed response if it resolves successfully
+// within the client response timeout. Otherwise, it returns the stale
+// response given, and the refresh continues in the background.
+// The stale response is returned right away if the entry is already
+// being refreshed or if the middleware is stopped.
+func (m *Middleware) resolveOrServeStale(next dns.Handler,
+	request, stale *dns.Msg,
+) (response *dns.Msg) {
+	var refreshRequest *dns.Msg
+	if !m.stopped.Load() {
+		refreshRequest = m.store.startRefresh(request)
+	}
+	if refreshRequest == nil {
+		makeStale(stale)
+		return stale
+	}
+
+	done := make(chan *dns.Msg, 1)
+	go func() {
+		response := resolve(next, nil, refreshRequest)
+		if _, ok := cacheTTL(response); ok {
+			m.store.add(refreshRequest, response, m.timeNow())
+		} else {
+			m.store.endRefresh(refreshRequest)
+		}
+		done <- response
+	}()
+
+	timer := time.NewTimer(m.responseTimeout)
+	defer timer.Stop()
+
+	select {
+	case response = <-done:
+		if response != nil && (response.Rcode == dns.RcodeSuccess ||
+			response.Rcode == dns.RcodeNameError) {
+			return response
+		}
+	case <-timer.C:
+	}
+
+	makeStale(stale)
+	return stale
+}
+
+func (m *Middleware) startPrefetch(next dns.Handler, request *dns.Msg, now time.Time) {
+	if !m.prefetch || m.stopped.Load() {
+		return
+	}
+
+	prefetchRequest := m.store.startPrefetch(request, now)
+	if prefetchRequest == nil {
+		return
+	}
+
+	go func() {
+		response := resolve(next, nil, prefetchRequest)
+		if _, ok := cacheTTL(response); !ok {
+			question := prefetchRequest.Question[0]
+			m.logger.Debug(fmt.Sprintf("prefetching %s %s failed",
+				question.Name, dns.TypeToString[question.Qtype]))
+			m.store.endRefresh(prefetchRequest)
+			return
+		}
+		m.store.add(prefetchRequest, response, m.timeNow())
+	}()
+}
+
+// resolve resolves the request using the next handler. The client
+// response writer is given so the middlewares further down the chain
+// can find it, and can be nil for requests not made by a client.
+func resolve(next dns.Handler, w dns.ResponseWriter,
+	request *dns.Msg,
+) (response *dns.Msg) {
+	writer := capture.New(w)
+	next.ServeDNS(writer, request)
+	return writer.Response
+}
+
+// decrementTTLs decrements the TTLs of the response records
+// by the age of the cached response.
+func decrementTTLs(response *dns.Msg, age time.Duration) {
+	elapsed := uint32(age / time.Second)
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range rrs {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+			header.Ttl -= min(header.Ttl, elapsed)
+		}
+	}
+}
+
+// makeStale sets the TTLs of the response records to the stale TTL,
+// and adds the stale answer extended DNS error (RFC 8914) if the
+// response has an EDNS0 OPT record.
+func makeStale(response *dns.Msg) {
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range rrs {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+			header.Ttl = staleTTL
+		}
+	}
+
+	opt := response.IsEdns0()
+	if opt != nil {
+		opt.Option = append(opt.Option, &dns.EDNS0_EDE{
+			InfoCode: dns.ExtendedErrorCodeStaleAnswer,
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package stalecache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *testWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string) {}
+
+// upstream is a test upstream handler answering with an A record
+// with the IP address set, or failing if the rcode is not success.
+type upstream struct {
+	mutex sync.Mutex
+	calls int
+	ip    string
+	rcode int
+	delay time.Duration
+	// called is signaled on each call if not nil.
+	called chan struct{}
+}
+
+func (u *upstream) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
+	u.mutex.Lock()
+	u.calls++
+	ip, rcode, delay, called := u.ip, u.rcode, u.delay, u.called
+	u.mutex.Unlock()
+
+	time.Sleep(delay)
+	response := new(dns.Msg).SetRcode(request, rcode)
+	if rcode == dns.RcodeSuccess {
+		rr, _ := dns.NewRR(request.Question[0].Name + " 100 IN A " + ip)
+		response.Answer = []dns.RR{rr}
+	}
+	_ = w.WriteMsg(response)
+	if called != nil {
+		called <- struct{}{}
+	}
+}
+
+func (u *upstream) set(ip string, rcode int, delay time.Duration) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.ip, u.rcode, u.delay = ip, rcode, delay
+}
+
+func (u *upstream) setCalled(called chan struct{}) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.called = called
+}
+
+func (u *upstream) getCalls() int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.calls
+}
+
+func query(handler dns.Handler) *dns.Msg {
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	writer := &testWriter{}
+	handler.ServeDNS(writer, request)
+	return writer.response
+}
+
+func answer(t *testing.T, response *dns.Msg) (ip string, ttl uint32) {
+	t.Helper()
+	require.NotNil(t, response)
+	require.Len(t, response.Answer, 1)
+	record, ok := response.Answer[0].(*dns.A)
+	require.True(t, ok)
+	return record.A.String(), record.Hdr.Ttl
+}
+
+func newTestMiddleware(t *testing.T, settings Settings, now *time.Time) *Middleware {
+	t.Helper()
+	settings.Logger = noopLogger{}
+	middleware, err := New(settings)
+	require.NoError(t, err)
+	var mutex sync.Mutex
+	middleware.timeNow = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return *now
+	}
+	return middleware
+}
+
+func Test_Middleware_cache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	middleware := newTestMiddleware(t, Settings{}, &now)
+	next := &upstream{ip: "1.1.1.1"}
+	handler := middleware.Wrap(next)
+
+	ip, ttl := answer(t, query(handler))
+	assert.Equal(t, "1.1.1.1", ip)
+	assert.Equal(t, uint32(100), ttl)
+
+	now = now.Add(40 * time.Second)
+	next.set("2.2.2.2", dns.RcodeSuccess, 0)
+	ip, ttl = answer(t, query(handler))
+	assert.Equal(t, "1.1.1.1", ip)
+	assert.Equal(t, uint32(60), ttl)
+	assert.Equal(t, 1, next.getCalls())
+
+	// Expired without serve-stale
+	now = now.Add(60 * time.Second)
+	ip, _ = answer(t, query(handler))
+	assert.Equal(t, "2.2.2.2", ip)
+	assert.Equal(t, 2, next.getCalls())
+}
+
+func Test_Middleware_clientWriter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	middleware := newTestMiddleware(t, Settings{}, &now)
+	client := &testWriter{}
+	var unwrapped dns.ResponseWriter
+	next := dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+		unwrapper, ok := w.(interface{ Unwrap() dns.ResponseWriter })
+		require.True(t, ok)
+		unwrapped = unwrapper.Unwrap()
+		(&upstream{ip: "1.1.1.1"}).ServeDNS(w, request)
+	})
+
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	middleware.Wrap(next).ServeDNS(client, request)
+
+	assert.Same(t, client, unwrapped)
+	ip, _ := answer(t, client.response)
+	assert.Equal(t, "1.1.1.1", ip)
+}
+
+func Test_Middleware_serveStale(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	middleware := newTestMiddleware(t, Settings{MaxStaleAge: time.Hour}, &now)
+	middleware.responseTimeout = 50 * time.Millisecond
+	next := &upstream{ip: "1.1.1.1"}
+	handler := middleware.Wrap(next)
+
+	_ = query(handler)
+
+	// Expired and upstream failing
+	now = now.Add(101 * time.Second)
+	next.set("", dns.RcodeServerFailure, 0)
+	response := query(handler)
+	ip, ttl := answer(t, response)
+	assert.Equal(t, "1.1.1.1", ip)
+	assert.Equal(t, uint32(staleTTL), ttl)
+	assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+
+	// Expired and upstream too slow, the stale response is served
+	// and the cache gets refreshed in the background.
+	called := make(chan struct{})
+	next.setCalled(called)
+	next.set("2.2.2.2", dns.RcodeSuccess, 200*time.Millisecond)
+	ip, _ = answer(t, query(handler))
+	assert.Equal(t, "1.1.1.1", ip)
+	<-called
+	next.setCalled(nil)
+	require.Eventually(t, func() bool {
+		ip, _ := answer(t, query(handler))
+		return ip == "2.2.2.2"
+	}, time.Second, 10*time.Millisecond)
+
+	// Expired beyond the maximum stale age
+	now = now.Add(100*time.Second + time.Hour)
+	next.set("", dns.RcodeServerFailure, 0)
+	response = query(handler)
+	assert.Equal(t, dns.RcodeServerFailure, response.Rcode)
+}
+
+func Test_Middleware_serveStale_singleRefresh(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	middleware := newTestMiddleware(t, Settings{MaxStaleAge: time.Hour}, &now)
+	middleware.responseTimeout = 10 * time.Millisecond
+	next := &upstream{ip: "1.1.1.1"}
+	handler := middleware.Wrap(next)
+
+	_ = query(handler)
+
+	// Expired and upstream too slow, only one refresh is
+	// running in the background for all the stale responses served.
+	now = now.Add(101 * time.Second)
+	next.set("2.2.2.2", dns.RcodeSuccess, 200*time.Millisecond)
+	for range 3 {
+		ip, _ := answer(t, query(handler))
+		assert.Equal(t, "1.1.1.1", ip)
+	}
+	require.Eventually(t, func() bool {
+		ip, _ := answer(t, query(handler))
+		return ip == "2.2.2.2"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, next.getCalls())
+
+	// Expired again and stopped, the stale response is served
+	// without refreshing it.
+	err := middleware.Stop()
+	require.NoError(t, err)
+	now = now.Add(101 * time.Second)
+	ip, ttl := answer(t, query(handler))
+	assert.Equal(t, "2.2.2.2", ip)
+	assert.Equal(t, uint32(staleTTL), ttl)
+	assert.Equal(t, 2, next.getCalls())
+}
+
+func Test_Middleware_prefetch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	middleware := newTestMiddleware(t, Settings{Prefetch: true}, &now)
+	next := &upstream{ip: "1.1.1.1", called: make(chan struct{}, 1)}
+	handler := middleware.Wrap(next)
+
+	_ = query(handler)
+	<-next.called
+
+	// Not popular enough
+	now = now.Add(95 * time.Second)
+	_ = query(handler)
+	_ = query(handler)
+	assert.Equal(t, 1, next.getCalls())
+
+	// Third hit in the last tenth of the TTL
+	next.set("2.2.2.2", dns.RcodeSuccess, 0)
+	ip, _ := answer(t, query(handler))
+	assert.Equal(t, "1.1.1.1", ip)
+	<-next.called
+	require.Eventually(t, func() bool {
+		ip, ttl := answer(t, query(handler))
+		return ip == "2.2.2.2" && ttl == 100
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, next.getCalls())
+}
+
+func Test_makeKey(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("Example.COM.", dns.TypeAAAA)
+	assert.Equal(t, "example.com.|28|1", makeKey(request))
+
+	request.SetEdns0(1232, true)
+	request.CheckingDisabled = true
+	assert.Equal(t, "example.com.|28|1|do|cd", makeKey(request))
+}
@@ -0,0 +1,48 @@
+package stalecache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gosettings"
+)
+
+type Settings struct {
+	// MaxEntries is the maximum number of responses to cache.
+	// It defaults to 10e4 if left unset.
+	MaxEntries int
+	// MaxStaleAge is the maximum duration after its expiry a
+	// cached response can be served if the upstream resolution
+	// fails, as described in RFC 8767. It can be left to 0 to
+	// disable serving stale responses.
+	MaxStaleAge time.Duration
+	// Prefetch is true if popular cached responses should be
+	// refreshed in the background shortly before they expire.
+	Prefetch bool
+	// Logger is used to log background refresh errors and must be set.
+	Logger Logger
+}
+
+func (s *Settings) setDefaults() {
+	const defaultMaxEntries = 10e4
+	s.MaxEntries = gosettings.DefaultComparable(s.MaxEntries, defaultMaxEntries)
+}
+
+var (
+	ErrMaxEntriesNotPositive = errors.New("max entries is not positive")
+	ErrMaxStaleAgeNegative   = errors.New("max stale age is negative")
+	ErrLoggerNotSet          = errors.New("logger is not set")
+)
+
+func (s Settings) validate() (err error) {
+	switch {
+	case s.MaxEntries <= 0:
+		return fmt.Errorf("%w: %d", ErrMaxEntriesNotPositive, s.MaxEntries)
+	case s.MaxStaleAge < 0:
+		return fmt.Errorf("%w: %s", ErrMaxStaleAgeNegative, s.MaxStaleAge)
+	case s.Logger == nil:
+		return fmt.Errorf("%w", ErrLoggerNotSet)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package stalecache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+type entry struct {
+	key string
+	// request is a copy of the request used to prefetch the response.
+	request  *dns.Msg
+	response *dns.Msg
+	storedAt time.Time
+	ttl      time.Duration
+	hits     uint
+	// refreshing is true if a background refresh is in progress.
+	refreshing bool
+}
+
+func (e *entry) expiry() time.Time {
+	return e.storedAt.Add(e.ttl)
+}
+
+// store is a least recently used store of DNS responses.
+type store struct {
+	maxEntries int
+	kv         map[string]*list.Element
+	linkedList *list.List
+	mutex      sync.Mutex
+}
+
+func newStore(maxEntries int) *store {
+	return &store{
+		maxEntries: maxEntries,
+		kv:         make(map[string]*list.Element),
+		linkedList: list.New(),
+	}
+}
+
+func makeKey(request *dns.Msg) (key string) {
+	question := request.Question[0]
+	key = dns.CanonicalName(question.Name) + "|" +
+		fmt.Sprint(question.Qtype) + "|" + fmt.Sprint(question.Qclass)
+	if opt := request.IsEdns0(); opt != nil && opt.Do() {
+		key += "|do"
+	}
+	if request.CheckingDisabled {
+		key += "|cd"
+	}
+	return key
+}
+
+// cacheTTL returns the duration the response can be cached for,
+// which is the minimum TTL of its records. It returns false if
+// the response cannot be cached.
+func cacheTTL(response *dns.Msg) (ttl time.Duration, ok bool) {
+	if response == nil || response.Rcode != dns.RcodeSuccess ||
+		response.Truncated || len(response.Answer) == 0 {
+		return 0, false
+	}
+
+	minTTL := ^uint32(0)
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns} {
+		for _, rr := range rrs {
+			minTTL = min(minTTL, rr.Header().Ttl)
+		}
+	}
+	if minTTL == 0 {
+		return 0, false
+	}
+	return time.Duration(minTTL) * time.Second, true
+}
+
+// add adds the response to the store if it can be cached, keeping
+// the hits count of the existing entry for the same key.
+func (s *store) add(request, response *dns.Msg, now time.Time) {
+	ttl, ok := cacheTTL(response)
+	if !ok {
+		return
+	}
+
+	key := makeKey(request)
+	newEntry := &entry{
+		key:      key,
+		request:  request.Copy(),
+		response: response.Copy(),
+		storedAt: now,
+		ttl:      ttl,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if listElement, ok := s.kv[key]; ok {
+		existing := listElement.Value.(*entry) //nolint:forcetypeassert
+		newEntry.hits = existing.hits
+		listElement.Value = newEntry
+		s.linkedList.MoveToFront(listElement)
+		return
+	}
+
+	if s.linkedList.Len() == s.maxEntries {
+		s.remove(s.linkedList.Back())
+	}
+	s.kv[key] = s.linkedList.PushFront(newEntry)
+}
+
+// get returns a copy of the cached response for the request, its age
+// and its time to live. It returns a nil response if there is no entry
+// or if the entry is expired for longer than maxStaleAge, in which case
+// the entry is removed.
+func (s *store) get(request *dns.Msg, now time.Time, maxStaleAge time.Duration) (
+	response *dns.Msg, age time.Duration, ttl time.Duration,
+) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listElement, ok := s.kv[makeKey(request)]
+	if !ok {
+		return nil, 0, 0
+	}
+
+	e := listElement.Value.(*entry) //nolint:forcetypeassert
+	if !now.Before(e.expiry().Add(maxStaleAge)) {
+		s.remove(listElement)
+		return nil, 0, 0
+	}
+
+	s.linkedList.MoveToFront(listElement)
+	e.hits++
+	return e.response.Copy(), now.Sub(e.storedAt), e.ttl
+}
+
+// startPrefetch returns a copy of the request to prefetch if the entry
+// for the request is popular and about to expire, and marks it as
+// being prefetched. It returns nil otherwise.
+func (s *store) startPrefetch(request *dns.Msg, now time.Time) (prefetchRequest *dns.Msg) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listElement, ok := s.kv[makeKey(request)]
+	if !ok {
+		return nil
+	}
+
+	e := listElement.Value.(*entry) //nolint:forcetypeassert
+	const minHits = 3
+	const prefetchFraction = 10 // last tenth of the TTL
+	remaining := e.expiry().Sub(now)
+	if e.refreshing || e.hits < minHits ||
+		remaining <= 0 || remaining > e.ttl/prefetchFraction {
+		return nil
+	}
+
+	e.refreshing = true
+	return e.request.Copy()
+}
+
+// startRefresh returns a copy of the request to refresh the entry for
+// the request with, and marks it as being refreshed. It returns nil if
+// there is no entry or if the entry is already being refreshed.
+func (s *store) startRefresh(request *dns.Msg) (refreshRequest *dns.Msg) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listElement, ok := s.kv[makeKey(request)]
+	if !ok {
+		return nil
+	}
+
+	e := listElement.Value.(*entry) //nolint:forcetypeassert
+	if e.refreshing {
+		return nil
+	}
+	e.refreshing = true
+	return e.request.Copy()
+}
+
+// endRefresh marks the entry for the request as no longer being
+// refreshed, for when the refresh failed.
+func (s *store) endRefresh(request *dns.Msg) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	listElement, ok := s.kv[makeKey(request)]
+	if !ok {
+		return
+	}
+	e := listElement.Value.(*entry) //nolint:forcetypeassert
+	e.refreshing = false
+}
+
+func (s *store) remove(listElement *list.Element) {
+	s.linkedList.Remove(listElement)
+	e := listElement.Value.(*entry) //nolint:forcetypeassert
+	delete(s.kv, e.key)
+}
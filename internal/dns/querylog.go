@@ -0,0 +1,15 @@
+package dns
+
+import "github.com/qdm12/gluetun/internal/dns/middleware/querylog"
+
+// GetQueryLog returns the DNS query log entries kept in memory,
+// from the oldest to the newest.
+func (l *Loop) GetQueryLog() (entries []querylog.Entry) {
+	return l.queryLog.Entries()
+}
+
+// GetQueryStats returns the DNS query totals and the top n clients
+// and domains aggregated from the query log entries in memory.
+func (l *Loop) GetQueryStats(n int) (stats querylog.Stats) {
+	return l.queryLog.Stats(n)
+}
@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/v2/pkg/middlewares/filter/mapfilter"
+	"github.com/qdm12/dns/v2/pkg/middlewares/filter/update"
+	"github.com/qdm12/gluetun/internal/dns/expansion"
+)
+
+// Filter is a DNS filter blocking requests matching the block
+// rules or blocked by the underlying map filter, unless they
+// match one of the allow rules, which always take precedence.
+// Responses are filtered by the underlying map filter only.
+type Filter struct {
+	mapFilter  *mapfilter.Filter
+	block      *Matcher
+	allow      *Matcher
+	exemption  *exemption
+	rulesMutex sync.RWMutex
+}
+
+type exemption struct {
+	isExempt func(name string) bool
+	prefixes []netip.Prefix
+}
+
+// NewFilter creates a new filter wrapping the given map filter.
+func NewFilter(mapFilter *mapfilter.Filter) *Filter {
+	emptyMatcher, _ := NewMatcher(nil)
+	return &Filter{
+		mapFilter: mapFilter,
+		block:     emptyMatcher,
+		allow:     emptyMatcher,
+	}
+}
+
+// Update updates the underlying map filter.
+func (f *Filter) Update(settings update.Settings) (err error) {
+	return f.mapFilter.Update(settings)
+}
+
+// UpdateRules sets the block and allow rules of the filter.
+func (f *Filter) UpdateRules(blockRules, allowRules []string) (err error) {
+	block, err := NewMatcher(blockRules)
+	if err != nil {
+		return fmt.Errorf("block rules: %w", err)
+	}
+
+	allow, err := NewMatcher(allowRules)
+	if err != nil {
+		return fmt.Errorf("allow rules: %w", err)
+	}
+
+	f.rulesMutex.Lock()
+	defer f.rulesMutex.Unlock()
+	f.block = block
+	f.allow = allow
+	return nil
+}
+
+// SetExemption sets a function returning true for names whose response
+// answers in the given IP prefixes must not be filtered out. This is
+// used to let DNS rebinding protection handle private IP addresses for
+// its allowed and bypassed names. Set isExempt to nil to disable it.
+func (f *Filter) SetExemption(isExempt func(name string) bool,
+	prefixes []netip.Prefix,
+) {
+	f.rulesMutex.Lock()
+	defer f.rulesMutex.Unlock()
+	if isExempt == nil {
+		f.exemption = nil
+		return
+	}
+	f.exemption = &exemption{
+		isExempt: isExempt,
+		prefixes: prefixes,
+	}
+}
+
+func (f *Filter) FilterRequest(request *dns.Msg) (blocked bool) {
+	f.rulesMutex.RLock()
+	block, allow := f.block, f.allow
+	f.rulesMutex.RUnlock()
+
+	for _, question := range request.Question {
+		if allow.Match(question.Name) {
+			return false
+		}
+	}
+
+	if f.mapFilter.FilterRequest(request) {
+		return true
+	}
+
+	for _, question := range request.Question {
+		if block.Match(question.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) FilterResponse(response *dns.Msg) (blocked bool) {
+	f.rulesMutex.RLock()
+	exemption := f.exemption
+	f.rulesMutex.RUnlock()
+
+	if exemption != nil && exemption.applies(response) {
+		response = exemption.removeExemptAnswers(response)
+	}
+
+	return f.mapFilter.FilterResponse(response)
+}
+
+func (e *exemption) applies(response *dns.Msg) bool {
+	for _, question := range response.Question {
+		if e.isExempt(question.Name) {
+			return true
+		}
+	}
+	// Only consider the search domain expansion of the question name,
+	// since a CNAME record from any name to an exempt name would
+	// otherwise bypass the filter.
+	target, ok := expansion.Target(response)
+	return ok && e.isExempt(target)
+}
+
+// removeExemptAnswers returns a shallow copy of the response without
+// the A and AAAA answers in the exemption IP prefixes.
+func (e *exemption) removeExemptAnswers(response *dns.Msg) *dns.Msg {
+	filtered := *response
+	filtered.Answer = make([]dns.RR, 0, len(response.Answer))
+	for _, answer := range response.Answer {
+		var ip net.IP
+		switch record := answer.(type) {
+		case *dns.A:
+			ip = record.A
+		case *dns.AAAA:
+			ip = record.AAAA
+		}
+		addr, ok := netip.AddrFromSlice(ip)
+		if ok && e.contains(addr.Unmap()) {
+			continue
+		}
+		filtered.Answer = append(filtered.Answer, answer)
+	}
+	return &filtered
+}
+
+func (e *exemption) contains(ip netip.Addr) bool {
+	for _, prefix := range e.prefixes {
+		if prefix.Contains(ip) || prefix.Contains(netip.AddrFrom16(ip.As16())) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package rules
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/v2/pkg/middlewares/filter/mapfilter"
+	"github.com/qdm12/dns/v2/pkg/middlewares/filter/update"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Filter_FilterRequest(t *testing.T) {
+	t.Parallel()
+
+	mapFilter, err := mapfilter.New(mapfilter.Settings{})
+	require.NoError(t, err)
+	filter := NewFilter(mapFilter)
+
+	updateSettings := update.Settings{}
+	updateSettings.BlockHostnames([]string{"listed.com", "allowed.listed.com"})
+	err = filter.Update(updateSettings)
+	require.NoError(t, err)
+
+	err = filter.UpdateRules(
+		[]string{"*.tracker.example", `/^ads[0-9]+\./`},
+		[]string{"good.tracker.example", "allowed.listed.com", "/^ads1\\./"},
+	)
+	require.NoError(t, err)
+
+	testCases := map[string]bool{
+		"listed.com.":               true,
+		"allowed.listed.com.":       false,
+		"bad.tracker.example.":      true,
+		"good.tracker.example.":     false,
+		"sub.good.tracker.example.": true,
+		"ads2.example.com.":         true,
+		"ads1.example.com.":         false,
+		"example.com.":              false,
+	}
+
+	for name, blocked := range testCases {
+		request := new(dns.Msg).SetQuestion(name, dns.TypeA)
+		assert.Equal(t, blocked, filter.FilterRequest(request), name)
+	}
+}
+
+func Test_Filter_UpdateRules(t *testing.T) {
+	t.Parallel()
+
+	mapFilter, err := mapfilter.New(mapfilter.Settings{})
+	require.NoError(t, err)
+	filter := NewFilter(mapFilter)
+
+	err = filter.UpdateRules([]string{"/(/"}, nil)
+	assert.ErrorIs(t, err, ErrRegexNotValid)
+
+	err = filter.UpdateRules(nil, []string{"*."})
+	assert.ErrorIs(t, err, ErrHostnameNotValid)
+}
+
+func Test_Filter_FilterResponse_exemption(t *testing.T) {
+	t.Parallel()
+
+	mapFilter, err := mapfilter.New(mapfilter.Settings{})
+	require.NoError(t, err)
+	filter := NewFilter(mapFilter)
+
+	newResponse := func(name, ip string) *dns.Msg {
+		response := new(dns.Msg).SetQuestion(name, dns.TypeA)
+		response.Answer = []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET},
+			A:   net.ParseIP(ip),
+		}}
+		return response
+	}
+
+	// The map filter refuses private addresses for public names.
+	assert.True(t, filter.FilterResponse(newResponse("nas.example.com.", "192.168.1.1")))
+
+	filter.SetExemption(func(name string) bool {
+		return name == "nas.example.com."
+	}, []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")})
+
+	assert.False(t, filter.FilterResponse(newResponse("nas.example.com.", "192.168.1.1")))
+	assert.True(t, filter.FilterResponse(newResponse("nas.example.com.", "10.0.0.1")))
+	assert.True(t, filter.FilterResponse(newResponse("other.example.com.", "192.168.1.1")))
+
+	// A CNAME record from a name to an exempt name is not exempt.
+	cnameResponse := newResponse("nas.example.com.", "192.168.1.1")
+	cnameResponse.Question[0].Name = "attacker.com."
+	cnameResponse.Answer = append([]dns.RR{&dns.CNAME{
+		Hdr:    dns.RR_Header{Name: "attacker.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET},
+		Target: "nas.example.com.",
+	}}, cnameResponse.Answer...)
+	assert.True(t, filter.FilterResponse(cnameResponse))
+
+	filter.SetExemption(nil, nil)
+	assert.True(t, filter.FilterResponse(newResponse("nas.example.com.", "192.168.1.1")))
+}
@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matcher matches hostnames against a set of rules.
+type Matcher struct {
+	tree    *suffixTree
+	regexes []*regexp.Regexp
+}
+
+// NewMatcher parses the rule strings given and returns a matcher.
+func NewMatcher(ruleStrings []string) (matcher *Matcher, err error) {
+	matcher = &Matcher{
+		tree: newSuffixTree(),
+	}
+
+	for _, ruleString := range ruleStrings {
+		rule, err := Parse(ruleString)
+		if err != nil {
+			return nil, err
+		}
+
+		switch rule.Kind {
+		case KindExact, KindWildcard:
+			matcher.tree.insert(rule.Hostname, rule.Kind == KindWildcard)
+		case KindRegex:
+			matcher.regexes = append(matcher.regexes, rule.Regex)
+		default:
+			panic(fmt.Sprintf("rule kind %d not implemented", rule.Kind))
+		}
+	}
+
+	return matcher, nil
+}
+
+// Match returns true if the hostname given matches one of the rules.
+// The hostname can be a fully qualified domain name and is matched
+// case insensitively.
+func (m *Matcher) Match(hostname string) bool {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	if m.tree.match(hostname) {
+		return true
+	}
+
+	for _, regex := range m.regexes {
+		if regex.MatchString(hostname) {
+			return true
+		}
+	}
+
+	return false
+}
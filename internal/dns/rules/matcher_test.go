@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		s          string
+		kind       Kind
+		hostname   string
+		errWrapped error
+		errMessage string
+	}{
+		"empty": {
+			errWrapped: ErrRuleEmpty,
+			errMessage: "rule is empty",
+		},
+		"exact": {
+			s:        "Example.com.",
+			kind:     KindExact,
+			hostname: "example.com",
+		},
+		"wildcard": {
+			s:        "*.tracker.example",
+			kind:     KindWildcard,
+			hostname: "tracker.example",
+		},
+		"regex": {
+			s:    `/^ads[0-9]+\./`,
+			kind: KindRegex,
+		},
+		"invalid_regex": {
+			s:          "/(/",
+			errWrapped: ErrRegexNotValid,
+			errMessage: "regular expression is not valid: /(/: " +
+				"error parsing regexp: missing closing ): `(`",
+		},
+		"invalid_hostname": {
+			s:          "a.*.com",
+			errWrapped: ErrHostnameNotValid,
+			errMessage: "hostname is not valid: a.*.com",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := Parse(testCase.s)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.kind, rule.Kind)
+			assert.Equal(t, testCase.hostname, rule.Hostname)
+			assert.Equal(t, testCase.kind == KindRegex, rule.Regex != nil)
+		})
+	}
+}
+
+func Test_Matcher_Match(t *testing.T) {
+	t.Parallel()
+
+	matcher, err := NewMatcher([]string{
+		"exact.com",
+		"*.tracker.example",
+		"*.deep.sub.org",
+		`/^ads[0-9]+\.example\.net$/`,
+	})
+	require.NoError(t, err)
+
+	testCases := map[string]bool{
+		"exact.com.":               true,
+		"EXACT.com":                true,
+		"sub.exact.com":            false,
+		"tracker.example":          false,
+		"a.tracker.example.":       true,
+		"a.b.tracker.example":      true,
+		"atracker.example":         false,
+		"sub.org":                  false,
+		"deep.sub.org":             false,
+		"x.deep.sub.org":           true,
+		"ads1.example.net.":        true,
+		"ads.example.net":          false,
+		"sub.ads1.example.net":     false,
+		"com":                      false,
+		"unrelated.example.com":    false,
+		"tracker.example.attacker": false,
+	}
+
+	for hostname, expected := range testCases {
+		t.Run(hostname, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, expected, matcher.Match(hostname))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of a hostname rule.
+type Kind uint8
+
+const (
+	// KindExact matches the hostname exactly.
+	KindExact Kind = iota
+	// KindWildcard matches subdomains of the hostname, and is
+	// written as `*.example.com`.
+	KindWildcard
+	// KindRegex matches hostnames using a regular expression,
+	// and is written as `/regex/`.
+	KindRegex
+)
+
+var (
+	ErrRuleEmpty        = errors.New("rule is empty")
+	ErrHostnameNotValid = errors.New("hostname is not valid")
+	ErrRegexNotValid    = errors.New("regular expression is not valid")
+)
+
+var hostnameRegex = regexp.MustCompile(`^([a-z0-9]|[a-z0-9_][a-z0-9\-_]{0,61}[a-z0-9_])(\.([a-z0-9]|[a-z0-9_][a-z0-9\-_]{0,61}[a-z0-9]))*$`) //nolint:lll
+
+// Rule is a parsed hostname rule.
+type Rule struct {
+	Kind Kind
+	// Hostname is the lowercased hostname without trailing dot,
+	// and is set for exact and wildcard rules.
+	Hostname string
+	// Regex is set for regular expression rules.
+	Regex *regexp.Regexp
+}
+
+// Parse parses a rule string which can be an exact hostname
+// such as `example.com`, a wildcard such as `*.example.com`
+// or a regular expression such as `/^ads[0-9]*\.example\.com$/`.
+func Parse(s string) (rule Rule, err error) {
+	switch {
+	case s == "":
+		return Rule{}, fmt.Errorf("%w", ErrRuleEmpty)
+	case len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/"):
+		regex, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %s: %w", ErrRegexNotValid, s, err)
+		}
+		return Rule{Kind: KindRegex, Regex: regex}, nil
+	}
+
+	rule.Kind = KindExact
+	hostname := strings.TrimSuffix(strings.ToLower(s), ".")
+	if trimmed, ok := strings.CutPrefix(hostname, "*."); ok {
+		rule.Kind = KindWildcard
+		hostname = trimmed
+	}
+
+	if !hostnameRegex.MatchString(hostname) {
+		return Rule{}, fmt.Errorf("%w: %s", ErrHostnameNotValid, s)
+	}
+	rule.Hostname = hostname
+	return rule, nil
+}
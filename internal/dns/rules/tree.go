@@ -0,0 +1,68 @@
+package rules
+
+import "strings"
+
+// suffixTree is a tree of domain name labels, starting from the
+// top level domain label, to match hostnames against exact and
+// wildcard rules in a time proportional to the number of labels.
+type suffixTree struct {
+	root *treeNode
+}
+
+type treeNode struct {
+	children map[string]*treeNode
+	// exact is true if the hostname ending at this node matches.
+	exact bool
+	// wildcard is true if subdomains of the hostname ending at
+	// this node match.
+	wildcard bool
+}
+
+func newSuffixTree() *suffixTree {
+	return &suffixTree{root: &treeNode{}}
+}
+
+func (t *suffixTree) insert(hostname string, wildcard bool) {
+	node := t.root
+	labels := strings.Split(hostname, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		child, ok := node.children[labels[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[string]*treeNode)
+			}
+			child = &treeNode{}
+			node.children[labels[i]] = child
+		}
+		node = child
+	}
+
+	if wildcard {
+		node.wildcard = true
+	} else {
+		node.exact = true
+	}
+}
+
+// match returns true if the hostname given, which must be lowercased
+// and without trailing dot, matches an exact or a wildcard rule.
+func (t *suffixTree) match(hostname string) bool {
+	node := t.root
+	for {
+		lastDot := strings.LastIndexByte(hostname, '.')
+		label := hostname[lastDot+1:]
+		child, ok := node.children[label]
+		if !ok {
+			return false
+		}
+		node = child
+
+		if lastDot == -1 {
+			return node.exact
+		}
+		if node.wildcard {
+			return true
+		}
+		hostname = hostname[:lastDot]
+	}
+}
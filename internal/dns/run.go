@@ -75,6 +75,10 @@ func (l *Loop) runWait(ctx context.Context, runError <-chan error) (exitLoop boo
 		select {
 		case <-ctx.Done():
 			l.stopServer()
+			err := l.queryLog.Close()
+			if err != nil {
+				l.logger.Error(err.Error())
+			}
 			// TODO revert OS and Go nameserver when exiting
 			return true
 		case <-l.stop:
@@ -99,6 +103,7 @@ func (l *Loop) runWait(ctx context.Context, runError <-chan error) (exitLoop boo
 }
 
 func (l *Loop) stopServer() {
+	l.stopBlockListsRefresh()
 	stopErr := l.server.Stop()
 	if stopErr != nil {
 		l.logger.Error("stopping DoT server: " + stopErr.Error())
@@ -0,0 +1,185 @@
+package dns
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/v2/pkg/server"
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/dns/encrypted"
+	"github.com/qdm12/goservices"
+)
+
+// newServers creates a group of DNS servers, one for each listening
+// address and protocol, sharing the same dialer and middlewares.
+// If no listening address is given, servers listen on all addresses.
+// The encrypted DNS servers serve queries using the handler of the
+// first plain DNS server.
+func newServers(serverSettings server.Settings, settings settings.DNS,
+	logger Logger, now time.Time,
+) (servers *goservices.Group, err error) {
+	var services []goservices.Service
+	sharer := &handlerSharer{}
+	// Middlewares are stopped by the first plain DNS server only.
+	noStopMiddlewares := make([]server.Middleware, len(serverSettings.Middlewares))
+	for i, middleware := range serverSettings.Middlewares {
+		noStopMiddlewares[i] = noStopMiddleware{Middleware: middleware}
+	}
+
+	for i, listeningAddress := range listeningAddresses(settings.ListeningAddresses,
+		*settings.ListeningPort) {
+		plainSettings := serverSettings
+		plainSettings.ListeningAddress = &listeningAddress
+		if i == 0 {
+			// The handler sharer wraps all the other middlewares.
+			plainSettings.Middlewares = append(slices.Clone(serverSettings.Middlewares), sharer)
+		} else {
+			plainSettings.Middlewares = noStopMiddlewares
+		}
+		service, err := server.New(plainSettings)
+		if err != nil {
+			return nil, fmt.Errorf("creating server listening on %s: %w",
+				listeningAddress, err)
+		}
+		services = append(services, service)
+	}
+
+	encryptedServices, err := newEncryptedServers(sharer,
+		settings, logger, now)
+	if err != nil {
+		return nil, err
+	}
+	services = append(services, encryptedServices...)
+
+	return goservices.NewGroup(goservices.GroupSettings{
+		Name:     "DNS servers",
+		Services: services,
+	})
+}
+
+func newEncryptedServers(handler dns.Handler, settings settings.DNS,
+	logger Logger, now time.Time,
+) (services []goservices.Service, err error) {
+	encryptedSettings := settings.Encrypted
+	if !*encryptedSettings.DoTEnabled && !*encryptedSettings.DoHEnabled {
+		return nil, nil
+	}
+
+	certificate, created, err := encrypted.LoadOrCreateCertificate(
+		*encryptedSettings.CertificateFilepath, *encryptedSettings.KeyFilepath,
+		encryptedSettings.Hostnames, certificateIPs(settings.ListeningAddresses), now)
+	if err != nil {
+		return nil, fmt.Errorf("setting up TLS certificate: %w", err)
+	}
+	if created {
+		logger.Info("generated self-signed certificate " + *encryptedSettings.CertificateFilepath)
+	}
+
+	type protocolPort struct {
+		protocol encrypted.Protocol
+		enabled  bool
+		port     uint16
+	}
+	protocolPorts := []protocolPort{
+		{
+			protocol: encrypted.ProtocolDoT,
+			enabled:  *encryptedSettings.DoTEnabled,
+			port:     *encryptedSettings.DoTPort,
+		},
+		{
+			protocol: encrypted.ProtocolDoH,
+			enabled:  *encryptedSettings.DoHEnabled,
+			port:     *encryptedSettings.DoHPort,
+		},
+	}
+	for _, protocolPort := range protocolPorts {
+		if !protocolPort.enabled {
+			continue
+		}
+		for _, listeningAddress := range listeningAddresses(settings.ListeningAddresses,
+			protocolPort.port) {
+			service, err := encrypted.New(encrypted.Settings{
+				Protocol:         protocolPort.protocol,
+				ListeningAddress: listeningAddress,
+				Path:             *encryptedSettings.DoHPath,
+				Certificate:      certificate,
+				Handler:          handler,
+				Logger:           logger,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("creating %s server listening on %s: %w",
+					protocolPort.protocol, listeningAddress, err)
+			}
+			services = append(services, service)
+		}
+	}
+
+	return services, nil
+}
+
+func listeningAddresses(addresses []netip.Addr, port uint16) (listeningAddresses []string) {
+	if len(addresses) == 0 {
+		return []string{":" + fmt.Sprint(port)}
+	}
+	listeningAddresses = make([]string, len(addresses))
+	for i, address := range addresses {
+		listeningAddresses[i] = netip.AddrPortFrom(address, port).String()
+	}
+	return listeningAddresses
+}
+
+// certificateIPs returns the IP addresses to set in a self-signed
+// certificate, which are the loopback IPv4 address and the specified
+// listening addresses.
+func certificateIPs(addresses []netip.Addr) (ips []netip.Addr) {
+	ips = []netip.Addr{netip.AddrFrom4([4]byte{127, 0, 0, 1})}
+	for _, address := range addresses {
+		if address.IsUnspecified() || address.IsLoopback() {
+			continue
+		}
+		ips = append(ips, address)
+	}
+	return ips
+}
+
+type noStopMiddleware struct {
+	server.Middleware
+}
+
+func (noStopMiddleware) Stop() (err error) { return nil }
+
+// handlerSharer is a middleware sharing the handler it wraps, so
+// queries it serves go through the handler of a plain DNS server
+// with its middlewares and upstream dialer. It should be the outer
+// most middleware of the plain DNS server.
+type handlerSharer struct {
+	handler atomic.Pointer[dns.Handler]
+}
+
+func (h *handlerSharer) String() string {
+	return "handler sharer"
+}
+
+func (h *handlerSharer) Stop() (err error) {
+	return nil
+}
+
+func (h *handlerSharer) Wrap(next dns.Handler) dns.Handler { //nolint:ireturn
+	h.handler.Store(&next)
+	return next
+}
+
+// ServeDNS serves the query with the handler shared, or answers with
+// a server failure if the plain DNS server is not started yet.
+func (h *handlerSharer) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
+	handler := h.handler.Load()
+	if handler == nil {
+		dns.HandleFailed(w, request)
+		return
+	}
+	(*handler).ServeDNS(w, request)
+}
@@ -0,0 +1,41 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordWriter struct {
+	dns.ResponseWriter
+	response *dns.Msg
+}
+
+func (w *recordWriter) WriteMsg(response *dns.Msg) error {
+	w.response = response
+	return nil
+}
+
+func Test_handlerSharer(t *testing.T) {
+	t.Parallel()
+
+	sharer := &handlerSharer{}
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+
+	writer := &recordWriter{}
+	sharer.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeServerFailure, writer.response.Rcode)
+
+	next := &nextHandler{}
+	wrapped := sharer.Wrap(next)
+	assert.Same(t, next, wrapped)
+
+	writer = &recordWriter{}
+	sharer.ServeDNS(writer, request)
+	require.NotNil(t, writer.response)
+	assert.Equal(t, dns.RcodeNameError, writer.response.Rcode)
+	assert.Equal(t, []string{"example.com."}, next.requests)
+}
@@ -3,17 +3,22 @@ package dns
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"time"
 
+	mdns "github.com/miekg/dns"
 	"github.com/qdm12/dns/v2/pkg/dot"
-	cachemiddleware "github.com/qdm12/dns/v2/pkg/middlewares/cache"
-	"github.com/qdm12/dns/v2/pkg/middlewares/cache/lru"
-	filtermiddleware "github.com/qdm12/dns/v2/pkg/middlewares/filter"
-	"github.com/qdm12/dns/v2/pkg/middlewares/filter/mapfilter"
 	"github.com/qdm12/dns/v2/pkg/provider"
 	"github.com/qdm12/dns/v2/pkg/server"
 	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/dns/middleware/acl"
+	dnssecmiddleware "github.com/qdm12/gluetun/internal/dns/middleware/dnssec"
+	"github.com/qdm12/gluetun/internal/dns/middleware/profilefilter"
+	"github.com/qdm12/gluetun/internal/dns/middleware/querylog"
+	"github.com/qdm12/gluetun/internal/dns/middleware/rebinding"
 	splitmiddleware "github.com/qdm12/gluetun/internal/dns/middleware/split"
+	"github.com/qdm12/gluetun/internal/dns/middleware/stalecache"
+	"github.com/qdm12/gluetun/internal/dns/rules"
 )
 
 func (l *Loop) GetSettings() (settings settings.DNS) { return l.state.GetSettings() }
@@ -25,7 +30,9 @@ func (l *Loop) SetSettings(ctx context.Context, settings settings.DNS) (
 }
 
 func buildDoTSettings(settings settings.DNS,
-	filter *mapfilter.Filter, logger Logger, bypassConfig *BypassConfig) (
+	filter *rules.Filter, profileFilters map[string]*rules.Filter,
+	logger Logger, bypassConfig *BypassConfig,
+	queryLog *querylog.Store) (
 	serverSettings server.Settings, err error,
 ) {
 	serverSettings.Logger = logger
@@ -50,7 +57,23 @@ func buildDoTSettings(settings settings.DNS,
 		return server.Settings{}, fmt.Errorf("creating DNS over TLS dialer: %w", err)
 	}
 
+	// The query log middleware wraps all the other middlewares except
+	// the access control middleware, and its markers are placed in between
+	// the other middlewares to detect if a query is blocked, cached or bypassed.
+	var queryLogMiddleware *querylog.Middleware
+	if *settings.DoT.QueryLog.Enabled {
+		queryLogMiddleware, err = querylog.New(querylog.Settings{
+			Store: queryLog,
+		})
+		if err != nil {
+			return server.Settings{}, fmt.Errorf("creating query log middleware: %w", err)
+		}
+		serverSettings.Middlewares = append(serverSettings.Middlewares,
+			queryLogMiddleware.Marker(querylog.StageUpstream))
+	}
+
 	// Add DNS bypass middleware if configured
+	var splitMiddleware *splitmiddleware.Middleware
 	if bypassConfig != nil && bypassConfig.Resolver.IsValid() && len(bypassConfig.Domains) > 0 {
 		// Convert timeout from seconds to duration
 		var timeout time.Duration
@@ -58,9 +81,11 @@ func buildDoTSettings(settings settings.DNS,
 			timeout = time.Duration(bypassConfig.Timeout) * time.Second
 		}
 
-		splitMiddleware, err := splitmiddleware.New(splitmiddleware.Settings{
+		splitMiddleware, err = splitmiddleware.New(splitmiddleware.Settings{
 			BypassResolver: bypassConfig.Resolver,
 			BypassDomains:  bypassConfig.Domains,
+			SearchDomains:  bypassConfig.SearchDomains,
+			Ndots:          &bypassConfig.Ndots,
 			Timeout:        timeout,
 			Logger:         logger,
 		})
@@ -68,17 +93,35 @@ func buildDoTSettings(settings settings.DNS,
 			return server.Settings{}, fmt.Errorf("creating DNS bypass middleware: %w", err)
 		}
 		serverSettings.Middlewares = append(serverSettings.Middlewares, splitMiddleware)
+		if queryLogMiddleware != nil {
+			serverSettings.Middlewares = append(serverSettings.Middlewares,
+				queryLogMiddleware.Marker(querylog.StageBypass))
+		}
 		logger.Info(fmt.Sprintf("DNS bypass enabled for %d domains using resolver: %s",
 			len(bypassConfig.Domains), bypassConfig.Resolver))
 	}
 
-	if *settings.DoT.Caching {
-		lruCache, err := lru.New(lru.Settings{})
+	// Middlewares later in the slice wrap the ones before them.
+	// The DNSSEC middleware is placed before the cache middleware,
+	// so the cache middleware wraps it and only caches validated
+	// responses, which are not validated again on cache hits.
+	if *settings.DoT.DNSSEC.Enabled {
+		dnssecMiddleware, err := buildDNSSECMiddleware(settings.DoT.DNSSEC,
+			splitMiddleware, logger)
 		if err != nil {
-			return server.Settings{}, fmt.Errorf("creating LRU cache: %w", err)
+			return server.Settings{}, fmt.Errorf("creating DNSSEC middleware: %w", err)
 		}
-		cacheMiddleware, err := cachemiddleware.New(cachemiddleware.Settings{
-			Cache: lruCache,
+		serverSettings.Middlewares = append(serverSettings.Middlewares, dnssecMiddleware)
+	}
+
+	// The cache middleware is used even if serving stale responses
+	// and prefetching are disabled, since it passes the client response
+	// writer down the middleware chain for the query log markers.
+	if *settings.DoT.Caching {
+		cacheMiddleware, err := stalecache.New(stalecache.Settings{
+			MaxStaleAge: *settings.DoT.ServeStaleMaxAge,
+			Prefetch:    *settings.DoT.Prefetch,
+			Logger:      logger,
 		})
 		if err != nil {
 			return server.Settings{}, fmt.Errorf("creating cache middleware: %w", err)
@@ -86,13 +129,117 @@ func buildDoTSettings(settings settings.DNS,
 		serverSettings.Middlewares = append(serverSettings.Middlewares, cacheMiddleware)
 	}
 
-	filterMiddleware, err := filtermiddleware.New(filtermiddleware.Settings{
-		Filter: filter,
+	// The DNS rebinding protection middleware wraps the DNSSEC
+	// middleware so that responses are validated before being stripped.
+	filters := []*rules.Filter{filter}
+	for _, profile := range settings.DoT.FilterProfiles {
+		filters = append(filters, profileFilters[profile.Name])
+	}
+	for _, filter := range filters {
+		filter.SetExemption(nil, nil)
+	}
+	if *settings.DoT.Blacklist.Rebinding.Enabled {
+		rebindingMiddleware, err := buildRebindingMiddleware(settings.DoT.Blacklist,
+			splitMiddleware, logger)
+		if err != nil {
+			return server.Settings{}, fmt.Errorf("creating DNS rebinding middleware: %w", err)
+		}
+		serverSettings.Middlewares = append(serverSettings.Middlewares, rebindingMiddleware)
+		// Let the rebinding middleware decide on protected IP addresses for
+		// its allowed and bypassed names, which would otherwise be refused
+		// by the filter.
+		for _, filter := range filters {
+			filter.SetExemption(rebindingMiddleware.IsExempt, rebindingMiddleware.Prefixes())
+		}
+	}
+
+	if queryLogMiddleware != nil {
+		serverSettings.Middlewares = append(serverSettings.Middlewares,
+			queryLogMiddleware.Marker(querylog.StageFiltered))
+	}
+
+	profiles := make([]profilefilter.Profile, len(settings.DoT.FilterProfiles))
+	for i, profile := range settings.DoT.FilterProfiles {
+		profiles[i] = profilefilter.Profile{
+			Name:    profile.Name,
+			Subnets: profile.ClientSubnets,
+			Filter:  profileFilters[profile.Name],
+		}
+	}
+	filterMiddleware, err := profilefilter.New(profilefilter.Settings{
+		Default:  filter,
+		Profiles: profiles,
 	})
 	if err != nil {
 		return server.Settings{}, fmt.Errorf("creating filter middleware: %w", err)
 	}
 	serverSettings.Middlewares = append(serverSettings.Middlewares, filterMiddleware)
 
+	if queryLogMiddleware != nil {
+		serverSettings.Middlewares = append(serverSettings.Middlewares, queryLogMiddleware)
+	}
+
+	// The access control middleware is the outermost middleware,
+	// so queries from refused clients are not recorded nor resolved.
+	if len(settings.AllowedClientSubnets) > 0 {
+		aclMiddleware, err := acl.New(acl.Settings{
+			AllowedSubnets: settings.AllowedClientSubnets,
+			Logger:         logger,
+		})
+		if err != nil {
+			return server.Settings{}, fmt.Errorf("creating access control middleware: %w", err)
+		}
+		serverSettings.Middlewares = append(serverSettings.Middlewares, aclMiddleware)
+	}
+
 	return serverSettings, nil
 }
+
+func buildDNSSECMiddleware(settings settings.DNSSEC,
+	splitMiddleware *splitmiddleware.Middleware, logger Logger,
+) (middleware *dnssecmiddleware.Middleware, err error) {
+	trustAnchors := make([]*mdns.DS, len(settings.TrustAnchors))
+	for i, trustAnchor := range settings.TrustAnchors {
+		trustAnchors[i], err = dnssecmiddleware.ParseTrustAnchor(trustAnchor)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trust anchor: %w", err)
+		}
+	}
+
+	var skip func(name string) bool
+	if splitMiddleware != nil && *settings.SkipBypassDomains {
+		skip = splitMiddleware.IsBypassed
+	}
+
+	return dnssecmiddleware.New(dnssecmiddleware.Settings{
+		TrustAnchors: trustAnchors,
+		Skip:         skip,
+		Logger:       logger,
+	})
+}
+
+// buildRebindingMiddleware creates the DNS rebinding protection middleware,
+// protecting the private IP prefixes as well as the blocked IP addresses
+// and networks, which default to private IP prefixes.
+func buildRebindingMiddleware(settings settings.DNSBlacklist,
+	splitMiddleware *splitmiddleware.Middleware, logger Logger,
+) (middleware *rebinding.Middleware, err error) {
+	prefixes := rebinding.PrivatePrefixes()
+	prefixes = append(prefixes, settings.AddBlockedIPPrefixes...)
+	for _, ip := range settings.AddBlockedIPs {
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+
+	var skip func(name string) bool
+	if splitMiddleware != nil {
+		skip = splitMiddleware.IsBypassed
+	}
+
+	return rebinding.New(rebinding.Settings{
+		Prefixes:     prefixes,
+		AllowedHosts: settings.Rebinding.AllowedHosts,
+		Skip:         skip,
+		Action:       *settings.Rebinding.Action,
+		Logger:       logger,
+	})
+}
@@ -0,0 +1,90 @@
+package dns
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+
+	mdns "github.com/miekg/dns"
+	"github.com/qdm12/dns/v2/pkg/middlewares/filter/mapfilter"
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/dns/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct {
+	mutex sync.Mutex
+	warns []string
+}
+
+func (l *testLogger) Debug(string) {}
+func (l *testLogger) Info(string)  {}
+func (l *testLogger) Error(string) {}
+func (l *testLogger) Warn(s string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.warns = append(l.warns, s)
+}
+
+type nextHandler struct {
+	requests []string
+}
+
+func (h *nextHandler) ServeDNS(w mdns.ResponseWriter, request *mdns.Msg) {
+	h.requests = append(h.requests, request.Question[0].Name)
+	response := new(mdns.Msg).SetRcode(request, mdns.RcodeNameError)
+	_ = w.WriteMsg(response)
+}
+
+type discardWriter struct {
+	mdns.ResponseWriter
+}
+
+func (discardWriter) WriteMsg(*mdns.Msg) error { return nil }
+
+func Test_buildDoTSettings_bypassSearchDomains(t *testing.T) {
+	t.Parallel()
+
+	var allSettings settings.Settings
+	allSettings.SetDefaults()
+	dnsSettings := allSettings.DNS
+
+	mapFilter, err := mapfilter.New(mapfilter.Settings{})
+	require.NoError(t, err)
+	filter := rules.NewFilter(mapFilter)
+
+	logger := &testLogger{}
+	bypassConfig := &BypassConfig{
+		// Nothing listens on this loopback address, so bypass
+		// queries fail immediately and a warning is logged for
+		// each search list candidate tried.
+		Resolver:      netip.AddrFrom4([4]byte{127, 0, 0, 254}),
+		Domains:       []string{"cluster.local"},
+		SearchDomains: []string{"svc.cluster.local"},
+		Ndots:         5,
+	}
+
+	serverSettings, err := buildDoTSettings(dnsSettings, filter, nil,
+		logger, bypassConfig, nil)
+	require.NoError(t, err)
+
+	next := &nextHandler{}
+	var handler mdns.Handler = next
+	for i := len(serverSettings.Middlewares) - 1; i >= 0; i-- {
+		if serverSettings.Middlewares[i].String() != "split" {
+			continue
+		}
+		handler = serverSettings.Middlewares[i].Wrap(handler)
+	}
+
+	request := new(mdns.Msg).SetQuestion("redis.", mdns.TypeA)
+	handler.ServeDNS(discardWriter{}, request)
+
+	// The short name is expanded with the search domain before being
+	// resolved as an absolute name by the next handler.
+	require.Len(t, logger.warns, 1)
+	assert.True(t, strings.Contains(logger.warns[0], "redis.svc.cluster.local."))
+	assert.Equal(t, []string{"redis."}, next.requests)
+}
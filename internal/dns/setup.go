@@ -4,41 +4,56 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/qdm12/dns/v2/pkg/check"
 	"github.com/qdm12/dns/v2/pkg/nameserver"
-	"github.com/qdm12/dns/v2/pkg/server"
 )
 
 var errUpdateBlockLists = errors.New("cannot update filter block lists")
 
-func (l *Loop) setupServer(ctx context.Context) (runError <-chan error, err error) {
-	err = l.updateFiles(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", errUpdateBlockLists, err)
-	}
+// blockListsMaxFreshAge is the age under which block lists loaded
+// from disk are not refreshed at startup, to avoid downloading them
+// again right after they were updated, for example on a restart.
+const blockListsMaxFreshAge = 5 * time.Minute
 
+func (l *Loop) setupServer(ctx context.Context) (runError <-chan error, err error) {
 	settings := l.GetSettings()
 
-	dotSettings, err := buildDoTSettings(settings, l.filter, l.logger, l.bypassConfig)
+	fetchedAt := l.loadBlockLists(settings.DoT)
+	if fetchedAt.IsZero() {
+		err = l.updateFiles(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errUpdateBlockLists, err)
+		}
+	} else if l.timeNow().Sub(fetchedAt) > blockListsMaxFreshAge {
+		// Serve DNS using the block lists loaded from disk right away,
+		// and refresh them in the background.
+		l.startBlockListsRefresh(ctx)
+	}
+
+	profileFilters := l.getProfileFilters(settings.DoT.FilterProfiles)
+	dotSettings, err := buildDoTSettings(settings, l.filter, profileFilters, l.logger,
+		l.bypassConfig, l.queryLog)
 	if err != nil {
 		return nil, fmt.Errorf("building DoT settings: %w", err)
 	}
 
-	server, err := server.New(dotSettings)
+	servers, err := newServers(dotSettings, settings, l.logger, l.timeNow())
 	if err != nil {
 		return nil, fmt.Errorf("creating DoT server: %w", err)
 	}
 
-	runError, err = server.Start(ctx)
+	runError, err = servers.Start(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("starting server: %w", err)
 	}
-	l.server = server
+	l.server = servers
 
 	// use internal DNS server
 	nameserver.UseDNSInternally(nameserver.SettingsInternalDNS{
-		IP: settings.ServerAddress,
+		IP:   settings.ServerAddress,
+		Port: *settings.ListeningPort,
 	})
 	err = nameserver.UseDNSSystemWide(nameserver.SettingsSystemDNS{
 		IP:         settings.ServerAddress,
@@ -2,21 +2,76 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/qdm12/dns/v2/pkg/blockbuilder"
 	"github.com/qdm12/dns/v2/pkg/middlewares/filter/update"
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/dns/blocklist"
+	"github.com/qdm12/gluetun/internal/dns/rules"
 )
 
+// updateFiles builds the block lists of the default filter and
+// of each filter profile, and updates the filters with them.
 func (l *Loop) updateFiles(ctx context.Context) (err error) {
+	l.updateFilesMutex.Lock()
+	defer l.updateFilesMutex.Unlock()
+
 	settings := l.GetSettings()
 
-	l.logger.Info("downloading hostnames and IP block lists")
-	blacklistSettings := settings.DoT.Blacklist.ToBlockBuilderSettings(l.client)
+	err = l.updateFilter(ctx, l.filter, settings.DoT.Blacklist, "")
+	if err != nil {
+		return err
+	}
+
+	profileFilters := l.getProfileFilters(settings.DoT.FilterProfiles)
+	for _, profile := range settings.DoT.FilterProfiles {
+		err = l.updateFilter(ctx, profileFilters[profile.Name], profile.Blacklist, profile.Name)
+		if err != nil {
+			return fmt.Errorf("filter profile %s: %w", profile.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// updateFilter builds the block lists for the settings given and updates
+// the filter with them. The profile name is only used for logging, and is
+// empty for the default filter.
+func (l *Loop) updateFilter(ctx context.Context, filter *rules.Filter,
+	settings settings.DNSBlacklist, profile string,
+) (err error) {
+	lists, err := l.buildBlockLists(ctx, settings, profile)
+	if err != nil {
+		return err
+	}
+
+	err = applyBlockLists(filter, lists, settings)
+	if err != nil {
+		return err
+	}
+
+	l.saveBlockLists(lists, settings)
+
+	return nil
+}
+
+func (l *Loop) buildBlockLists(ctx context.Context,
+	settings settings.DNSBlacklist, profile string,
+) (lists blocklist.Lists, err error) {
+	if profile == "" {
+		l.logger.Info("downloading hostnames and IP block lists")
+	} else {
+		l.logger.Info("downloading hostnames and IP block lists for filter profile " + profile)
+	}
+	blacklistSettings := settings.ToBlockBuilderSettings(l.client)
 
 	blockBuilder, err := blockbuilder.New(blacklistSettings)
 	if err != nil {
-		return fmt.Errorf("creating block builder: %w", err)
+		return lists, fmt.Errorf("creating block builder: %w", err)
 	}
 
 	result := blockBuilder.BuildAll(ctx)
@@ -28,19 +83,206 @@ func (l *Loop) updateFiles(ctx context.Context) (err error) {
 		err = resultErr
 	}
 
+	blockedHostnames := result.BlockedHostnames
+	customResults := blocklist.Build(ctx, l.client, settings.CustomBlockLists)
+	for _, customResult := range customResults {
+		if customResult.Err != nil {
+			// Do not discard all the block lists
+			// because of a single custom block list.
+			l.logger.Warn(fmt.Sprintf("block list %s: %s",
+				customResult.Source, customResult.Err))
+			continue
+		}
+		l.logCustomBlockListResult(customResult)
+		blockedHostnames = append(blockedHostnames, customResult.Hostnames...)
+	}
+
 	if err != nil {
-		return err
+		return lists, err
 	}
 
-	updateSettings := update.Settings{
+	if len(customResults) > 0 {
+		blockedHostnames = deduplicateHostnames(blockedHostnames)
+	}
+
+	return blocklist.Lists{
+		Hostnames:  blockedHostnames,
 		IPs:        result.BlockedIPs,
 		IPPrefixes: result.BlockedIPPrefixes,
+	}, nil
+}
+
+func applyBlockLists(filter *rules.Filter, lists blocklist.Lists,
+	settings settings.DNSBlacklist,
+) (err error) {
+	updateSettings := update.Settings{
+		IPs:        lists.IPs,
+		IPPrefixes: lists.IPPrefixes,
 	}
-	updateSettings.BlockHostnames(result.BlockedHostnames)
-	err = l.filter.Update(updateSettings)
+	updateSettings.BlockHostnames(lists.Hostnames)
+	err = filter.Update(updateSettings)
 	if err != nil {
 		return fmt.Errorf("updating filter: %w", err)
 	}
 
+	// Allowed hosts rules take precedence over all blocked hostnames,
+	// and are evaluated by the filter at query time.
+	err = filter.UpdateRules(settings.AddBlockedHosts, settings.AllowedHosts)
+	if err != nil {
+		return fmt.Errorf("updating filter rules: %w", err)
+	}
+
 	return nil
 }
+
+// blockListsSettingsChecksum returns a checksum of the settings
+// used to build the block lists, to detect persisted block lists
+// built with different settings.
+func blockListsSettingsChecksum(settings settings.DNSBlacklist) (
+	checksum string, err error,
+) {
+	return blocklist.Checksum(struct {
+		BlockBuilder     blockbuilder.Settings
+		CustomBlockLists []string
+	}{
+		BlockBuilder:     settings.ToBlockBuilderSettings(nil),
+		CustomBlockLists: settings.CustomBlockLists,
+	})
+}
+
+func (l *Loop) saveBlockLists(lists blocklist.Lists, settings settings.DNSBlacklist) {
+	path := *settings.CacheFilepath
+	if path == "" {
+		return
+	}
+
+	settingsChecksum, err := blockListsSettingsChecksum(settings)
+	if err == nil {
+		err = blocklist.Save(path, lists, l.timeNow(), settingsChecksum)
+	}
+	if err != nil {
+		l.logger.Warn("saving block lists to " + path + ": " + err.Error())
+	}
+}
+
+// loadBlockLists loads the block lists persisted to disk into the default
+// filter and into each filter profile. It returns the time the oldest block
+// lists were fetched at, or the zero time if any block lists could not
+// be loaded.
+func (l *Loop) loadBlockLists(settings settings.DoT) (fetchedAt time.Time) {
+	fetchedAt = l.loadFilter(l.filter, settings.Blacklist)
+	if fetchedAt.IsZero() {
+		return time.Time{}
+	}
+
+	profileFilters := l.getProfileFilters(settings.FilterProfiles)
+	for _, profile := range settings.FilterProfiles {
+		profileFetchedAt := l.loadFilter(profileFilters[profile.Name], profile.Blacklist)
+		if profileFetchedAt.IsZero() {
+			return time.Time{}
+		}
+		if profileFetchedAt.Before(fetchedAt) {
+			fetchedAt = profileFetchedAt
+		}
+	}
+
+	return fetchedAt
+}
+
+// loadFilter loads the block lists persisted to disk into the filter.
+// It returns the time the block lists were fetched at, or the zero time
+// if the block lists could not be loaded.
+func (l *Loop) loadFilter(filter *rules.Filter, settings settings.DNSBlacklist) (fetchedAt time.Time) {
+	path := *settings.CacheFilepath
+	if path == "" {
+		return time.Time{}
+	}
+
+	settingsChecksum, err := blockListsSettingsChecksum(settings)
+	if err != nil {
+		l.logger.Warn("computing block lists settings checksum: " + err.Error())
+		return time.Time{}
+	}
+
+	lists, fetchedAt, err := blocklist.Load(path, settingsChecksum)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return time.Time{}
+	case errors.Is(err, blocklist.ErrSettingsChanged):
+		l.logger.Info("not using block lists from " + path + " since they were built with different settings")
+		return time.Time{}
+	case err != nil:
+		l.logger.Warn("loading block lists from " + path + ": " + err.Error())
+		return time.Time{}
+	}
+
+	err = applyBlockLists(filter, lists, settings)
+	if err != nil {
+		l.logger.Warn("applying block lists from " + path + ": " + err.Error())
+		return time.Time{}
+	}
+
+	l.logger.Info(fmt.Sprintf("loaded %d hostnames, %d IP addresses and %d IP networks "+
+		"block lists from %s fetched at %s", len(lists.Hostnames), len(lists.IPs),
+		len(lists.IPPrefixes), path, fetchedAt.Format(time.RFC3339)))
+	return fetchedAt
+}
+
+// startBlockListsRefresh builds the block lists and updates the filter
+// in the background, after block lists were loaded from disk, stopping
+// any previous refresh first. On failure, the block lists loaded from
+// disk are kept. It is stopped with stopBlockListsRefresh when the
+// server is stopped.
+func (l *Loop) startBlockListsRefresh(ctx context.Context) {
+	l.stopBlockListsRefresh()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	l.refreshCancel, l.refreshDone = cancel, done
+	go func() {
+		defer close(done)
+		err := l.updateFiles(ctx)
+		if err != nil && ctx.Err() == nil {
+			l.logger.Warn("refreshing block lists, keeping block lists loaded from disk: " + err.Error())
+		}
+	}()
+}
+
+// stopBlockListsRefresh cancels the background block lists
+// refresh if it is running, and waits for it to exit.
+func (l *Loop) stopBlockListsRefresh() {
+	if l.refreshCancel == nil {
+		return
+	}
+	l.refreshCancel()
+	<-l.refreshDone
+	l.refreshCancel, l.refreshDone = nil, nil
+}
+
+func (l *Loop) logCustomBlockListResult(result blocklist.Result) {
+	l.logger.Info(fmt.Sprintf("block list %s: %d hostnames, %d parse errors",
+		result.Source, len(result.Hostnames), len(result.ParseErrors)))
+
+	const maxParseErrorsLogged = 5
+	for i, parseErr := range result.ParseErrors {
+		if i == maxParseErrorsLogged {
+			l.logger.Debug(fmt.Sprintf("block list %s: %d more parse errors not shown",
+				result.Source, len(result.ParseErrors)-maxParseErrorsLogged))
+			break
+		}
+		l.logger.Debug(fmt.Sprintf("block list %s: %s", result.Source, parseErr))
+	}
+}
+
+func deduplicateHostnames(hostnames []string) (unique []string) {
+	seen := make(map[string]struct{}, len(hostnames))
+	unique = make([]string, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		if _, ok := seen[hostname]; ok {
+			continue
+		}
+		seen[hostname] = struct{}{}
+		unique = append(unique, hostname)
+	}
+	return unique
+}
@@ -0,0 +1,55 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deduplicateHostnames(t *testing.T) {
+	t.Parallel()
+
+	hostnames := []string{"a.com", "sub.a.com", "b.com", "b.com", "a.com"}
+
+	unique := deduplicateHostnames(hostnames)
+
+	assert.Equal(t, []string{"a.com", "sub.a.com", "b.com"}, unique)
+}
+
+func Test_Loop_buildBlockLists_customListFailing(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/list" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("blocked.com\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	logger := &testLogger{}
+	loop := &Loop{
+		client: server.Client(),
+		logger: logger,
+	}
+	disabled := false
+	blacklist := settings.DNSBlacklist{
+		BlockMalicious:    &disabled,
+		BlockAds:          &disabled,
+		BlockSurveillance: &disabled,
+		CustomBlockLists:  []string{server.URL + "/list", server.URL + "/missing"},
+	}
+
+	lists, err := loop.buildBlockLists(context.Background(), blacklist, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blocked.com"}, lists.Hostnames)
+	require.Len(t, logger.warns, 1)
+	assert.Contains(t, logger.warns[0], server.URL+"/missing")
+}
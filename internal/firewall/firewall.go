@@ -26,6 +26,7 @@ type Config struct { //nolint:maligned
 	enabled           bool
 	vpnConnection     models.Connection
 	vpnIntf           string
+	latencyProbes     []models.Connection
 	outboundSubnets   []netip.Prefix
 	allowedInputPorts map[uint16]map[string]struct{} // port to interfaces set mapping
 	portRedirections  portRedirections
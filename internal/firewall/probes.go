@@ -0,0 +1,69 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// SetLatencyProbes allows output traffic to the probe connections given,
+// replacing any previous probe connections. The protocol of each connection
+// can be 'icmp' (IPv4 only) or 'tcp'. Call it with no connection to remove
+// all the probe rules once probing is done.
+func (c *Config) SetLatencyProbes(ctx context.Context, connections []models.Connection) (err error) {
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	if !c.enabled {
+		return nil
+	}
+
+	remove := true
+	for _, connection := range c.latencyProbes {
+		for _, defaultRoute := range c.defaultRoutes {
+			err = c.acceptOutputProbe(ctx, defaultRoute.NetInterface, connection, remove)
+			if err != nil {
+				c.logger.Error("cannot remove outdated latency probe rule: " + err.Error())
+			}
+		}
+	}
+	c.latencyProbes = nil
+
+	remove = false
+	for _, connection := range connections {
+		for _, defaultRoute := range c.defaultRoutes {
+			err = c.acceptOutputProbe(ctx, defaultRoute.NetInterface, connection, remove)
+			if err != nil {
+				return fmt.Errorf("allowing latency probe to %s: %w", connection.IP, err)
+			}
+		}
+		c.latencyProbes = append(c.latencyProbes, connection)
+	}
+
+	return nil
+}
+
+func (c *Config) acceptOutputProbe(ctx context.Context,
+	defaultInterface string, connection models.Connection, remove bool,
+) error {
+	var instruction string
+	switch connection.Protocol {
+	case "icmp":
+		if !connection.IP.Is4() {
+			return nil
+		}
+		instruction = fmt.Sprintf("%s OUTPUT -d %s -o %s -p icmp --icmp-type echo-request -j ACCEPT",
+			appendOrDelete(remove), connection.IP, defaultInterface)
+	default:
+		instruction = fmt.Sprintf("%s OUTPUT -d %s -o %s -p tcp -m tcp --dport %d -j ACCEPT",
+			appendOrDelete(remove), connection.IP, defaultInterface, connection.Port)
+	}
+
+	if connection.IP.Is4() {
+		return c.runIptablesInstruction(ctx, instruction)
+	} else if c.ip6Tables == "" {
+		return fmt.Errorf("accept output latency probe: %w", ErrNeedIP6Tables)
+	}
+	return c.runIP6tablesInstruction(ctx, instruction)
+}
@@ -0,0 +1,21 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrDNSNoAddress = errors.New("no address resolved")
+
+// checkDNS resolves the hostname using the system resolver,
+// which is the internal DNS server when it is enabled.
+func (s *Server) checkDNS(ctx context.Context, hostname string) (err error) {
+	ips, err := s.dialer.Resolver.LookupNetIP(ctx, "ip", hostname)
+	if err != nil {
+		return fmt.Errorf("resolving: %w", err)
+	} else if len(ips) == 0 {
+		return fmt.Errorf("%w", ErrDNSNoAddress)
+	}
+	return nil
+}
@@ -2,12 +2,13 @@ package healthcheck
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
-	"net"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
 )
 
 func (s *Server) runHealthcheckLoop(ctx context.Context, done chan<- struct{}) {
@@ -39,6 +40,7 @@ func (s *Server) runHealthcheckLoop(ctx context.Context, done chan<- struct{}) {
 		switch {
 		case previousErr != nil && err == nil: // First success
 			s.logger.Info("healthy!")
+			s.vpn.loop.MarkConnectionHealthy()
 			timeoutIndex = 0
 			s.vpn.healthyTimer.Stop()
 			s.vpn.healthyWait = *s.config.VPN.Initial
@@ -67,56 +69,75 @@ func (s *Server) runHealthcheckLoop(ctx context.Context, done chan<- struct{}) {
 	}
 }
 
-func (s *Server) healthCheck(ctx context.Context) (err error) {
-	// TODO use mullvad API if current provider is Mullvad
+var ErrTargetQuorumNotReached = errors.New("target quorum not reached")
 
-	address, err := makeAddressToDial(s.config.TargetAddress)
-	if err != nil {
-		return err
+// healthCheck probes all the target addresses in parallel and returns
+// an error if fewer than the quorum of target addresses succeeded.
+// The error message contains the result of each target address.
+func (s *Server) healthCheck(ctx context.Context) (err error) {
+	targets := s.config.TargetAddresses
+	errs := make([]error, len(targets))
+	var waitGroup sync.WaitGroup
+	for i, target := range targets {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			errs[i] = s.checkTarget(ctx, target)
+		}()
 	}
+	waitGroup.Wait()
 
-	const dialNetwork = "tcp4"
-	connection, err := s.dialer.DialContext(ctx, dialNetwork, address)
-	if err != nil {
-		return fmt.Errorf("dialing: %w", err)
+	successes := uint(0)
+	results := make([]string, len(targets))
+	for i, target := range targets {
+		if errs[i] == nil {
+			successes++
+			results[i] = target + ": ok"
+			continue
+		}
+		results[i] = target + ": " + errs[i].Error()
 	}
 
-	if strings.HasSuffix(address, ":443") {
-		host, _, err := net.SplitHostPort(address)
-		if err != nil {
-			return fmt.Errorf("splitting host and port: %w", err)
-		}
-		tlsConfig := &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			ServerName: host,
-		}
-		tlsConnection := tls.Client(connection, tlsConfig)
-		err = tlsConnection.HandshakeContext(ctx)
-		if err != nil {
-			return fmt.Errorf("running TLS handshake: %w", err)
+	quorum := max(s.config.TargetQuorum, 1)
+	if successes < quorum {
+		if len(targets) == 1 {
+			return errs[0]
 		}
+		return fmt.Errorf("%w: %d of %d targets succeeded, quorum is %d: %s",
+			ErrTargetQuorumNotReached, successes, len(targets), quorum,
+			strings.Join(results, "; "))
 	}
 
-	err = connection.Close()
-	if err != nil {
-		return fmt.Errorf("closing connection: %w", err)
+	if successes < uint(len(targets)) {
+		s.logger.Debug(fmt.Sprintf("%d of %d targets succeeded: %s",
+			successes, len(targets), strings.Join(results, "; ")))
 	}
 
+	err = s.checkIPLeak(ctx)
+	if err != nil {
+		return fmt.Errorf("IP leak check: %w", err)
+	}
 	return nil
 }
 
-func makeAddressToDial(address string) (addressToDial string, err error) {
-	host, port, err := net.SplitHostPort(address)
+func (s *Server) checkTarget(ctx context.Context, targetString string) (err error) {
+	target, err := settings.ParseHealthTarget(targetString)
 	if err != nil {
-		addrErr := new(net.AddrError)
-		ok := errors.As(err, &addrErr)
-		if !ok || addrErr.Err != "missing port in address" {
-			return "", fmt.Errorf("splitting host and port from address: %w", err)
-		}
-		host = address
-		const defaultPort = "443"
-		port = defaultPort
+		return err
+	}
+
+	switch target.Type {
+	case settings.HealthTargetTCP:
+		return s.checkTCP(ctx, target.Address)
+	case settings.HealthTargetICMP:
+		return s.checkICMP(ctx, target.Address)
+	case settings.HealthTargetDNS:
+		return s.checkDNS(ctx, target.Address)
+	case settings.HealthTargetHTTP:
+		return s.checkHTTP(ctx, target)
+	case settings.HealthTargetWireguard:
+		return s.checkWireguard()
+	default:
+		panic("health target type not handled: " + string(target.Type))
 	}
-	address = net.JoinHostPort(host, port)
-	return address, nil
 }
@@ -24,7 +24,8 @@ func Test_Server_healthCheck(t *testing.T) {
 		server := &Server{
 			dialer: dialer,
 			config: settings.Health{
-				TargetAddress: address,
+				TargetAddresses: []string{address},
+				TargetQuorum:    1,
 			},
 		}
 
@@ -50,10 +51,18 @@ func Test_Server_healthCheck(t *testing.T) {
 		listeningAddress := listener.Addr()
 
 		dialer := &net.Dialer{}
+		disabled := false
 		server := &Server{
 			dialer: dialer,
 			config: settings.Health{
-				TargetAddress: listeningAddress.String(),
+				TargetAddresses: []string{listeningAddress.String()},
+				TargetQuorum:    1,
+				IPLeak: settings.HealthIPLeak{
+					PreVPNIP:      &disabled,
+					ServerIP:      &disabled,
+					ServerCountry: &disabled,
+					Provider:      &disabled,
+				},
 			},
 		}
 
@@ -65,6 +74,43 @@ func Test_Server_healthCheck(t *testing.T) {
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("quorum not reached", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp4", "localhost:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			err = listener.Close()
+			assert.NoError(t, err)
+		})
+
+		closedListener, err := net.Listen("tcp4", "localhost:0")
+		require.NoError(t, err)
+		closedAddress := closedListener.Addr().String()
+		err = closedListener.Close()
+		require.NoError(t, err)
+
+		dialer := &net.Dialer{}
+		server := &Server{
+			dialer: dialer,
+			config: settings.Health{
+				TargetAddresses: []string{listener.Addr().String(), closedAddress},
+				TargetQuorum:    2,
+			},
+		}
+
+		const timeout = time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		err = server.healthCheck(ctx)
+
+		require.ErrorIs(t, err, ErrTargetQuorumNotReached)
+		assert.Contains(t, err.Error(), "1 of 2 targets succeeded, quorum is 2")
+		assert.Contains(t, err.Error(), listener.Addr().String()+": ok")
+		assert.Contains(t, err.Error(), closedAddress+": dialing")
+	})
 }
 
 func Test_makeAddressToDial(t *testing.T) {
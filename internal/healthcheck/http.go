@@ -0,0 +1,57 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+)
+
+var (
+	ErrHTTPStatusUnexpected = errors.New("unexpected HTTP status code")
+	ErrHTTPBodyUnexpected   = errors.New("HTTP response body does not contain expected substring")
+)
+
+// checkHTTP sends a GET request to the target URL and checks the
+// response status code and body match the ones expected for the target.
+func (s *Server) checkHTTP(ctx context.Context, target settings.HealthTarget) (err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.Address, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	expectedStatus := int(target.HTTPExpectedStatus)
+	switch {
+	case expectedStatus == 0 && (response.StatusCode < http.StatusOK ||
+		response.StatusCode >= http.StatusMultipleChoices):
+		return fmt.Errorf("%w: %s is not 2xx", ErrHTTPStatusUnexpected, response.Status)
+	case expectedStatus != 0 && response.StatusCode != expectedStatus:
+		return fmt.Errorf("%w: %s instead of %d", ErrHTTPStatusUnexpected,
+			response.Status, expectedStatus)
+	}
+
+	if target.HTTPExpectedBody == "" {
+		return nil
+	}
+
+	const maxBodySize = 1 << 20
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	if !strings.Contains(string(body), target.HTTPExpectedBody) {
+		return fmt.Errorf("%w: %q", ErrHTTPBodyUnexpected, target.HTTPExpectedBody)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Server_checkHTTP(t *testing.T) {
+	t.Parallel()
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/generate_204" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_, _ = w.Write([]byte("connected to the VPN"))
+	}))
+	t.Cleanup(httpServer.Close)
+
+	testCases := map[string]struct {
+		path       string
+		target     settings.HealthTarget
+		errWrapped error
+	}{
+		"any_2xx_status": {
+			path: "/generate_204",
+		},
+		"expected_status": {
+			path:   "/generate_204",
+			target: settings.HealthTarget{HTTPExpectedStatus: http.StatusNoContent},
+		},
+		"unexpected_status": {
+			path:       "/",
+			target:     settings.HealthTarget{HTTPExpectedStatus: http.StatusNoContent},
+			errWrapped: ErrHTTPStatusUnexpected,
+		},
+		"expected_body": {
+			path:   "/",
+			target: settings.HealthTarget{HTTPExpectedBody: "connected"},
+		},
+		"unexpected_body": {
+			path:       "/",
+			target:     settings.HealthTarget{HTTPExpectedBody: "not connected"},
+			errWrapped: ErrHTTPBodyUnexpected,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := &Server{
+				httpClient: httpServer.Client(),
+			}
+			target := testCase.target
+			target.Address = httpServer.URL + testCase.path
+
+			err := server.checkHTTP(context.Background(), target)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/qdm12/gluetun/internal/ping"
+)
+
+var ErrICMPNoAddress = errors.New("no IPv4 address found")
+
+// checkICMP sends an ICMP echo request to the host through the
+// VPN tunnel interface and waits for the matching echo reply.
+func (s *Server) checkICMP(ctx context.Context, host string) (err error) {
+	ip, err := s.resolveIPv4(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	interfaceName := s.vpnInterfaceName()
+	err = ping.ICMP(ctx, ip, interfaceName)
+	if err != nil {
+		return fmt.Errorf("pinging through interface %s: %w", interfaceName, err)
+	}
+	return nil
+}
+
+func (s *Server) resolveIPv4(ctx context.Context, host string) (ip netip.Addr, err error) {
+	ip, err = netip.ParseAddr(host)
+	if err == nil {
+		return ip, nil
+	}
+
+	ips, err := s.dialer.Resolver.LookupNetIP(ctx, "ip4", host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("resolving %s: %w", host, err)
+	} else if len(ips) == 0 {
+		return netip.Addr{}, fmt.Errorf("%w: for %s", ErrICMPNoAddress, host)
+	}
+	return ips[0].Unmap(), nil
+}
@@ -0,0 +1,169 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/provider"
+)
+
+type PublicIPFetcher interface {
+	FetchInfo(ctx context.Context, ip netip.Addr) (
+		result models.PublicIP, err error)
+}
+
+type ipLeakHealth struct {
+	fetcher  PublicIPFetcher
+	preVPNIP netip.Addr
+	// lastCheck is the time of the last public IP address fetch.
+	lastCheck time.Time
+	// lastConnection is the VPN connection during the last check.
+	lastConnection models.Connection
+	// lastErr is the result of the last check.
+	lastErr error
+}
+
+// checkIPLeak checks the public IP address goes through the VPN
+// according to the IP leak criteria enabled. To avoid being rate
+// limited, the public IP address is fetched at most once per period,
+// unless the VPN connection changed, and the last result is returned
+// otherwise. Failing to fetch the public IP address information or to
+// verify it with the VPN provider is logged and the last result is
+// kept, so that only leaks found make the health check fail.
+func (s *Server) checkIPLeak(ctx context.Context) (err error) {
+	if !s.config.IPLeak.Enabled() {
+		return nil
+	}
+
+	connection := s.vpn.loop.GetConnection()
+	now := s.timeNow()
+	connectionChanged := !connection.Equal(s.ipLeak.lastConnection)
+	if !s.ipLeak.lastCheck.IsZero() && !connectionChanged &&
+		now.Sub(s.ipLeak.lastCheck) < *s.config.IPLeak.Period {
+		return s.ipLeak.lastErr
+	}
+
+	if connectionChanged {
+		// the last result is about a previous connection
+		s.ipLeak.lastErr = nil
+	}
+	s.ipLeak.lastCheck = now
+	s.ipLeak.lastConnection = connection
+
+	// The public IP APIs and the VPN provider APIs can be slow,
+	// so they are queried with their own timeout instead of the
+	// short health check timeout.
+	const fetchTimeout = 10 * time.Second
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+	defer fetchCancel()
+
+	leakErr, err := s.findIPLeak(fetchCtx, connection)
+	if err != nil {
+		s.logger.Warn("IP leak check: " + err.Error())
+		return s.ipLeak.lastErr
+	}
+	s.ipLeak.lastErr = leakErr
+	return s.ipLeak.lastErr
+}
+
+// findIPLeak returns a leak error if any of the IP leak criteria enabled
+// is not met, or an error if the public IP address information could not
+// be fetched or verified.
+func (s *Server) findIPLeak(ctx context.Context, connection models.Connection) (
+	leakErr, err error,
+) {
+	settings := s.config.IPLeak
+	if *settings.PreVPNIP || *settings.ServerIP || *settings.ServerCountry {
+		publicIP, err := s.ipLeak.fetcher.FetchInfo(ctx, netip.Addr{})
+		if err != nil {
+			return nil, fmt.Errorf("fetching public IP address: %w", err)
+		}
+		leakErr = checkPublicIP(settings, publicIP, s.ipLeak.preVPNIP,
+			connection, s.serverIPs(connection))
+		if leakErr != nil {
+			return leakErr, nil
+		}
+	}
+
+	if *settings.Provider {
+		return s.verifyWithProvider(ctx)
+	}
+	return nil, nil
+}
+
+var ErrProviderNotConfirmed = errors.New("VPN provider does not confirm the connection")
+
+// verifyWithProvider returns a leak error if the VPN provider does
+// not confirm the traffic goes through its VPN, or an error if the
+// verification failed. Providers not supporting the verification
+// are ignored.
+func (s *Server) verifyWithProvider(ctx context.Context) (leakErr, err error) {
+	verification, err := s.vpn.loop.VerifyConnection(ctx)
+	switch {
+	case errors.Is(err, provider.ErrVerificationNotSupported):
+		s.logger.Debug(err.Error())
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case !verification.Connected:
+		return fmt.Errorf("%w: public IP address %s", ErrProviderNotConfirmed,
+			verification.PublicIP), nil
+	}
+	return nil, nil
+}
+
+// serverIPs returns the IP addresses of the VPN server of the connection,
+// or nil if they are not known, for example for the custom provider.
+func (s *Server) serverIPs(connection models.Connection) (ips []netip.Addr) {
+	if !*s.config.IPLeak.ServerIP {
+		return nil
+	}
+
+	details, err := s.vpn.loop.GetConnectionDetails()
+	if err != nil || details.Server == nil || len(details.Server.IPs) == 0 {
+		return nil
+	}
+	return append([]netip.Addr{connection.IP}, details.Server.IPs...)
+}
+
+var (
+	ErrPublicIPIsPreVPNIP         = errors.New("public IP address is the pre-VPN public IP address")
+	ErrPublicIPNotServerIP        = errors.New("public IP address is not a VPN server IP address")
+	ErrPublicIPNotServerCountry   = errors.New("public IP address country is not the VPN server country")
+	ErrPublicIPVPNConnectionUnset = errors.New("VPN connection is not set")
+)
+
+// checkPublicIP returns an error if the public IP address does not meet
+// the criteria enabled. The server IP addresses criterion is skipped if
+// serverIPs is empty, since the server IP addresses are not known.
+func checkPublicIP(settings settings.HealthIPLeak, publicIP models.PublicIP,
+	preVPNIP netip.Addr, connection models.Connection, serverIPs []netip.Addr,
+) (err error) {
+	if *settings.PreVPNIP && preVPNIP.IsValid() && publicIP.IP == preVPNIP {
+		return fmt.Errorf("%w", ErrPublicIPIsPreVPNIP)
+	}
+
+	if (*settings.ServerIP || *settings.ServerCountry) && !connection.IP.IsValid() {
+		return fmt.Errorf("%w", ErrPublicIPVPNConnectionUnset)
+	}
+
+	if *settings.ServerIP && len(serverIPs) > 0 &&
+		!slices.Contains(serverIPs, publicIP.IP) {
+		return fmt.Errorf("%w: %s", ErrPublicIPNotServerIP, publicIP.IP)
+	}
+
+	if *settings.ServerCountry && connection.Country != "" &&
+		!strings.EqualFold(publicIP.Country, connection.Country) {
+		return fmt.Errorf("%w: %s is not %s", ErrPublicIPNotServerCountry,
+			publicIP.Country, connection.Country)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkPublicIP(t *testing.T) {
+	t.Parallel()
+
+	makeSettings := func(preVPNIP, serverIP, serverCountry bool) settings.HealthIPLeak {
+		return settings.HealthIPLeak{
+			PreVPNIP:      &preVPNIP,
+			ServerIP:      &serverIP,
+			ServerCountry: &serverCountry,
+		}
+	}
+	preVPNIP := netip.MustParseAddr("1.2.3.4")
+	connection := models.Connection{
+		IP:      netip.MustParseAddr("5.6.7.8"),
+		Country: "Netherlands",
+	}
+
+	testCases := map[string]struct {
+		settings   settings.HealthIPLeak
+		publicIP   models.PublicIP
+		preVPNIP   netip.Addr
+		connection models.Connection
+		serverIPs  []netip.Addr
+		errWrapped error
+		errMessage string
+	}{
+		"all_criteria_passing": {
+			settings:   makeSettings(true, true, true),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9"), Country: "netherlands"},
+			preVPNIP:   preVPNIP,
+			connection: connection,
+			serverIPs:  []netip.Addr{connection.IP, netip.MustParseAddr("9.9.9.9")},
+		},
+		"pre_vpn_ip": {
+			settings:   makeSettings(true, false, false),
+			publicIP:   models.PublicIP{IP: preVPNIP},
+			preVPNIP:   preVPNIP,
+			errWrapped: ErrPublicIPIsPreVPNIP,
+			errMessage: "public IP address is the pre-VPN public IP address",
+		},
+		"pre_vpn_ip_unknown": {
+			settings: makeSettings(true, false, false),
+			publicIP: models.PublicIP{IP: preVPNIP},
+		},
+		"connection_unset": {
+			settings:   makeSettings(false, false, true),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9")},
+			errWrapped: ErrPublicIPVPNConnectionUnset,
+			errMessage: "VPN connection is not set",
+		},
+		"server_ip_mismatch": {
+			settings:   makeSettings(false, true, false),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9")},
+			connection: connection,
+			serverIPs:  []netip.Addr{connection.IP},
+			errWrapped: ErrPublicIPNotServerIP,
+			errMessage: "public IP address is not a VPN server IP address: 9.9.9.9",
+		},
+		"server_ips_unknown": {
+			settings:   makeSettings(false, true, false),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9")},
+			connection: connection,
+		},
+		"server_country_mismatch": {
+			settings:   makeSettings(false, false, true),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9"), Country: "Germany"},
+			connection: connection,
+			errWrapped: ErrPublicIPNotServerCountry,
+			errMessage: "public IP address country is not the VPN server country: Germany is not Netherlands",
+		},
+		"server_country_unknown": {
+			settings:   makeSettings(false, false, true),
+			publicIP:   models.PublicIP{IP: netip.MustParseAddr("9.9.9.9"), Country: "Germany"},
+			connection: models.Connection{IP: connection.IP},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkPublicIP(testCase.settings, testCase.publicIP,
+				testCase.preVPNIP, testCase.connection, testCase.serverIPs)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
+
+type fakePublicIPFetcher struct {
+	result models.PublicIP
+	err    error
+}
+
+func (f *fakePublicIPFetcher) FetchInfo(context.Context, netip.Addr) (
+	result models.PublicIP, err error,
+) {
+	return f.result, f.err
+}
+
+type fakeVPNLoop struct {
+	VPNLoop
+	connection models.Connection
+}
+
+func (f *fakeVPNLoop) GetConnection() models.Connection { return f.connection }
+
+type fakeLogger struct {
+	Logger
+	warns []string
+}
+
+func (f *fakeLogger) Warn(s string) { f.warns = append(f.warns, s) }
+
+func Test_Server_checkIPLeak(t *testing.T) {
+	t.Parallel()
+
+	enabled, disabled := true, false
+	period := time.Minute
+	preVPNIP := netip.MustParseAddr("1.2.3.4")
+	fetcher := &fakePublicIPFetcher{result: models.PublicIP{IP: preVPNIP}}
+	logger := &fakeLogger{}
+	now := time.Unix(0, 0)
+	server := &Server{
+		logger: logger,
+		config: settings.Health{
+			IPLeak: settings.HealthIPLeak{
+				PreVPNIP:      &enabled,
+				ServerIP:      &disabled,
+				ServerCountry: &disabled,
+				Provider:      &disabled,
+				Period:        &period,
+			},
+		},
+		vpn: vpnHealth{
+			loop: &fakeVPNLoop{connection: models.Connection{IP: netip.MustParseAddr("5.6.7.8")}},
+		},
+		ipLeak: ipLeakHealth{
+			fetcher:  fetcher,
+			preVPNIP: preVPNIP,
+		},
+		timeNow: func() time.Time { return now },
+	}
+
+	err := server.checkIPLeak(context.Background())
+	assert.ErrorIs(t, err, ErrPublicIPIsPreVPNIP)
+
+	// A fetch failure is logged and keeps the last result.
+	now = now.Add(period)
+	fetcher.err = errors.New("rate limited")
+	err = server.checkIPLeak(context.Background())
+	assert.ErrorIs(t, err, ErrPublicIPIsPreVPNIP)
+	assert.Equal(t, []string{"IP leak check: fetching public IP address: rate limited"}, logger.warns)
+
+	// A fetch failure for a new connection does not
+	// keep the result of the previous connection.
+	server.vpn.loop = &fakeVPNLoop{connection: models.Connection{IP: netip.MustParseAddr("9.9.9.9")}}
+	err = server.checkIPLeak(context.Background())
+	assert.NoError(t, err)
+}
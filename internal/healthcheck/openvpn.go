@@ -8,7 +8,7 @@ import (
 )
 
 type vpnHealth struct {
-	loop         StatusApplier
+	loop         VPNLoop
 	healthyWait  time.Duration
 	healthyTimer *time.Timer
 }
@@ -18,6 +18,7 @@ func (s *Server) onUnhealthyVPN(ctx context.Context, lastErrMessage string) {
 		s.vpn.healthyWait.String() + ": restarting VPN (healthcheck error: " + lastErrMessage + ")")
 	s.logger.Info("👉 See https://github.com/qdm12/gluetun-wiki/blob/main/faq/healthcheck.md")
 	s.logger.Info("DO NOT OPEN AN ISSUE UNLESS YOU READ AND TRIED EACH POSSIBLE SOLUTION")
+	s.vpn.loop.MarkConnectionFailed("healthcheck: " + lastErrMessage)
 	_, _ = s.vpn.loop.ApplyStatus(ctx, constants.Stopped)
 	_, _ = s.vpn.loop.ApplyStatus(ctx, constants.Running)
 	s.vpn.healthyWait += *s.config.VPN.Addition
@@ -3,28 +3,43 @@ package healthcheck
 import (
 	"context"
 	"net"
+	"net/http"
+	"net/netip"
+	"time"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/constants/vpn"
 	"github.com/qdm12/gluetun/internal/models"
 )
 
 type Server struct {
-	logger  Logger
-	handler *handler
-	dialer  *net.Dialer
-	config  settings.Health
-	vpn     vpnHealth
+	logger     Logger
+	handler    *handler
+	dialer     *net.Dialer
+	httpClient *http.Client
+	config     settings.Health
+	vpn        vpnHealth
+	ipLeak     ipLeakHealth
+	timeNow    func() time.Time
 }
 
 func NewServer(config settings.Health,
-	logger Logger, vpnLoop StatusApplier,
+	logger Logger, vpnLoop VPNLoop, publicIPFetcher PublicIPFetcher,
+	preVPNPublicIP netip.Addr,
 ) *Server {
+	dialer := &net.Dialer{
+		Resolver: &net.Resolver{
+			PreferGo: true,
+		},
+	}
 	return &Server{
 		logger:  logger,
 		handler: newHandler(),
-		dialer: &net.Dialer{
-			Resolver: &net.Resolver{
-				PreferGo: true,
+		dialer:  dialer,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext:       dialer.DialContext,
+				DisableKeepAlives: true,
 			},
 		},
 		config: config,
@@ -32,10 +47,32 @@ func NewServer(config settings.Health,
 			loop:        vpnLoop,
 			healthyWait: *config.VPN.Initial,
 		},
+		ipLeak: ipLeakHealth{
+			fetcher:  publicIPFetcher,
+			preVPNIP: preVPNPublicIP,
+		},
+		timeNow: time.Now,
 	}
 }
 
-type StatusApplier interface {
+type VPNLoop interface {
 	ApplyStatus(ctx context.Context, status models.LoopStatus) (
 		outcome string, err error)
+	GetActiveSettings() (settings settings.VPN)
+	GetConnection() (connection models.Connection)
+	GetConnectionDetails() (details models.ConnectionDetails, err error)
+	VerifyConnection(ctx context.Context) (
+		verification models.ConnectionVerification, err error)
+	MarkConnectionFailed(reason string)
+	MarkConnectionHealthy()
+}
+
+// vpnInterfaceName returns the network interface name
+// of the VPN tunnel.
+func (s *Server) vpnInterfaceName() string {
+	vpnSettings := s.vpn.loop.GetActiveSettings()
+	if vpnSettings.Type == vpn.Wireguard {
+		return vpnSettings.Wireguard.Interface
+	}
+	return vpnSettings.OpenVPN.Interface
 }
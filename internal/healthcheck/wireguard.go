@@ -0,0 +1,52 @@
+package healthcheck
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"golang.zx2c4.com/wireguard/wgctrl"
+)
+
+var (
+	ErrWireguardNotUsed        = errors.New("VPN type is not Wireguard")
+	ErrWireguardNoHandshake    = errors.New("no Wireguard handshake done")
+	ErrWireguardHandshakeStale = errors.New("last Wireguard handshake is too old")
+)
+
+// checkWireguard checks the most recent handshake of the Wireguard
+// tunnel interface peers is more recent than the maximum age set.
+func (s *Server) checkWireguard() (err error) {
+	vpnSettings := s.vpn.loop.GetActiveSettings()
+	if vpnSettings.Type != vpn.Wireguard {
+		return fmt.Errorf("%w: %s", ErrWireguardNotUsed, vpnSettings.Type)
+	}
+
+	client, err := wgctrl.New()
+	if err != nil {
+		return fmt.Errorf("creating Wireguard client: %w", err)
+	}
+	defer client.Close()
+
+	device, err := client.Device(vpnSettings.Wireguard.Interface)
+	if err != nil {
+		return fmt.Errorf("getting Wireguard device: %w", err)
+	}
+
+	var lastHandshake time.Time
+	for _, peer := range device.Peers {
+		if peer.LastHandshakeTime.After(lastHandshake) {
+			lastHandshake = peer.LastHandshakeTime
+		}
+	}
+
+	switch {
+	case lastHandshake.IsZero():
+		return fmt.Errorf("%w", ErrWireguardNoHandshake)
+	case time.Since(lastHandshake) > s.config.WireguardHandshakeMaxAge:
+		return fmt.Errorf("%w: %s ago exceeds %s", ErrWireguardHandshakeStale,
+			time.Since(lastHandshake).Round(time.Second), s.config.WireguardHandshakeMaxAge)
+	}
+	return nil
+}
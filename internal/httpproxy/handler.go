@@ -4,11 +4,13 @@ import (
 	"context"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 func newHandler(ctx context.Context, wg *sync.WaitGroup, logger Logger,
 	stealth, verbose bool, username, password string,
+	activeConnections *atomic.Int64,
 ) http.Handler {
 	const httpTimeout = 24 * time.Hour
 	return &handler{
@@ -23,6 +25,8 @@ func newHandler(ctx context.Context, wg *sync.WaitGroup, logger Logger,
 		stealth:  stealth,
 		username: username,
 		password: password,
+
+		activeConnections: activeConnections,
 	}
 }
 
@@ -33,6 +37,9 @@ type handler struct {
 	logger             Logger
 	verbose, stealth   bool
 	username, password string
+	// activeConnections is the number of requests
+	// and HTTPS tunnels currently being proxied.
+	activeConnections *atomic.Int64
 }
 
 func (h *handler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
@@ -42,6 +49,9 @@ func (h *handler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Re
 	if !h.isAuthorized(responseWriter, request) {
 		return
 	}
+	h.activeConnections.Add(1)
+	defer h.activeConnections.Add(-1)
+
 	request.Header.Del("Proxy-Connection")
 	request.Header.Del("Proxy-Authenticate")
 	request.Header.Del("Proxy-Authorization")
@@ -2,6 +2,7 @@ package httpproxy
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
@@ -22,6 +23,9 @@ type Loop struct {
 	start         chan struct{}
 	userTrigger   bool
 	backoffTime   time.Duration
+	// activeConnections is shared with the servers
+	// created by the loop, see ActiveConnections.
+	activeConnections *atomic.Int64
 }
 
 const defaultBackoffTime = 10 * time.Second
@@ -46,6 +50,8 @@ func NewLoop(logger Logger, settings settings.HTTPProxy) *Loop {
 		stopped:       stopped,
 		userTrigger:   true,
 		backoffTime:   defaultBackoffTime,
+
+		activeConnections: new(atomic.Int64),
 	}
 }
 
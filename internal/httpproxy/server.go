@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,11 +20,14 @@ type Server struct {
 func New(ctx context.Context, address string, logger Logger,
 	stealth, verbose bool, username, password string,
 	readHeaderTimeout, readTimeout time.Duration,
+	activeConnections *atomic.Int64,
 ) *Server {
 	wg := &sync.WaitGroup{}
+	handler := newHandler(ctx, wg, logger, stealth, verbose, username, password,
+		activeConnections)
 	return &Server{
 		address:           address,
-		handler:           newHandler(ctx, wg, logger, stealth, verbose, username, password),
+		handler:           handler,
 		logger:            logger,
 		internalWG:        wg,
 		readHeaderTimeout: readHeaderTimeout,
@@ -15,3 +15,9 @@ func (l *Loop) ApplyStatus(ctx context.Context, status models.LoopStatus) (
 ) {
 	return l.statusManager.ApplyStatus(ctx, status)
 }
+
+// ActiveConnections returns the number of requests and
+// HTTPS tunnels currently being proxied.
+func (l *Loop) ActiveConnections() (count int) {
+	return int(l.activeConnections.Load())
+}
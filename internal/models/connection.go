@@ -2,6 +2,7 @@ package models
 
 import (
 	"net/netip"
+	"time"
 )
 
 type Connection struct {
@@ -23,6 +24,22 @@ type Connection struct {
 	ServerName string `json:"server_name,omitempty"`
 	// PortForward is used for PIA and ProtonVPN for port forwarding
 	PortForward bool `json:"port_forward"`
+	// Country is the VPN server country, and is empty
+	// if the server country is not known.
+	Country string `json:"country,omitempty"`
+}
+
+// ConnectionDetails contains details on the current VPN connection.
+type ConnectionDetails struct {
+	Connection Connection `json:"connection"`
+	// Server is the server of the connection, and is nil
+	// if it is not found, for example for the custom provider.
+	Server *Server `json:"server,omitempty"`
+	// Interface is the VPN network interface name.
+	Interface string `json:"interface"`
+	// TunnelIPs are the IP addresses assigned
+	// to the VPN network interface.
+	TunnelIPs []netip.Addr `json:"tunnel_ips"`
 }
 
 func (c *Connection) Equal(other Connection) bool {
@@ -45,3 +62,17 @@ func (c *Connection) UpdateEmptyWith(ip netip.Addr, port uint16, protocol string
 		c.Protocol = protocol
 	}
 }
+
+// FailedConnection is a VPN server connection which failed
+// recently, and is excluded from the server selection until
+// its cooldown expires.
+type FailedConnection struct {
+	// IP is the VPN server IP address.
+	IP netip.Addr `json:"ip"`
+	// Hostname is the VPN server hostname, if any.
+	Hostname string `json:"hostname,omitempty"`
+	// Reason is the reason the connection failed.
+	Reason string `json:"reason"`
+	// Until is the time the connection stops being excluded.
+	Until time.Time `json:"until"`
+}
@@ -36,6 +36,9 @@ type Server struct {
 	PortForward bool         `json:"port_forward,omitempty"`
 	Keep        bool         `json:"keep,omitempty"`
 	IPs         []netip.Addr `json:"ips,omitempty"`
+	// Load is the server load percentage published by the
+	// VPN provider, and is nil if the provider does not publish it.
+	Load *uint8 `json:"load,omitempty"`
 }
 
 var (
@@ -72,6 +75,9 @@ func (s *Server) Equal(other Server) (equal bool) {
 	serverCopy := *s
 	serverCopy.IPs = nil
 	other.IPs = nil
+	// Ignore the load which changes all the time.
+	serverCopy.Load = nil
+	other.Load = nil
 	return reflect.DeepEqual(serverCopy, other)
 }
 
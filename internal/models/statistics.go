@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// TunnelStatistics contains traffic statistics of the VPN tunnel.
+type TunnelStatistics struct {
+	// ConnectedSince is the time the current connection tunnel
+	// went up, and is nil if the VPN tunnel is not up.
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	// UptimeSeconds is the number of seconds since
+	// the current connection tunnel went up.
+	UptimeSeconds uint64 `json:"uptime_seconds"`
+	// Connection contains the traffic counters
+	// since the current connection tunnel went up.
+	Connection TrafficCounters `json:"connection"`
+	// Process contains the traffic counters of all
+	// the connections since the program started.
+	Process TrafficCounters `json:"process"`
+	// Wireguard contains statistics specific to Wireguard,
+	// and is nil if the current connection is not Wireguard.
+	Wireguard *WireguardStatistics `json:"wireguard,omitempty"`
+}
+
+// TrafficCounters contains received (rx) and
+// transmitted (tx) bytes and packets counters.
+type TrafficCounters struct {
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	TxPackets uint64 `json:"tx_packets"`
+}
+
+// WireguardStatistics contains the Wireguard peers statistics.
+type WireguardStatistics struct {
+	// LastHandshake is the most recent handshake time
+	// of the peers, and is nil if no handshake happened.
+	LastHandshake *time.Time `json:"last_handshake,omitempty"`
+	// RxBytes is the number of bytes received from the peers.
+	RxBytes uint64 `json:"rx_bytes"`
+	// TxBytes is the number of bytes transmitted to the peers.
+	TxBytes uint64 `json:"tx_bytes"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"net/netip"
+)
+
+// ConnectionVerification is the result of the VPN provider
+// verifying the traffic goes through its VPN.
+type ConnectionVerification struct {
+	// Provider is the VPN provider name.
+	Provider string `json:"provider"`
+	// Connected is true if the VPN provider confirms
+	// the traffic goes through its VPN.
+	Connected bool `json:"connected"`
+	// PublicIP is the public IP address seen by the provider.
+	PublicIP netip.Addr `json:"public_ip,omitempty"`
+	// Server is the VPN server name reported by the provider,
+	// and is empty if the provider does not report it.
+	Server string `json:"server,omitempty"`
+}
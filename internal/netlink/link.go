@@ -36,6 +36,26 @@ func (n *NetLink) LinkByIndex(index int) (link Link, err error) {
 	return netlinkLinkToLink(netlinkLink), nil
 }
 
+// LinkStatistics returns the traffic counters of the link with
+// the name given, which are zero if the kernel does not report them.
+func (n *NetLink) LinkStatistics(name string) (statistics LinkStatistics, err error) {
+	netlinkLink, err := netlink.LinkByName(name)
+	if err != nil {
+		return LinkStatistics{}, err
+	}
+
+	netlinkStatistics := netlinkLink.Attrs().Statistics
+	if netlinkStatistics == nil {
+		return LinkStatistics{}, nil
+	}
+	return LinkStatistics{
+		RxBytes:   netlinkStatistics.RxBytes,
+		TxBytes:   netlinkStatistics.TxBytes,
+		RxPackets: netlinkStatistics.RxPackets,
+		TxPackets: netlinkStatistics.TxPackets,
+	}, nil
+}
+
 func (n *NetLink) LinkAdd(link Link) (linkIndex int, err error) {
 	netlinkLink := linkToNetlinkLink(&link)
 	err = netlink.LinkAdd(netlinkLink)
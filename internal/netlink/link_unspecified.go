@@ -14,6 +14,10 @@ func (n *NetLink) LinkByIndex(index int) (link Link, err error) {
 	panic("not implemented")
 }
 
+func (n *NetLink) LinkStatistics(name string) (statistics LinkStatistics, err error) {
+	panic("not implemented")
+}
+
 func (n *NetLink) LinkAdd(link Link) (linkIndex int, err error) {
 	panic("not implemented")
 }
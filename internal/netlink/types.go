@@ -21,6 +21,14 @@ type Link struct {
 	MTU       uint16
 }
 
+// LinkStatistics contains the traffic counters of a link.
+type LinkStatistics struct {
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+}
+
 type Route struct {
 	LinkIndex int
 	Dst       netip.Prefix
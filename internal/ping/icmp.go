@@ -0,0 +1,103 @@
+// Package ping sends ICMP echo requests to IPv4 addresses.
+package ping
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+var ErrIPNotIPv4 = errors.New("IP address is not IPv4")
+
+// ICMP sends an ICMP echo request to the IPv4 address given and
+// waits for the matching echo reply. If interfaceName is not empty,
+// the echo request is sent through this network interface.
+func ICMP(ctx context.Context, ip netip.Addr, interfaceName string) (err error) {
+	if !ip.Is4() {
+		return fmt.Errorf("%w: %s", ErrIPNotIPv4, ip)
+	}
+
+	var listenConfig net.ListenConfig
+	if interfaceName != "" {
+		listenConfig.Control = func(_, _ string, rawConn syscall.RawConn) error {
+			var setErr error
+			err := rawConn.Control(func(fd uintptr) {
+				setErr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET,
+					unix.SO_BINDTODEVICE, interfaceName)
+			})
+			if err != nil {
+				return err
+			}
+			return setErr
+		}
+	}
+	packetConn, err := listenConfig.ListenPacket(ctx, "ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return fmt.Errorf("listening: %w", err)
+	}
+	defer packetConn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = packetConn.Close() })
+	defer stop()
+
+	const idAndSeqSize = 4
+	idAndSeq := make([]byte, idAndSeqSize)
+	_, _ = rand.Read(idAndSeq)
+	echo := &icmp.Echo{
+		ID:   int(binary.BigEndian.Uint16(idAndSeq[:2])),
+		Seq:  int(binary.BigEndian.Uint16(idAndSeq[2:])),
+		Data: []byte("gluetun"),
+	}
+	request, err := (&icmp.Message{Type: ipv4.ICMPTypeEcho, Body: echo}).Marshal(nil)
+	if err != nil {
+		return fmt.Errorf("encoding echo request: %w", err)
+	}
+
+	_, err = packetConn.WriteTo(request, &net.IPAddr{IP: ip.AsSlice()})
+	if err != nil {
+		return fmt.Errorf("sending echo request: %w", wrapCtxErr(ctx, err))
+	}
+
+	const protocolICMP = 1
+	const maxPacketSize = 1500
+	buffer := make([]byte, maxPacketSize)
+	for {
+		n, from, err := packetConn.ReadFrom(buffer)
+		if err != nil {
+			return fmt.Errorf("receiving echo reply: %w", wrapCtxErr(ctx, err))
+		}
+
+		fromIP, ok := from.(*net.IPAddr)
+		if !ok || !fromIP.IP.Equal(ip.AsSlice()) {
+			continue
+		}
+
+		message, err := icmp.ParseMessage(protocolICMP, buffer[:n])
+		if err != nil || message.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		reply, ok := message.Body.(*icmp.Echo)
+		if ok && reply.ID == echo.ID && reply.Seq == echo.Seq {
+			return nil
+		}
+	}
+}
+
+// wrapCtxErr returns the context error if the context is done,
+// since closing the connection on context cancellation results
+// in a less meaningful error.
+func wrapCtxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -10,18 +10,20 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	client *http.Client,
 ) *Provider {
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(client),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		Fetcher:        updater.New(client),
 	}
 }
 
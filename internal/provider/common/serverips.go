@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// ServerIPs caches the VPN server IP addresses of a provider, to
+// check if a public IP address belongs to one of its VPN servers.
+type ServerIPs struct {
+	fetch            func(ctx context.Context) (ipToServer map[netip.Addr]string, err error)
+	minRefreshPeriod time.Duration
+	timeNow          func() time.Time
+
+	mutex      sync.Mutex
+	ipToServer map[netip.Addr]string
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// NewServerIPs creates a server IP addresses cache using the fetch
+// function given to fetch a map of server IP address to server name.
+// The server IP addresses are fetched again at most once per refresh
+// period given.
+func NewServerIPs(fetch func(ctx context.Context) (ipToServer map[netip.Addr]string, err error),
+	minRefreshPeriod time.Duration,
+) *ServerIPs {
+	return &ServerIPs{
+		fetch:            fetch,
+		minRefreshPeriod: minRefreshPeriod,
+		timeNow:          time.Now,
+	}
+}
+
+// Refresh fetches the server IP addresses if they were never fetched,
+// or if they were fetched more than the refresh period ago. It does
+// nothing if a refresh is already in progress.
+func (s *ServerIPs) Refresh(ctx context.Context) (err error) {
+	s.mutex.Lock()
+	upToDate := !s.fetchedAt.IsZero() && s.timeNow().Sub(s.fetchedAt) < s.minRefreshPeriod
+	if s.refreshing || upToDate {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.refreshing = true
+	s.mutex.Unlock()
+
+	ipToServer, err := s.fetch(ctx)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.refreshing = false
+	if err != nil {
+		return err
+	}
+	s.ipToServer = ipToServer
+	s.fetchedAt = s.timeNow()
+	return nil
+}
+
+var (
+	ErrServerIPsNotFetched = errors.New("server IP addresses are not fetched yet")
+	ErrServerIPsOutdated   = errors.New("server IP addresses are outdated")
+)
+
+// Lookup returns the server name of the IP address given, and whether
+// the IP address is a VPN server IP address. It never fetches the server
+// IP addresses itself: if the IP address is not found and the server IP
+// addresses are not fetched yet or are outdated, a refresh is started in
+// the background and an error is returned, since the IP address could
+// belong to a server missing from the cache.
+func (s *ServerIPs) Lookup(ip netip.Addr) (server string, found bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	server, found = s.ipToServer[ip]
+	switch {
+	case found:
+		return server, true, nil
+	case s.fetchedAt.IsZero():
+		err = fmt.Errorf("%w", ErrServerIPsNotFetched)
+	case s.timeNow().Sub(s.fetchedAt) >= s.minRefreshPeriod:
+		err = fmt.Errorf("%w", ErrServerIPsOutdated)
+	default:
+		return "", false, nil
+	}
+
+	if !s.refreshing {
+		go s.refreshInBackground()
+	}
+	return "", false, err
+}
+
+func (s *ServerIPs) refreshInBackground() {
+	const timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// The error is ignored since the next lookup triggers a new refresh.
+	_ = s.Refresh(ctx)
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ServerIPs(t *testing.T) {
+	t.Parallel()
+
+	serverIP := netip.MustParseAddr("1.2.3.4")
+	otherIP := netip.MustParseAddr("5.6.7.8")
+	fetched := make(chan struct{}, 1)
+	fetchErr := error(nil)
+	fetch := func(context.Context) (map[netip.Addr]string, error) {
+		defer func() { fetched <- struct{}{} }()
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return map[netip.Addr]string{serverIP: "server"}, nil
+	}
+
+	const refreshPeriod = time.Hour
+	serverIPs := NewServerIPs(fetch, refreshPeriod)
+	now := time.Unix(0, 0)
+	serverIPs.timeNow = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Lookup before any fetch: background refresh
+	_, found, err := serverIPs.Lookup(serverIP)
+	assert.ErrorIs(t, err, ErrServerIPsNotFetched)
+	assert.False(t, found)
+	<-fetched
+	require.Eventually(t, func() bool {
+		_, found, _ := serverIPs.Lookup(serverIP)
+		return found
+	}, time.Second, time.Millisecond)
+
+	// Refresh within the refresh period: no fetch
+	err = serverIPs.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, fetched)
+
+	// IP address not found within the refresh period
+	_, found, err = serverIPs.Lookup(otherIP)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, fetched)
+
+	// IP address not found after the refresh period: fetch failing
+	now = now.Add(refreshPeriod)
+	fetchErr = errors.New("test error")
+	err = serverIPs.Refresh(ctx)
+	assert.ErrorIs(t, err, fetchErr)
+	<-fetched
+	_, found, err = serverIPs.Lookup(otherIP)
+	assert.ErrorIs(t, err, ErrServerIPsOutdated)
+	assert.False(t, found)
+	<-fetched
+
+	// Cached IP address still found
+	server, found, err := serverIPs.Lookup(serverIP)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "server", server)
+}
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"context"
+
 	"github.com/qdm12/gluetun/internal/configuration/settings"
 	"github.com/qdm12/gluetun/internal/models"
 )
@@ -9,3 +11,8 @@ type Storage interface {
 	FilterServers(provider string, selection settings.ServerSelection) (
 		servers []models.Server, err error)
 }
+
+type StrategyPicker interface {
+	PickConnection(ctx context.Context, connections []models.Connection,
+		selection settings.ServerSelection) (connection models.Connection, err error)
+}
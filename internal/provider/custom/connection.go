@@ -1,6 +1,7 @@
 package custom
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -13,7 +14,8 @@ import (
 var ErrVPNTypeNotSupported = errors.New("VPN type not supported for custom provider")
 
 // GetConnection gets the connection from the OpenVPN configuration file.
-func (p *Provider) GetConnection(selection settings.ServerSelection, _ bool) (
+func (p *Provider) GetConnection(_ context.Context,
+	selection settings.ServerSelection, _ bool) (
 	connection models.Connection, err error,
 ) {
 	switch selection.VPN {
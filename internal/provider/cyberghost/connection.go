@@ -1,15 +1,18 @@
 package cyberghost
 
 import (
+	"context"
+
 	"github.com/qdm12/gluetun/internal/configuration/settings"
 	"github.com/qdm12/gluetun/internal/models"
 	"github.com/qdm12/gluetun/internal/provider/utils"
 )
 
-func (p *Provider) GetConnection(selection settings.ServerSelection, ipv6Supported bool) (
+func (p *Provider) GetConnection(ctx context.Context,
+	selection settings.ServerSelection, ipv6Supported bool) (
 	connection models.Connection, err error,
 ) {
 	defaults := utils.NewConnectionDefaults(443, 443, 0) //nolint:mnd
-	return utils.GetConnection(p.Name(),
-		p.storage, selection, defaults, ipv6Supported, p.randSource)
+	return utils.GetConnection(ctx, p.Name(),
+		p.storage, selection, defaults, ipv6Supported, p.randSource, p.strategyPicker)
 }
@@ -10,20 +10,22 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
 	common.Fetcher
 }
 
 // TODO: remove unneeded arguments once the updater is implemented.
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	updaterWarner common.Warner, client *http.Client,
 	unzipper common.Unzipper, parallelResolver common.ParallelResolver,
 ) *Provider {
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(updaterWarner, unzipper, client, parallelResolver),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		Fetcher:        updater.New(updaterWarner, unzipper, client, parallelResolver),
 	}
 }
 
@@ -1,6 +1,7 @@
 package expressvpn
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"net/netip"
@@ -89,16 +90,16 @@ func Test_Provider_GetConnection(t *testing.T) {
 			unzipper := (common.Unzipper)(nil)
 			warner := (common.Warner)(nil)
 			parallelResolver := (common.ParallelResolver)(nil)
-			provider := New(storage, randSource, unzipper, warner, parallelResolver)
+			provider := New(storage, randSource, nil, unzipper, warner, parallelResolver)
 
 			if testCase.panicMessage != "" {
 				assert.PanicsWithValue(t, testCase.panicMessage, func() {
-					_, _ = provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+					_, _ = provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 				})
 				return
 			}
 
-			connection, err := provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+			connection, err := provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 
 			assert.ErrorIs(t, err, testCase.errWrapped)
 			if testCase.errWrapped != nil {
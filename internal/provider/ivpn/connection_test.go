@@ -1,6 +1,7 @@
 package ivpn
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"net/http"
@@ -100,9 +101,9 @@ func Test_Provider_GetConnection(t *testing.T) {
 			client := (*http.Client)(nil)
 			warner := (common.Warner)(nil)
 			parallelResolver := (common.ParallelResolver)(nil)
-			provider := New(storage, randSource, client, warner, parallelResolver)
+			provider := New(storage, randSource, nil, client, warner, parallelResolver)
 
-			connection, err := provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+			connection, err := provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 
 			assert.ErrorIs(t, err, testCase.errWrapped)
 			if testCase.errWrapped != nil {
@@ -1,6 +1,7 @@
 package mullvad
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"net/http"
@@ -98,9 +99,9 @@ func Test_Provider_GetConnection(t *testing.T) {
 			randSource := rand.NewSource(0)
 
 			client := (*http.Client)(nil)
-			provider := New(storage, randSource, client)
+			provider := New(storage, randSource, nil, client)
 
-			connection, err := provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+			connection, err := provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 
 			assert.ErrorIs(t, err, testCase.errWrapped)
 			if testCase.errWrapped != nil {
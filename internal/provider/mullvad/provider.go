@@ -10,18 +10,22 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
+	client         *http.Client
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	client *http.Client,
 ) *Provider {
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(client),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		client:         client,
+		Fetcher:        updater.New(client),
 	}
 }
 
@@ -0,0 +1,56 @@
+package mullvad
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/qdm12/gluetun/internal/constants/providers"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+var ErrHTTPStatusCodeNotOK = errors.New("HTTP status code not OK")
+
+// VerifyConnection uses the Mullvad connection check API to
+// verify the traffic goes through a Mullvad VPN server.
+func (p *Provider) VerifyConnection(ctx context.Context) (
+	verification models.ConnectionVerification, err error,
+) {
+	const url = "https://am.i.mullvad.net/json"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return verification, fmt.Errorf("creating request: %w", err)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return verification, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return verification, fmt.Errorf("%w: %d %s", ErrHTTPStatusCodeNotOK,
+			response.StatusCode, response.Status)
+	}
+
+	var data struct {
+		IP             netip.Addr `json:"ip"`
+		ExitIP         bool       `json:"mullvad_exit_ip"`
+		ExitIPHostname string     `json:"mullvad_exit_ip_hostname"`
+	}
+	decoder := json.NewDecoder(response.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		return verification, fmt.Errorf("decoding response body: %w", err)
+	}
+
+	return models.ConnectionVerification{
+		Provider:  providers.Mullvad,
+		Connected: data.ExitIP,
+		PublicIP:  data.IP,
+		Server:    data.ExitIPHostname,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package mullvad
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (s roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return s(r)
+}
+
+func Test_Provider_VerifyConnection(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		statusCode   int
+		body         string
+		verification models.ConnectionVerification
+		errMessage   string
+	}{
+		"connected": {
+			statusCode: http.StatusOK,
+			body: `{"ip":"1.2.3.4","mullvad_exit_ip":true,` +
+				`"mullvad_exit_ip_hostname":"nl-ams-wg-001"}`,
+			verification: models.ConnectionVerification{
+				Provider:  "mullvad",
+				Connected: true,
+				PublicIP:  netip.MustParseAddr("1.2.3.4"),
+				Server:    "nl-ams-wg-001",
+			},
+		},
+		"not_connected": {
+			statusCode: http.StatusOK,
+			body:       `{"ip":"1.2.3.4","mullvad_exit_ip":false}`,
+			verification: models.ConnectionVerification{
+				Provider: "mullvad",
+				PublicIP: netip.MustParseAddr("1.2.3.4"),
+			},
+		},
+		"bad_status_code": {
+			statusCode: http.StatusTooManyRequests,
+			errMessage: "HTTP status code not OK: 429 Too Many Requests",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &http.Client{
+				Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+					assert.Equal(t, "https://am.i.mullvad.net/json", r.URL.String())
+					return &http.Response{
+						StatusCode: testCase.statusCode,
+						Status:     http.StatusText(testCase.statusCode),
+						Body:       io.NopCloser(strings.NewReader(testCase.body)),
+					}, nil
+				}),
+			}
+			provider := New(nil, nil, nil, client)
+
+			verification, err := provider.VerifyConnection(context.Background())
+
+			assert.Equal(t, testCase.verification, verification)
+			if testCase.errMessage != "" {
+				require.EqualError(t, err, testCase.errMessage)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -10,18 +10,23 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
+	serversUpdater *updater.Updater
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	client *http.Client, updaterWarner common.Warner,
 ) *Provider {
+	serversUpdater := updater.New(client, updaterWarner)
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(client, updaterWarner),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		serversUpdater: serversUpdater,
+		Fetcher:        serversUpdater,
 	}
 }
 
@@ -0,0 +1,23 @@
+package updater
+
+import (
+	"context"
+)
+
+// FetchLoads fetches the load percentage of each server,
+// returning a map from server hostname to load.
+func (u *Updater) FetchLoads(ctx context.Context) (
+	hostnameToLoad map[string]uint8, err error,
+) {
+	const limit = 0
+	data, err := fetchAPI(ctx, u.client, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnameToLoad = make(map[string]uint8, len(data.Servers))
+	for _, server := range data.Servers {
+		hostnameToLoad[server.Hostname] = server.Load
+	}
+	return hostnameToLoad, nil
+}
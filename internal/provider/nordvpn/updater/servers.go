@@ -84,6 +84,7 @@ func extractServers(jsonServer serverData, groups map[uint32]groupData,
 		Categories: jsonServer.categories(groups),
 		Hostname:   jsonServer.Hostname,
 		IPs:        jsonServer.ips(),
+		Load:       &jsonServer.Load,
 	}
 
 	number, err := parseServerName(jsonServer.Name)
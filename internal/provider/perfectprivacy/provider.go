@@ -9,18 +9,20 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	unzipper common.Unzipper, updaterWarner common.Warner,
 ) *Provider {
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(unzipper, updaterWarner),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		Fetcher:        updater.New(unzipper, updaterWarner),
 	}
 }
 
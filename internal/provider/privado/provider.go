@@ -9,20 +9,22 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	ipFetcher common.IPFetcher, unzipper common.Unzipper,
 	updaterWarner common.Warner,
 	parallelResolver common.ParallelResolver,
 ) *Provider {
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(ipFetcher, unzipper, updaterWarner, parallelResolver),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		Fetcher:        updater.New(ipFetcher, unzipper, updaterWarner, parallelResolver),
 	}
 }
 
@@ -11,15 +11,16 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
-	timeNow    func() time.Time
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
+	timeNow        func() time.Time
 	common.Fetcher
 	// Port forwarding
 	portForwardPath string
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	timeNow func() time.Time, client *http.Client,
 ) *Provider {
 	const jsonPortForwardPath = "/gluetun/piaportforward.json"
@@ -27,6 +28,7 @@ func New(storage common.Storage, randSource rand.Source,
 		storage:         storage,
 		timeNow:         timeNow,
 		randSource:      randSource,
+		strategyPicker:  strategyPicker,
 		portForwardPath: jsonPortForwardPath,
 		Fetcher:         updater.New(client),
 	}
@@ -3,6 +3,7 @@ package protonvpn
 import (
 	"math/rand"
 	"net/http"
+	"time"
 
 	"github.com/qdm12/gluetun/internal/constants/providers"
 	"github.com/qdm12/gluetun/internal/provider/common"
@@ -10,19 +11,29 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
+	client         *http.Client
+	exitIPs        *common.ServerIPs
+	serversUpdater *updater.Updater
 	common.Fetcher
 	portForwarded uint16
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	client *http.Client, updaterWarner common.Warner,
 ) *Provider {
+	serversUpdater := updater.New(client, updaterWarner)
+	const exitIPsRefreshPeriod = time.Hour
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(client, updaterWarner),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		client:         client,
+		exitIPs:        common.NewServerIPs(serversUpdater.FetchExitIPs, exitIPsRefreshPeriod),
+		serversUpdater: serversUpdater,
+		Fetcher:        serversUpdater,
 	}
 }
 
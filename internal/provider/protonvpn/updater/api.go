@@ -23,6 +23,7 @@ type logicalServer struct {
 	Servers     []physicalServer `json:"Servers"`
 	Features    uint16           `json:"Features"`
 	Tier        *uint8           `json:"Tier,omitempty"`
+	Load        uint8            `json:"Load"`
 }
 
 type physicalServer struct {
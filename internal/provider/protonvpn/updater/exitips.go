@@ -0,0 +1,28 @@
+package updater
+
+import (
+	"context"
+	"net/netip"
+)
+
+// FetchExitIPs fetches the exit IP addresses of the ProtonVPN servers,
+// mapped to their logical server name.
+func (u *Updater) FetchExitIPs(ctx context.Context) (
+	exitIPToServer map[netip.Addr]string, err error,
+) {
+	data, err := fetchAPI(ctx, u.client)
+	if err != nil {
+		return nil, err
+	}
+
+	exitIPToServer = make(map[netip.Addr]string)
+	for _, logicalServer := range data.LogicalServers {
+		for _, physicalServer := range logicalServer.Servers {
+			if !physicalServer.ExitIP.IsValid() {
+				continue
+			}
+			exitIPToServer[physicalServer.ExitIP] = logicalServer.Name
+		}
+	}
+	return exitIPToServer, nil
+}
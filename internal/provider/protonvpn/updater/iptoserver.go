@@ -17,7 +17,7 @@ type features struct {
 }
 
 func (its ipToServers) add(country, region, city, name, hostname, wgPubKey string,
-	free bool, entryIP netip.Addr, features features,
+	free bool, entryIP netip.Addr, features features, load uint8,
 ) {
 	key := entryIP.String()
 
@@ -38,6 +38,7 @@ func (its ipToServers) add(country, region, city, name, hostname, wgPubKey strin
 		PortForward: features.p2p,
 		Stream:      features.stream,
 		IPs:         []netip.Addr{entryIP},
+		Load:        &load,
 	}
 	openvpnServer := baseServer
 	openvpnServer.VPN = vpn.OpenVPN
@@ -0,0 +1,24 @@
+package updater
+
+import (
+	"context"
+)
+
+// FetchLoads fetches the load percentage of each server,
+// returning a map from server hostname to load.
+func (u *Updater) FetchLoads(ctx context.Context) (
+	hostnameToLoad map[string]uint8, err error,
+) {
+	data, err := fetchAPI(ctx, u.client)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnameToLoad = make(map[string]uint8)
+	for _, logicalServer := range data.LogicalServers {
+		for _, physicalServer := range logicalServer.Servers {
+			hostnameToLoad[physicalServer.Domain] = logicalServer.Load
+		}
+	}
+	return hostnameToLoad, nil
+}
@@ -76,7 +76,8 @@ func (u *Updater) FetchServers(ctx context.Context, minServers int) (
 				u.warner.Warn(warning)
 			}
 
-			ipToServer.add(country, region, city, name, hostname, wgPubKey, free, entryIP, features)
+			load := logicalServer.Load
+			ipToServer.add(country, region, city, name, hostname, wgPubKey, free, entryIP, features, load)
 		}
 	}
 
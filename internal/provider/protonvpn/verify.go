@@ -0,0 +1,66 @@
+package protonvpn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/qdm12/gluetun/internal/constants/providers"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+var ErrHTTPStatusCodeNotOK = errors.New("HTTP status code not OK")
+
+// PrepareVerification fetches the exit IP addresses of the
+// ProtonVPN servers, if they are not fetched yet or are outdated.
+func (p *Provider) PrepareVerification(ctx context.Context) (err error) {
+	return p.exitIPs.Refresh(ctx)
+}
+
+// VerifyConnection uses the ProtonVPN location API to get the public
+// IP address seen by ProtonVPN, and verifies it is the exit IP address
+// of one of the ProtonVPN servers.
+func (p *Provider) VerifyConnection(ctx context.Context) (
+	verification models.ConnectionVerification, err error,
+) {
+	const url = "https://api.protonmail.ch/vpn/location"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return verification, fmt.Errorf("creating request: %w", err)
+	}
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return verification, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return verification, fmt.Errorf("%w: %d %s", ErrHTTPStatusCodeNotOK,
+			response.StatusCode, response.Status)
+	}
+
+	var data struct {
+		IP netip.Addr `json:"IP"`
+	}
+	decoder := json.NewDecoder(response.Body)
+	err = decoder.Decode(&data)
+	if err != nil {
+		return verification, fmt.Errorf("decoding response body: %w", err)
+	}
+
+	server, found, err := p.exitIPs.Lookup(data.IP)
+	if err != nil {
+		return verification, fmt.Errorf("looking up server exit IP address: %w", err)
+	}
+
+	return models.ConnectionVerification{
+		Provider:  providers.Protonvpn,
+		Connected: found,
+		PublicIP:  data.IP,
+		Server:    server,
+	}, nil
+}
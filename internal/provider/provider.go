@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
 	"github.com/qdm12/gluetun/internal/models"
@@ -9,9 +10,34 @@ import (
 
 // Provider contains methods to read and modify the openvpn configuration to connect as a client.
 type Provider interface {
-	GetConnection(selection settings.ServerSelection, ipv6Supported bool) (connection models.Connection, err error)
+	GetConnection(ctx context.Context, selection settings.ServerSelection,
+		ipv6Supported bool) (connection models.Connection, err error)
 	OpenVPNConfig(connection models.Connection, settings settings.OpenVPN, ipv6Supported bool) (lines []string)
 	Name() string
 	FetchServers(ctx context.Context, minServers int) (
 		servers []models.Server, err error)
 }
+
+// ErrVerificationNotSupported is returned when verifying the connection
+// with a provider not implementing ConnectionVerifier.
+var ErrVerificationNotSupported = errors.New("provider does not support connection verification")
+
+// ConnectionVerifier is implemented by providers having an API
+// to verify the traffic goes through their VPN.
+type ConnectionVerifier interface {
+	VerifyConnection(ctx context.Context) (
+		verification models.ConnectionVerification, err error)
+}
+
+// VerificationPreparer is implemented by connection verifiers
+// needing data fetched before verifying the connection, such as
+// the IP addresses of the provider servers.
+type VerificationPreparer interface {
+	PrepareVerification(ctx context.Context) (err error)
+}
+
+// LoadFetcher is implemented by providers publishing
+// the load of their servers.
+type LoadFetcher interface {
+	FetchLoads(ctx context.Context) (hostnameToLoad map[string]uint8, err error)
+}
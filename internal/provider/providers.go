@@ -51,8 +51,12 @@ type Extractor interface {
 		connection models.Connection, err error)
 }
 
-func NewProviders(storage Storage, timeNow func() time.Time,
-	updaterWarner common.Warner, client *http.Client, unzipper common.Unzipper,
+// NewProviders creates all the providers. The strategy picker is used by
+// the providers to pick a connection according to the selection strategy,
+// and can be nil to pick a random connection whatever the strategy.
+func NewProviders(storage Storage, strategyPicker common.StrategyPicker,
+	timeNow func() time.Time, updaterWarner common.Warner,
+	client *http.Client, unzipper common.Unzipper,
 	parallelResolver common.ParallelResolver, ipFetcher common.IPFetcher,
 	extractor custom.Extractor,
 ) *Providers {
@@ -60,31 +64,31 @@ func NewProviders(storage Storage, timeNow func() time.Time,
 
 	//nolint:lll
 	providerNameToProvider := map[string]Provider{
-		providers.Airvpn:                airvpn.New(storage, randSource, client),
+		providers.Airvpn:                airvpn.New(storage, randSource, strategyPicker, client),
 		providers.Custom:                custom.New(extractor),
-		providers.Cyberghost:            cyberghost.New(storage, randSource, parallelResolver),
-		providers.Expressvpn:            expressvpn.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.Fastestvpn:            fastestvpn.New(storage, randSource, client, updaterWarner, parallelResolver),
-		providers.Giganews:              giganews.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.HideMyAss:             hidemyass.New(storage, randSource, client, updaterWarner, parallelResolver),
-		providers.Ipvanish:              ipvanish.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.Ivpn:                  ivpn.New(storage, randSource, client, updaterWarner, parallelResolver),
-		providers.Mullvad:               mullvad.New(storage, randSource, client),
-		providers.Nordvpn:               nordvpn.New(storage, randSource, client, updaterWarner),
-		providers.Perfectprivacy:        perfectprivacy.New(storage, randSource, unzipper, updaterWarner),
-		providers.Privado:               privado.New(storage, randSource, ipFetcher, unzipper, updaterWarner, parallelResolver),
-		providers.PrivateInternetAccess: privateinternetaccess.New(storage, randSource, timeNow, client),
-		providers.Privatevpn:            privatevpn.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.Protonvpn:             protonvpn.New(storage, randSource, client, updaterWarner),
-		providers.Purevpn:               purevpn.New(storage, randSource, ipFetcher, unzipper, updaterWarner, parallelResolver),
-		providers.SlickVPN:              slickvpn.New(storage, randSource, client, updaterWarner, parallelResolver),
-		providers.Surfshark:             surfshark.New(storage, randSource, client, unzipper, updaterWarner, parallelResolver),
-		providers.Torguard:              torguard.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.VPNSecure:             vpnsecure.New(storage, randSource, client, updaterWarner, parallelResolver),
-		providers.VPNUnlimited:          vpnunlimited.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.Vyprvpn:               vyprvpn.New(storage, randSource, unzipper, updaterWarner, parallelResolver),
-		providers.Wevpn:                 wevpn.New(storage, randSource, updaterWarner, parallelResolver),
-		providers.Windscribe:            windscribe.New(storage, randSource, client, updaterWarner),
+		providers.Cyberghost:            cyberghost.New(storage, randSource, strategyPicker, parallelResolver),
+		providers.Expressvpn:            expressvpn.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.Fastestvpn:            fastestvpn.New(storage, randSource, strategyPicker, client, updaterWarner, parallelResolver),
+		providers.Giganews:              giganews.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.HideMyAss:             hidemyass.New(storage, randSource, strategyPicker, client, updaterWarner, parallelResolver),
+		providers.Ipvanish:              ipvanish.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.Ivpn:                  ivpn.New(storage, randSource, strategyPicker, client, updaterWarner, parallelResolver),
+		providers.Mullvad:               mullvad.New(storage, randSource, strategyPicker, client),
+		providers.Nordvpn:               nordvpn.New(storage, randSource, strategyPicker, client, updaterWarner),
+		providers.Perfectprivacy:        perfectprivacy.New(storage, randSource, strategyPicker, unzipper, updaterWarner),
+		providers.Privado:               privado.New(storage, randSource, strategyPicker, ipFetcher, unzipper, updaterWarner, parallelResolver),
+		providers.PrivateInternetAccess: privateinternetaccess.New(storage, randSource, strategyPicker, timeNow, client),
+		providers.Privatevpn:            privatevpn.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.Protonvpn:             protonvpn.New(storage, randSource, strategyPicker, client, updaterWarner),
+		providers.Purevpn:               purevpn.New(storage, randSource, strategyPicker, ipFetcher, unzipper, updaterWarner, parallelResolver),
+		providers.SlickVPN:              slickvpn.New(storage, randSource, strategyPicker, client, updaterWarner, parallelResolver),
+		providers.Surfshark:             surfshark.New(storage, randSource, strategyPicker, client, unzipper, updaterWarner, parallelResolver),
+		providers.Torguard:              torguard.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.VPNSecure:             vpnsecure.New(storage, randSource, strategyPicker, client, updaterWarner, parallelResolver),
+		providers.VPNUnlimited:          vpnunlimited.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.Vyprvpn:               vyprvpn.New(storage, randSource, strategyPicker, unzipper, updaterWarner, parallelResolver),
+		providers.Wevpn:                 wevpn.New(storage, randSource, strategyPicker, updaterWarner, parallelResolver),
+		providers.Windscribe:            windscribe.New(storage, randSource, strategyPicker, client, updaterWarner),
 	}
 
 	targetLength := len(providers.AllWithCustom())
@@ -0,0 +1,13 @@
+package surfshark
+
+import (
+	"context"
+)
+
+// FetchLoads fetches the load percentage of each server,
+// returning a map from server hostname to load.
+func (p *Provider) FetchLoads(ctx context.Context) (
+	hostnameToLoad map[string]uint8, err error,
+) {
+	return p.serversUpdater.FetchLoads(ctx)
+}
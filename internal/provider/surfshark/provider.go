@@ -10,19 +10,24 @@ import (
 )
 
 type Provider struct {
-	storage    common.Storage
-	randSource rand.Source
+	storage        common.Storage
+	randSource     rand.Source
+	strategyPicker common.StrategyPicker
+	serversUpdater *updater.Updater
 	common.Fetcher
 }
 
-func New(storage common.Storage, randSource rand.Source,
+func New(storage common.Storage, randSource rand.Source, strategyPicker common.StrategyPicker,
 	client *http.Client, unzipper common.Unzipper, updaterWarner common.Warner,
 	parallelResolver common.ParallelResolver,
 ) *Provider {
+	serversUpdater := updater.New(client, unzipper, updaterWarner, parallelResolver)
 	return &Provider{
-		storage:    storage,
-		randSource: randSource,
-		Fetcher:    updater.New(client, unzipper, updaterWarner, parallelResolver),
+		storage:        storage,
+		randSource:     randSource,
+		strategyPicker: strategyPicker,
+		serversUpdater: serversUpdater,
+		Fetcher:        serversUpdater,
 	}
 }
 
@@ -33,6 +33,8 @@ func addServersFromAPI(ctx context.Context, client *http.Client,
 			hts.addWireguard(serverData.Host, serverData.Region, serverData.Country,
 				serverData.Location, retroLoc, serverData.PubKey)
 		}
+
+		hts.setLoad(serverData.Host, serverData.Load)
 	}
 
 	return nil
@@ -46,6 +48,7 @@ type serverData struct {
 	Country  string `json:"country"`
 	Location string `json:"location"`
 	PubKey   string `json:"pubKey"`
+	Load     uint8  `json:"load"`
 }
 
 func fetchAPI(ctx context.Context, client *http.Client) (
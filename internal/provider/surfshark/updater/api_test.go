@@ -44,9 +44,9 @@ func Test_addServersFromAPI(t *testing.T) {
 				requestURL:     "https://api.surfshark.com/v4/server/clusters/generic",
 				responseStatus: http.StatusOK,
 				responseBody: io.NopCloser(strings.NewReader(`[
-				{"connectionName":"host1","region":"region1","country":"country1","location":"location1"},
-				{"connectionName":"host1","region":"region1","country":"country1","location":"location1","pubkey":"pubKeyValue"},
-				{"connectionName":"host2","region":"region2","country":"country1","location":"location2"}
+				{"connectionName":"host1","region":"region1","country":"country1","location":"location1","load":20},
+				{"connectionName":"host1","region":"region1","country":"country1","location":"location1","pubkey":"pubKeyValue","load":20},
+				{"connectionName":"host2","region":"region2","country":"country1","location":"location2","load":55}
 			]`)),
 			}, {
 				requestURL:     "https://api.surfshark.com/v4/server/clusters/double",
@@ -71,6 +71,7 @@ func Test_addServersFromAPI(t *testing.T) {
 					Hostname: "host1",
 					TCP:      true,
 					UDP:      true,
+					Load:     ptrTo(uint8(20)),
 				}, {
 					VPN:      vpn.Wireguard,
 					Region:   "region1",
@@ -78,6 +79,7 @@ func Test_addServersFromAPI(t *testing.T) {
 					City:     "location1",
 					Hostname: "host1",
 					WgPubKey: "pubKeyValue",
+					Load:     ptrTo(uint8(20)),
 				}},
 				"host2": {{
 					VPN:      vpn.OpenVPN,
@@ -87,6 +89,7 @@ func Test_addServersFromAPI(t *testing.T) {
 					Hostname: "host2",
 					TCP:      true,
 					UDP:      true,
+					Load:     ptrTo(uint8(55)),
 				}},
 			},
 		},
@@ -238,3 +241,5 @@ func Test_fetchAPI(t *testing.T) {
 		})
 	}
 }
+
+func ptrTo[T any](value T) *T { return &value }
@@ -68,6 +68,13 @@ func (hts hostToServers) addWireguard(host, region, country, city, retroLoc,
 	hts[host] = append(servers, server)
 }
 
+func (hts hostToServers) setLoad(host string, load uint8) {
+	servers := hts[host]
+	for i := range servers {
+		servers[i].Load = &load
+	}
+}
+
 func (hts hostToServers) toHostsSlice() (hosts []string) {
 	const vpnServerTypes = 2 // OpenVPN + Wireguard
 	hosts = make([]string, 0, vpnServerTypes*len(hts))
@@ -0,0 +1,22 @@
+package updater
+
+import (
+	"context"
+)
+
+// FetchLoads fetches the load percentage of each server,
+// returning a map from server hostname to load.
+func (u *Updater) FetchLoads(ctx context.Context) (
+	hostnameToLoad map[string]uint8, err error,
+) {
+	data, err := fetchAPI(ctx, u.client)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnameToLoad = make(map[string]uint8, len(data))
+	for _, server := range data {
+		hostnameToLoad[server.Host] = server.Load
+	}
+	return hostnameToLoad, nil
+}
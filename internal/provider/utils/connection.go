@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -30,12 +31,13 @@ type Storage interface {
 		servers []models.Server, err error)
 }
 
-func GetConnection(provider string,
+func GetConnection(ctx context.Context, provider string,
 	storage Storage,
 	selection settings.ServerSelection,
 	defaults ConnectionDefaults,
 	ipv6Supported bool,
-	randSource rand.Source) (
+	randSource rand.Source,
+	strategyPicker StrategyPicker) (
 	connection models.Connection, err error,
 ) {
 	servers, err := storage.FilterServers(provider, selection)
@@ -43,6 +45,10 @@ func GetConnection(provider string,
 		return connection, fmt.Errorf("filtering servers: %w", err)
 	}
 
+	if selection.Strategy == settings.SelectionStrategyLeastLoaded {
+		servers = leastLoadedServers(servers)
+	}
+
 	protocol := getProtocol(selection)
 	port := getPort(selection, defaults.OpenVPNTCPPort,
 		defaults.OpenVPNUDPPort, defaults.WireguardPort)
@@ -70,10 +76,35 @@ func GetConnection(provider string,
 				ServerName:  server.ServerName,
 				PortForward: server.PortForward,
 				PubKey:      server.WgPubKey, // Wireguard
+				Country:     server.Country,
 			}
 			connections = append(connections, connection)
 		}
 	}
 
-	return pickConnection(connections, selection, randSource)
+	return pickConnection(ctx, connections, selection, randSource, strategyPicker)
+}
+
+// leastLoadedServers returns the servers with the lowest load.
+// Servers without load information are only returned if
+// no server has load information.
+func leastLoadedServers(servers []models.Server) (leastLoaded []models.Server) {
+	var minLoad *uint8
+	for _, server := range servers {
+		switch {
+		case server.Load == nil:
+			continue
+		case minLoad == nil || *server.Load < *minLoad:
+			minLoad = server.Load
+			leastLoaded = leastLoaded[:0]
+			leastLoaded = append(leastLoaded, server)
+		case *server.Load == *minLoad:
+			leastLoaded = append(leastLoaded, server)
+		}
+	}
+
+	if minLoad == nil {
+		return servers
+	}
+	return leastLoaded
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"net/netip"
@@ -194,9 +195,9 @@ func Test_GetConnection(t *testing.T) {
 				FilterServers(testCase.provider, testCase.serverSelection).
 				Return(testCase.filteredServers, testCase.filterError)
 
-			connection, err := GetConnection(testCase.provider, storage,
+			connection, err := GetConnection(context.Background(), testCase.provider, storage,
 				testCase.serverSelection, testCase.defaults, testCase.ipv6Supported,
-				testCase.randSource)
+				testCase.randSource, nil)
 
 			assert.Equal(t, testCase.connection, connection)
 			assert.ErrorIs(t, err, testCase.errWrapped)
@@ -206,3 +207,39 @@ func Test_GetConnection(t *testing.T) {
 		})
 	}
 }
+
+func Test_leastLoadedServers(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		servers     []models.Server
+		leastLoaded []models.Server
+	}{
+		"no load information": {
+			servers:     []models.Server{{Hostname: "a"}, {Hostname: "b"}},
+			leastLoaded: []models.Server{{Hostname: "a"}, {Hostname: "b"}},
+		},
+		"lowest loads": {
+			servers: []models.Server{
+				{Hostname: "a", Load: ptrTo(uint8(50))},
+				{Hostname: "b"},
+				{Hostname: "c", Load: ptrTo(uint8(10))},
+				{Hostname: "d", Load: ptrTo(uint8(10))},
+			},
+			leastLoaded: []models.Server{
+				{Hostname: "c", Load: ptrTo(uint8(10))},
+				{Hostname: "d", Load: ptrTo(uint8(10))},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			leastLoaded := leastLoadedServers(testCase.servers)
+
+			assert.Equal(t, testCase.leastLoaded, leastLoaded)
+		})
+	}
+}
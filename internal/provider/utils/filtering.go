@@ -67,6 +67,10 @@ func filterServer(server models.Server,
 		return true
 	}
 
+	if selection.MaxLoad > 0 && server.Load != nil && *server.Load > selection.MaxLoad {
+		return true
+	}
+
 	if filterByPossibilities(server.Country, selection.Countries) {
 		return true
 	}
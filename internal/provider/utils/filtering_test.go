@@ -141,6 +141,20 @@ func Test_FilterServers(t *testing.T) {
 				{Tor: true, VPN: vpn.OpenVPN, UDP: true},
 			},
 		},
+		"filter by maximum load": {
+			selection: settings.ServerSelection{
+				MaxLoad: 50,
+			}.WithDefaults(providers.Nordvpn),
+			servers: []models.Server{
+				{Load: ptrTo(uint8(80)), VPN: vpn.OpenVPN, UDP: true},
+				{Load: ptrTo(uint8(50)), VPN: vpn.OpenVPN, UDP: true},
+				{VPN: vpn.OpenVPN, UDP: true},
+			},
+			filtered: []models.Server{
+				{Load: ptrTo(uint8(50)), VPN: vpn.OpenVPN, UDP: true},
+				{VPN: vpn.OpenVPN, UDP: true},
+			},
+		},
 		"filter by owned": {
 			selection: settings.ServerSelection{
 				OwnedOnly: boolPtr(true),
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -13,13 +14,23 @@ import (
 
 var ErrNoConnectionToPickFrom = errors.New("no connection to pick from")
 
+// StrategyPicker picks a connection according to the selection strategy.
+type StrategyPicker interface {
+	PickConnection(ctx context.Context, connections []models.Connection,
+		selection settings.ServerSelection) (connection models.Connection, err error)
+}
+
 // pickConnection picks a connection from a pool of connections.
 // If the VPN protocol is Wireguard and the target IP is set,
 // it finds the connection corresponding to this target IP.
-// Otherwise, it picks a random connection from the pool of connections
-// and sets the target IP address as the IP if this one is set.
-func pickConnection(connections []models.Connection,
-	selection settings.ServerSelection, randSource rand.Source) (
+// Otherwise, it picks a connection from the pool of connections
+// using the strategy picker if it is not nil. If the strategy picker
+// is nil, for example for the OpenVPN configuration command, a random
+// connection is picked whatever the selection strategy. It then sets
+// the target IP address as the IP if this one is set.
+func pickConnection(ctx context.Context, connections []models.Connection,
+	selection settings.ServerSelection, randSource rand.Source,
+	strategyPicker StrategyPicker) (
 	connection models.Connection, err error,
 ) {
 	if len(connections) == 0 {
@@ -33,7 +44,16 @@ func pickConnection(connections []models.Connection,
 		return getTargetIPConnection(connections, selection.TargetIP)
 	}
 
-	connection = pickRandomConnection(connections, randSource)
+	if strategyPicker == nil {
+		connection = pickRandomConnection(connections, randSource)
+	} else {
+		connection, err = strategyPicker.PickConnection(ctx, connections, selection)
+		if err != nil {
+			return connection, fmt.Errorf("picking connection with strategy %s: %w",
+				selection.Strategy, err)
+		}
+	}
+
 	if targetIPSet {
 		connection.IP = selection.TargetIP
 	}
@@ -1,13 +1,52 @@
 package utils
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 
+	"github.com/qdm12/gluetun/internal/configuration/settings"
 	"github.com/qdm12/gluetun/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+type fakeStrategyPicker struct {
+	strategies []string
+}
+
+func (f *fakeStrategyPicker) PickConnection(_ context.Context,
+	connections []models.Connection, selection settings.ServerSelection,
+) (connection models.Connection, err error) {
+	f.strategies = append(f.strategies, selection.Strategy)
+	return connections[len(connections)-1], nil
+}
+
+func Test_pickConnection_strategyPicker(t *testing.T) {
+	t.Parallel()
+
+	connections := []models.Connection{{Port: 1}, {Port: 2}}
+	strategies := []string{
+		"",
+		settings.SelectionStrategyRandom,
+		settings.SelectionStrategyLeastLoaded,
+		settings.SelectionStrategyRoundRobin,
+		settings.SelectionStrategySticky,
+		settings.SelectionStrategyLowestLatency,
+	}
+
+	// The strategy picker picks the connection for all strategies.
+	picker := &fakeStrategyPicker{}
+	for _, strategy := range strategies {
+		selection := settings.ServerSelection{Strategy: strategy}
+		connection, err := pickConnection(context.Background(), connections,
+			selection, rand.NewSource(0), picker)
+		require.NoError(t, err)
+		assert.Equal(t, models.Connection{Port: 2}, connection)
+	}
+	assert.Equal(t, strategies, picker.strategies)
+}
+
 func Test_pickRandomConnection(t *testing.T) {
 	t.Parallel()
 	connections := []models.Connection{
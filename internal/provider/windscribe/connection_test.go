@@ -1,6 +1,7 @@
 package windscribe
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"net/http"
@@ -100,16 +101,16 @@ func Test_Provider_GetConnection(t *testing.T) {
 
 			client := (*http.Client)(nil)
 			warner := (common.Warner)(nil)
-			provider := New(storage, randSource, client, warner)
+			provider := New(storage, randSource, nil, client, warner)
 
 			if testCase.panicMessage != "" {
 				assert.PanicsWithValue(t, testCase.panicMessage, func() {
-					_, _ = provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+					_, _ = provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 				})
 				return
 			}
 
-			connection, err := provider.GetConnection(testCase.selection, testCase.ipv6Supported)
+			connection, err := provider.GetConnection(context.Background(), testCase.selection, testCase.ipv6Supported)
 
 			assert.ErrorIs(t, err, testCase.errWrapped)
 			if testCase.errWrapped != nil {
@@ -0,0 +1,31 @@
+package publicip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/publicip/api"
+)
+
+// FetchPreVPNIP fetches the public IP address using the public IP
+// APIs from the settings given. It is meant to be called at startup
+// before the firewall is enabled, to obtain the public IP address
+// without the VPN.
+func FetchPreVPNIP(ctx context.Context, settings settings.PublicIP,
+	httpClient *http.Client, logger Logger,
+) (ip netip.Addr, err error) {
+	fetchers, err := api.New(makeNameTokenPairs(settings.APIs), httpClient)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("creating fetchers: %w", err)
+	}
+
+	fetcher := api.NewResilient(fetchers, logger)
+	result, err := fetcher.FetchInfo(ctx, netip.Addr{})
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("fetching information: %w", err)
+	}
+	return result.IP, nil
+}
@@ -3,7 +3,9 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -25,7 +27,8 @@ type dnsHandler struct {
 
 func (h *dnsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	r.RequestURI = strings.TrimPrefix(r.RequestURI, "/dns")
-	switch r.RequestURI {
+	path, _, _ := strings.Cut(r.RequestURI, "?")
+	switch path {
 	case "/status": //nolint:goconst
 		switch r.Method {
 		case http.MethodGet:
@@ -35,6 +38,27 @@ func (h *dnsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		default:
 			errMethodNotSupported(w, r.Method)
 		}
+	case "/querylog":
+		switch r.Method {
+		case http.MethodGet:
+			h.getQueryLog(w)
+		default:
+			errMethodNotSupported(w, r.Method)
+		}
+	case "/stats":
+		switch r.Method {
+		case http.MethodGet:
+			h.getQueryStats(w, r)
+		default:
+			errMethodNotSupported(w, r.Method)
+		}
+	case "/leaktest":
+		switch r.Method {
+		case http.MethodGet:
+			h.getLeakTestResult(w)
+		default:
+			errMethodNotSupported(w, r.Method)
+		}
 	default:
 		errRouteNotSupported(w, r.RequestURI)
 	}
@@ -75,3 +99,49 @@ func (h *dnsHandler) setStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (h *dnsHandler) getQueryLog(w http.ResponseWriter) {
+	entries := h.loop.GetQueryLog()
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(entries); err != nil {
+		h.warner.Warn(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *dnsHandler) getQueryStats(w http.ResponseWriter, r *http.Request) {
+	const defaultTop = 10
+	top := defaultTop
+	if topString := r.URL.Query().Get("top"); topString != "" {
+		var err error
+		top, err = strconv.Atoi(topString)
+		if err != nil || top < 1 {
+			http.Error(w, fmt.Sprintf("top query parameter is not a strictly positive integer: %s",
+				topString), http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats := h.loop.GetQueryStats(top)
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(stats); err != nil {
+		h.warner.Warn(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *dnsHandler) getLeakTestResult(w http.ResponseWriter) {
+	result := h.loop.GetLeakTestResult()
+	if result.Empty() {
+		http.Error(w, "no DNS leak test result available", http.StatusNotFound)
+		return
+	}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(result); err != nil {
+		h.warner.Warn(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/dns/leaktest"
+	"github.com/qdm12/gluetun/internal/dns/middleware/querylog"
 	"github.com/qdm12/gluetun/internal/models"
 )
 
@@ -13,12 +15,20 @@ type VPNLooper interface {
 		outcome string, err error)
 	GetSettings() (settings settings.VPN)
 	SetSettings(ctx context.Context, settings settings.VPN) (outcome string)
+	VerifyConnection(ctx context.Context) (
+		verification models.ConnectionVerification, err error)
+	GetFailedConnections() (failed []models.FailedConnection)
+	GetTunnelStatistics() (statistics models.TunnelStatistics)
+	GetConnectionDetails() (details models.ConnectionDetails, err error)
 }
 
 type DNSLoop interface {
 	ApplyStatus(ctx context.Context, status models.LoopStatus) (
 		outcome string, err error)
 	GetStatus() (status models.LoopStatus)
+	GetQueryLog() (entries []querylog.Entry)
+	GetQueryStats(n int) (stats querylog.Stats)
+	GetLeakTestResult() (result leaktest.Result)
 }
 
 type PortForwardedGetter interface {
@@ -119,12 +119,17 @@ var validRoutes = map[string]struct{}{ //nolint:gochecknoglobals
 	http.MethodPut + " /v1/vpn/status":            {},
 	http.MethodGet + " /v1/vpn/settings":          {},
 	http.MethodPut + " /v1/vpn/settings":          {},
+	http.MethodGet + " /v1/vpn/connection":        {},
+	http.MethodGet + " /v1/vpn/verification":      {},
 	http.MethodGet + " /v1/openvpn/status":        {},
 	http.MethodPut + " /v1/openvpn/status":        {},
 	http.MethodGet + " /v1/openvpn/portforwarded": {},
 	http.MethodGet + " /v1/openvpn/settings":      {},
 	http.MethodGet + " /v1/dns/status":            {},
 	http.MethodPut + " /v1/dns/status":            {},
+	http.MethodGet + " /v1/dns/querylog":          {},
+	http.MethodGet + " /v1/dns/stats":             {},
+	http.MethodGet + " /v1/dns/leaktest":          {},
 	http.MethodGet + " /v1/updater/status":        {},
 	http.MethodPut + " /v1/updater/status":        {},
 	http.MethodGet + " /v1/publicip/ip":           {},
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
 )
 
 func newVPNHandler(ctx context.Context, looper VPNLooper,
@@ -50,6 +51,20 @@ func (h *vpnHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		default:
 			errMethodNotSupported(w, r.Method)
 		}
+	case "/connection":
+		switch r.Method {
+		case http.MethodGet:
+			h.getConnection(w)
+		default:
+			errMethodNotSupported(w, r.Method)
+		}
+	case "/verification":
+		switch r.Method {
+		case http.MethodGet:
+			h.getVerification(w, r)
+		default:
+			errMethodNotSupported(w, r.Method)
+		}
 	default:
 		errRouteNotSupported(w, r.RequestURI)
 	}
@@ -58,7 +73,15 @@ func (h *vpnHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *vpnHandler) getStatus(w http.ResponseWriter) {
 	status := h.looper.GetStatus()
 	encoder := json.NewEncoder(w)
-	data := statusWrapper{Status: string(status)}
+	data := struct {
+		Status            string                    `json:"status"`
+		FailedConnections []models.FailedConnection `json:"failed_connections,omitempty"`
+		Statistics        models.TunnelStatistics   `json:"statistics"`
+	}{
+		Status:            string(status),
+		FailedConnections: h.looper.GetFailedConnections(),
+		Statistics:        h.looper.GetTunnelStatistics(),
+	}
 	if err := encoder.Encode(data); err != nil {
 		h.warner.Warn(err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
@@ -129,3 +152,31 @@ func (h *vpnHandler) patchSettings(w http.ResponseWriter, r *http.Request) {
 		h.warner.Warn("writing response: " + err.Error())
 	}
 }
+
+func (h *vpnHandler) getConnection(w http.ResponseWriter) {
+	details, err := h.looper.GetConnectionDetails()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(details); err != nil {
+		h.warner.Warn(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *vpnHandler) getVerification(w http.ResponseWriter, r *http.Request) {
+	verification, err := h.looper.VerifyConnection(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(verification); err != nil {
+		h.warner.Warn(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
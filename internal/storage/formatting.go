@@ -136,6 +136,10 @@ func noServerFoundError(selection settings.ServerSelection) (err error) {
 		messageParts = append(messageParts, "multihop only")
 	}
 
+	if selection.MaxLoad > 0 {
+		messageParts = append(messageParts, "maximum load "+strconv.Itoa(int(selection.MaxLoad))+"%")
+	}
+
 	if *selection.PortForwardOnly {
 		messageParts = append(messageParts, "port forwarding only")
 	}
@@ -92,3 +92,32 @@ func (s *Storage) getMergedServersObject(provider string) (serversObject models.
 	}
 	return serversObject
 }
+
+// SetServerLoads sets the load of the servers of the provider given
+// using the hostname to load map given, in the in-memory map only,
+// and returns the number of servers updated.
+func (s *Storage) SetServerLoads(provider string, hostnameToLoad map[string]uint8) (updated int) {
+	if provider == providers.Custom {
+		return 0
+	}
+
+	s.mergedMutex.Lock()
+	defer s.mergedMutex.Unlock()
+
+	serversObject := s.getMergedServersObject(provider)
+	// Copy the servers slice since it can be shared
+	// with the caller of SetServers.
+	servers := make([]models.Server, len(serversObject.Servers))
+	copy(servers, serversObject.Servers)
+	for i, server := range servers {
+		load, ok := hostnameToLoad[server.Hostname]
+		if !ok {
+			continue
+		}
+		servers[i].Load = &load
+		updated++
+	}
+	serversObject.Servers = servers
+	s.mergedServers.ProviderToServers[provider] = serversObject
+	return updated
+}
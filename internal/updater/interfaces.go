@@ -16,6 +16,7 @@ type Storage interface {
 	SetServers(provider string, servers []models.Server) (err error)
 	GetServersCount(provider string) (count int)
 	ServersAreEqual(provider string, servers []models.Server) (equal bool)
+	SetServerLoads(provider string, hostnameToLoad map[string]uint8) (updated int)
 	// Extra methods to match the provider.New storage interface
 	FilterServers(provider string, selection settings.ServerSelection) (filtered []models.Server, err error)
 }
@@ -0,0 +1,42 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/qdm12/gluetun/internal/provider"
+)
+
+// RefreshLoads fetches the server loads of the providers given
+// publishing them, and sets them in the storage in memory.
+// Providers not publishing server loads are ignored. A provider failing
+// does not prevent refreshing the loads of the next providers, and all
+// the errors encountered are returned joined together.
+func (u *Updater) RefreshLoads(ctx context.Context, providers []string) (err error) {
+	var errs []error
+	for _, providerName := range providers {
+		loadFetcher, ok := u.providers.Get(providerName).(provider.LoadFetcher)
+		if !ok {
+			continue
+		}
+
+		hostnameToLoad, err := loadFetcher.FetchLoads(ctx)
+		if err != nil {
+			// stop refreshing the next providers if context is canceled.
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			// Log the error and continue refreshing the next provider.
+			err = fmt.Errorf("fetching %s server loads: %w", providerName, err)
+			u.logger.Error(err.Error())
+			errs = append(errs, err)
+			continue
+		}
+
+		updated := u.storage.SetServerLoads(providerName, hostnameToLoad)
+		u.logger.Info(fmt.Sprintf("refreshed the load of %d %s servers", updated, providerName))
+	}
+	return errors.Join(errs...)
+}
@@ -14,6 +14,7 @@ import (
 
 type Updater interface {
 	UpdateServers(ctx context.Context, providers []string, minRatio float64) (err error)
+	RefreshLoads(ctx context.Context, providers []string) (err error)
 }
 
 type Loop struct {
@@ -187,3 +188,28 @@ func (l *Loop) RunRestartTicker(ctx context.Context, done chan<- struct{}) {
 		}
 	}
 }
+
+// RunLoadRefresher refreshes the server loads periodically,
+// if the load refresh period is set.
+func (l *Loop) RunLoadRefresher(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	period := *l.GetSettings().LoadPeriod
+	if period == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := l.updater.RefreshLoads(ctx, l.GetSettings().Providers)
+			if err != nil && ctx.Err() == nil {
+				l.logger.Warn("refreshing server loads: " + err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package vpn
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// ConnectionBreaker is a circuit breaker excluding servers of recently
+// failed connections from the servers returned by the storage it wraps,
+// until their cooldown expires. If all the servers matching a selection
+// are excluded, it falls back to returning them all.
+type ConnectionBreaker struct {
+	storage Storage
+	logger  Infoer
+	timeNow func() time.Time
+
+	mutex  sync.Mutex
+	failed []models.FailedConnection
+}
+
+// NewConnectionBreaker creates a connection circuit breaker
+// wrapping the storage given. It implements the Storage interface
+// and should be given to the providers as their storage.
+func NewConnectionBreaker(storage Storage, logger Infoer) *ConnectionBreaker {
+	return &ConnectionBreaker{
+		storage: storage,
+		logger:  logger,
+		timeNow: time.Now,
+	}
+}
+
+// FilterServers returns the servers from the storage matching the
+// selection, excluding the servers of recently failed connections
+// unless all of them would be excluded.
+func (b *ConnectionBreaker) FilterServers(provider string, selection settings.ServerSelection) (
+	servers []models.Server, err error,
+) {
+	servers, err = b.storage.FilterServers(provider, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.removeExpired()
+	if len(b.failed) == 0 {
+		return servers, nil
+	}
+
+	filtered := make([]models.Server, 0, len(servers))
+	for _, server := range servers {
+		if !b.isFailed(server) {
+			filtered = append(filtered, server)
+		}
+	}
+
+	switch {
+	case len(filtered) == len(servers):
+		return servers, nil
+	case len(filtered) == 0:
+		b.logger.Info(fmt.Sprintf("all %d servers matching the selection failed recently, "+
+			"not excluding any of them", len(servers)))
+		return servers, nil
+	default:
+		b.logger.Info(fmt.Sprintf("excluding %d recently failed server(s) from %d servers",
+			len(servers)-len(filtered), len(servers)))
+		return filtered, nil
+	}
+}
+
+func (b *ConnectionBreaker) isFailed(server models.Server) bool {
+	for _, failed := range b.failed {
+		if slices.Contains(server.IPs, failed.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// add excludes the server of the connection given for the cooldown given.
+func (b *ConnectionBreaker) add(connection models.Connection,
+	reason string, cooldown time.Duration,
+) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failed := models.FailedConnection{
+		IP:       connection.IP,
+		Hostname: connection.Hostname,
+		Reason:   reason,
+		Until:    b.timeNow().Add(cooldown),
+	}
+	for i := range b.failed {
+		if b.failed[i].IP == connection.IP {
+			b.failed[i] = failed
+			return
+		}
+	}
+	b.failed = append(b.failed, failed)
+}
+
+// getFailed returns the connections currently excluded.
+func (b *ConnectionBreaker) getFailed() (failed []models.FailedConnection) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.removeExpired()
+	return slices.Clone(b.failed)
+}
+
+func (b *ConnectionBreaker) removeExpired() {
+	now := b.timeNow()
+	b.failed = slices.DeleteFunc(b.failed, func(failed models.FailedConnection) bool {
+		return !now.Before(failed.Until)
+	})
+}
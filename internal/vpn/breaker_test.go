@@ -0,0 +1,64 @@
+package vpn
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storageFunc func(provider string, selection settings.ServerSelection) (
+	servers []models.Server, err error)
+
+func (f storageFunc) FilterServers(provider string, selection settings.ServerSelection) (
+	servers []models.Server, err error,
+) {
+	return f(provider, selection)
+}
+
+type noopInfoer struct{}
+
+func (noopInfoer) Info(string) {}
+
+func Test_ConnectionBreaker(t *testing.T) {
+	t.Parallel()
+
+	serverA := models.Server{Hostname: "a", IPs: []netip.Addr{netip.AddrFrom4([4]byte{1, 1, 1, 1})}}
+	serverB := models.Server{Hostname: "b", IPs: []netip.Addr{netip.AddrFrom4([4]byte{2, 2, 2, 2})}}
+	storage := storageFunc(func(string, settings.ServerSelection) ([]models.Server, error) {
+		return []models.Server{serverA, serverB}, nil
+	})
+
+	now := time.Unix(0, 0)
+	breaker := NewConnectionBreaker(storage, noopInfoer{})
+	breaker.timeNow = func() time.Time { return now }
+
+	servers, err := breaker.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA, serverB}, servers)
+
+	breaker.add(models.Connection{IP: serverA.IPs[0], Hostname: "a"}, "reason", time.Minute)
+	servers, err = breaker.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverB}, servers)
+	assert.Equal(t, []models.FailedConnection{{
+		IP: serverA.IPs[0], Hostname: "a", Reason: "reason", Until: now.Add(time.Minute),
+	}}, breaker.getFailed())
+
+	// All servers failed: fallback to all servers
+	breaker.add(models.Connection{IP: serverB.IPs[0]}, "reason", 2*time.Minute)
+	servers, err = breaker.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA, serverB}, servers)
+
+	// Server A cooldown expired
+	now = now.Add(time.Minute)
+	servers, err = breaker.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA}, servers)
+	assert.Len(t, breaker.getFailed(), 1)
+}
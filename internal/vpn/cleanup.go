@@ -3,9 +3,14 @@ package vpn
 import (
 	"context"
 	"errors"
+
+	"github.com/qdm12/gluetun/internal/models"
 )
 
 func (l *Loop) cleanup() {
+	l.runDownCommand()
+	l.stopTrafficStatistics()
+
 	for _, vpnPort := range l.vpnInputPorts {
 		err := l.fw.RemoveAllowedPort(context.Background(), vpnPort)
 		if err != nil {
@@ -13,6 +18,8 @@ func (l *Loop) cleanup() {
 		}
 	}
 
+	l.setConnection(models.Connection{})
+
 	err := l.publicip.ClearData()
 	if err != nil {
 		l.logger.Error("clearing public IP data: " + err.Error())
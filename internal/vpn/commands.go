@@ -0,0 +1,160 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/qdm12/gluetun/internal/command"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// commandVariables contains the values of the template
+// variables of the VPN up and down commands.
+type commandVariables struct {
+	interfaceName string
+	tunnelIP      string
+	publicIP      string
+	serverName    string
+	serverCountry string
+}
+
+func newCommandVariables(details models.ConnectionDetails,
+	publicIP models.PublicIP,
+) (variables commandVariables) {
+	variables.interfaceName = details.Interface
+	if len(details.TunnelIPs) > 0 {
+		variables.tunnelIP = details.TunnelIPs[0].String()
+	}
+	if publicIP.IP.IsValid() {
+		variables.publicIP = publicIP.IP.String()
+	}
+
+	variables.serverName = details.Connection.ServerName
+	if variables.serverName == "" {
+		variables.serverName = details.Connection.Hostname
+	}
+
+	// Fall back on the public IP country if the server is not
+	// found in the servers data, for example for the custom provider.
+	variables.serverCountry = publicIP.Country
+	if details.Server != nil && details.Server.Country != "" {
+		variables.serverCountry = details.Server.Country
+	}
+	return variables
+}
+
+// apply splits the command template into arguments and replaces the
+// template variables in each argument, so a value containing spaces
+// or quotes is never split or interpreted as part of the command.
+func (v commandVariables) apply(commandTemplate string) (args []string, err error) {
+	args, err = command.Split(commandTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing command: %w", err)
+	}
+
+	replacer := strings.NewReplacer(
+		"{{VPN_INTERFACE}}", v.interfaceName,
+		"{{TUNNEL_IP}}", v.tunnelIP,
+		"{{PUBLIC_IP}}", v.publicIP,
+		"{{SERVER_NAME}}", v.serverName,
+		"{{SERVER_COUNTRY}}", v.serverCountry,
+	)
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+	}
+	return args, nil
+}
+
+// startUpCommand records the template variables values for the VPN
+// down command, and runs the VPN up command in a goroutine if it is set,
+// so it does not delay the rest of the tunnel setup. The up command is
+// canceled when the tunnel goes down, by runDownCommand.
+func (l *Loop) startUpCommand(ctx context.Context) {
+	l.stopUpCommand()
+
+	commandsSettings := l.GetActiveSettings().Commands
+	if *commandsSettings.Up == "" && *commandsSettings.Down == "" {
+		return
+	}
+
+	details, err := l.GetConnectionDetails()
+	if err != nil {
+		l.logger.Error("getting connection details for VPN commands: " + err.Error())
+		return
+	}
+	variables := newCommandVariables(details, l.publicip.GetData())
+
+	l.commandMutex.Lock()
+	defer l.commandMutex.Unlock()
+	l.commandVariables = &variables
+
+	if *commandsSettings.Up == "" {
+		return
+	}
+
+	args, err := variables.apply(*commandsSettings.Up)
+	if err != nil {
+		l.logger.Error("running VPN up command: " + err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *commandsSettings.Timeout)
+	done := make(chan struct{})
+	l.cancelUpCommand = cancel
+	l.upCommandDone = done
+	go func() {
+		defer close(done)
+		defer cancel()
+		err := command.RunArgsAndStream(ctx, l.starter, l.logger, args)
+		if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			l.logger.Error("running VPN up command: " + err.Error())
+		}
+	}()
+}
+
+// stopUpCommand cancels the VPN up command if it is running,
+// and waits for it to exit.
+func (l *Loop) stopUpCommand() {
+	l.commandMutex.Lock()
+	cancel, done := l.cancelUpCommand, l.upCommandDone
+	l.cancelUpCommand, l.upCommandDone = nil, nil
+	l.commandMutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// runDownCommand cancels the VPN up command if it is still running,
+// and runs the VPN down command if it is set and if the template
+// variables were recorded when the tunnel went up.
+func (l *Loop) runDownCommand() {
+	l.stopUpCommand()
+
+	l.commandMutex.Lock()
+	variables := l.commandVariables
+	l.commandVariables = nil
+	l.commandMutex.Unlock()
+
+	commandsSettings := l.GetActiveSettings().Commands
+	if variables == nil || *commandsSettings.Down == "" {
+		return
+	}
+
+	args, err := variables.apply(*commandsSettings.Down)
+	if err != nil {
+		l.logger.Error("running VPN down command: " + err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *commandsSettings.Timeout)
+	defer cancel()
+	err = command.RunArgsAndStream(ctx, l.starter, l.logger, args)
+	if err != nil {
+		l.logger.Error("running VPN down command: " + err.Error())
+	}
+}
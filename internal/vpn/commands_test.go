@@ -0,0 +1,158 @@
+package vpn
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/command"
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/vpn/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newCommandVariables(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		details   models.ConnectionDetails
+		publicIP  models.PublicIP
+		variables commandVariables
+	}{
+		"empty": {},
+		"server_found": {
+			details: models.ConnectionDetails{
+				Connection: models.Connection{
+					ServerName: "server",
+					Hostname:   "hostname",
+				},
+				Server:    &models.Server{Country: "Sweden"},
+				Interface: "tun0",
+				TunnelIPs: []netip.Addr{
+					netip.AddrFrom4([4]byte{10, 0, 0, 2}),
+					netip.MustParseAddr("fd00::2"),
+				},
+			},
+			publicIP: models.PublicIP{
+				IP:      netip.AddrFrom4([4]byte{1, 2, 3, 4}),
+				Country: "Norway",
+			},
+			variables: commandVariables{
+				interfaceName: "tun0",
+				tunnelIP:      "10.0.0.2",
+				publicIP:      "1.2.3.4",
+				serverName:    "server",
+				serverCountry: "Sweden",
+			},
+		},
+		"server_not_found": {
+			details: models.ConnectionDetails{
+				Connection: models.Connection{Hostname: "hostname"},
+				Interface:  "wg0",
+			},
+			publicIP: models.PublicIP{Country: "Norway"},
+			variables: commandVariables{
+				interfaceName: "wg0",
+				serverName:    "hostname",
+				serverCountry: "Norway",
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			variables := newCommandVariables(testCase.details, testCase.publicIP)
+
+			assert.Equal(t, testCase.variables, variables)
+		})
+	}
+}
+
+func Test_commandVariables_apply(t *testing.T) {
+	t.Parallel()
+
+	variables := commandVariables{
+		interfaceName: "tun0",
+		tunnelIP:      "10.0.0.2",
+		publicIP:      "1.2.3.4",
+		serverName:    "server",
+		serverCountry: "Cote d'Ivoire",
+	}
+
+	testCases := map[string]struct {
+		commandTemplate string
+		args            []string
+		errWrapped      error
+		errMessage      string
+	}{
+		"arguments": {
+			commandTemplate: "/gluetun/up.sh {{VPN_INTERFACE}} {{TUNNEL_IP}} " +
+				"{{PUBLIC_IP}} {{SERVER_NAME}} {{SERVER_COUNTRY}} {{UNKNOWN}}",
+			args: []string{"/gluetun/up.sh", "tun0", "10.0.0.2",
+				"1.2.3.4", "server", "Cote d'Ivoire", "{{UNKNOWN}}"},
+		},
+		"variables_in_quoted_argument": {
+			commandTemplate: `/bin/echo "{{SERVER_NAME}}: {{SERVER_COUNTRY}}"`,
+			args:            []string{"/bin/echo", "server: Cote d'Ivoire"},
+		},
+		"malformed_template": {
+			commandTemplate: `/bin/echo "{{SERVER_COUNTRY}}`,
+			errWrapped:      command.ErrDoubleQuoteUnterminated,
+			errMessage: `parsing command: splitting word in "/bin/echo \"{{SERVER_COUNTRY}}": ` +
+				"unterminated double-quoted string",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := variables.apply(testCase.commandTemplate)
+
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+			assert.Equal(t, testCase.args, args)
+		})
+	}
+}
+
+func Test_Loop_runDownCommand_cancelsUpCommand(t *testing.T) {
+	t.Parallel()
+
+	vpnSettings := settings.VPN{
+		Commands: settings.VPNCommands{
+			Up:      ptrTo("sleep 60"),
+			Down:    ptrTo(""),
+			Timeout: ptrTo(time.Minute),
+		},
+	}
+	loop := &Loop{
+		state: state.New(nil, vpnSettings),
+	}
+
+	// Simulate an up command still running when the tunnel goes down.
+	upCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		<-upCtx.Done()
+		close(done)
+	}()
+	loop.cancelUpCommand = cancel
+	loop.upCommandDone = done
+
+	loop.runDownCommand()
+
+	assert.ErrorIs(t, upCtx.Err(), context.Canceled)
+	select {
+	case <-done:
+	default:
+		t.Error("up command did not exit before the down command")
+	}
+	assert.Nil(t, loop.cancelUpCommand)
+}
@@ -0,0 +1,113 @@
+package vpn
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/netlink"
+)
+
+// GetConnection returns the VPN server connection currently
+// used, or an empty connection if the VPN is not running.
+func (l *Loop) GetConnection() (connection models.Connection) {
+	l.connectionMutex.RLock()
+	defer l.connectionMutex.RUnlock()
+	return l.connection
+}
+
+func (l *Loop) setConnection(connection models.Connection) {
+	l.connectionMutex.Lock()
+	defer l.connectionMutex.Unlock()
+	l.connection = connection
+}
+
+// MarkConnectionFailed counts a failure towards the failover threshold,
+// and excludes the server of the current connection from the server
+// selection for the failed server cooldown duration, unless the
+// cooldown is disabled or if there is no current connection.
+func (l *Loop) MarkConnectionFailed(reason string) {
+	l.markConnectionFailed(reason)
+}
+
+// markConnectionFailed is MarkConnectionFailed returning
+// true if the failure made it fail over.
+func (l *Loop) markConnectionFailed(reason string) (failedOver bool) {
+	failedOver = l.recordFailure()
+
+	cooldown := *l.state.GetSettings().FailedServerCooldown
+	if cooldown == 0 || l.breaker == nil {
+		return failedOver
+	}
+
+	connection := l.GetConnection()
+	if !connection.IP.IsValid() {
+		return failedOver
+	}
+
+	l.breaker.add(connection, reason, cooldown)
+	l.logger.Info("excluding server " + connectionName(connection) +
+		" for " + cooldown.String() + ": " + reason)
+	return failedOver
+}
+
+// GetFailedConnections returns the connections currently
+// excluded from the server selection.
+func (l *Loop) GetFailedConnections() (failed []models.FailedConnection) {
+	if l.breaker == nil {
+		return nil
+	}
+	return l.breaker.getFailed()
+}
+
+// GetConnectionDetails returns details on the current VPN connection,
+// or an error wrapping ErrVPNNotConnected if the VPN is not connected.
+func (l *Loop) GetConnectionDetails() (details models.ConnectionDetails, err error) {
+	connection := l.GetConnection()
+	if !connection.IP.IsValid() {
+		return details, fmt.Errorf("%w", ErrVPNNotConnected)
+	}
+
+	vpnSettings := l.GetActiveSettings()
+	interfaceName := vpnSettings.OpenVPN.Interface
+	if vpnSettings.Type == vpn.Wireguard {
+		interfaceName = vpnSettings.Wireguard.Interface
+	}
+
+	details = models.ConnectionDetails{
+		Connection: connection,
+		Interface:  interfaceName,
+		TunnelIPs:  []netip.Addr{},
+	}
+
+	servers, err := l.storage.FilterServers(vpnSettings.Provider.Name,
+		vpnSettings.Provider.ServerSelection)
+	if err == nil {
+		details.Server = findConnectionServer(servers, connection)
+	}
+
+	for _, family := range []int{netlink.FamilyV4, netlink.FamilyV6} {
+		ip, err := l.routing.AssignedIP(interfaceName, family)
+		if err != nil {
+			// no address assigned for this IP family
+			continue
+		}
+		details.TunnelIPs = append(details.TunnelIPs, ip)
+	}
+
+	return details, nil
+}
+
+func findConnectionServer(servers []models.Server,
+	connection models.Connection,
+) (server *models.Server) {
+	for i := range servers {
+		if servers[i].VPN == connection.Type &&
+			slices.Contains(servers[i].IPs, connection.IP) {
+			return &servers[i]
+		}
+	}
+	return nil
+}
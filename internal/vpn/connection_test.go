@@ -0,0 +1,94 @@
+package vpn
+
+import (
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/vpn/state"
+	"github.com/qdm12/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findConnectionServer(t *testing.T) {
+	t.Parallel()
+
+	ipA := netip.AddrFrom4([4]byte{1, 1, 1, 1})
+	ipB := netip.AddrFrom4([4]byte{2, 2, 2, 2})
+	servers := []models.Server{
+		{VPN: vpn.OpenVPN, Hostname: "a", IPs: []netip.Addr{ipA}},
+		{VPN: vpn.Wireguard, Hostname: "b", IPs: []netip.Addr{ipA}},
+		{VPN: vpn.OpenVPN, Hostname: "c", IPs: []netip.Addr{ipB}},
+	}
+
+	testCases := map[string]struct {
+		connection models.Connection
+		hostname   string
+	}{
+		"no_match": {
+			connection: models.Connection{Type: vpn.OpenVPN, IP: netip.AddrFrom4([4]byte{3, 3, 3, 3})},
+		},
+		"match_openvpn": {
+			connection: models.Connection{Type: vpn.OpenVPN, IP: ipA},
+			hostname:   "a",
+		},
+		"match_wireguard": {
+			connection: models.Connection{Type: vpn.Wireguard, IP: ipA},
+			hostname:   "b",
+		},
+		"vpn_type_mismatch": {
+			connection: models.Connection{Type: vpn.Wireguard, IP: ipB},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server := findConnectionServer(servers, testCase.connection)
+
+			if testCase.hostname == "" {
+				assert.Nil(t, server)
+				return
+			}
+			if assert.NotNil(t, server) {
+				assert.Equal(t, testCase.hostname, server.Hostname)
+			}
+		})
+	}
+}
+
+func Test_Loop_markConnectionFailed(t *testing.T) {
+	t.Parallel()
+
+	vpnSettings := settings.VPN{
+		FailedServerCooldown: ptrTo(time.Hour),
+		Failover: settings.VPNFailover{
+			Backups:   []settings.VPNBackup{{Provider: settings.Provider{Name: "backup"}}},
+			Threshold: ptrTo(uint(1)),
+		},
+	}
+	loop := &Loop{
+		state:   state.New(nil, vpnSettings),
+		logger:  log.New(log.SetWriters(io.Discard)),
+		breaker: NewConnectionBreaker(nil, noopInfoer{}),
+	}
+
+	// No connection set, for example if the tunnel setup failed.
+	failedOver := loop.markConnectionFailed("setup failed")
+	assert.True(t, failedOver)
+	assert.Empty(t, loop.GetFailedConnections())
+
+	connection := models.Connection{Hostname: "a", IP: netip.AddrFrom4([4]byte{1, 1, 1, 1})}
+	loop.setConnection(connection)
+	failedOver = loop.markConnectionFailed("tunnel exited")
+	assert.True(t, failedOver)
+	failed := loop.GetFailedConnections()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "tunnel exited", failed[0].Reason)
+}
@@ -0,0 +1,124 @@
+package vpn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/constants"
+)
+
+type failover struct {
+	// index is 0 for the primary VPN configuration,
+	// and i for the i-th backup VPN configuration.
+	index               int
+	consecutiveFailures uint
+	failedOverAt        time.Time
+}
+
+// GetActiveSettings returns the VPN settings currently in use, which
+// are the settings of the backup VPN configuration failed over to, if any.
+func (l *Loop) GetActiveSettings() (vpnSettings settings.VPN) {
+	vpnSettings = l.state.GetSettings()
+
+	l.failoverMutex.Lock()
+	index := l.failover.index
+	l.failoverMutex.Unlock()
+
+	return withBackup(vpnSettings, index)
+}
+
+func withBackup(vpnSettings settings.VPN, index int) settings.VPN {
+	if index == 0 || index > len(vpnSettings.Failover.Backups) {
+		return vpnSettings
+	}
+	backup := vpnSettings.Failover.Backups[index-1]
+	vpnSettings.Type = backup.Type
+	vpnSettings.Provider = backup.Provider
+	vpnSettings.OpenVPN = backup.OpenVPN
+	vpnSettings.Wireguard = backup.Wireguard
+	return vpnSettings
+}
+
+// MarkConnectionHealthy resets the count of consecutive
+// failures of the VPN configuration in use.
+func (l *Loop) MarkConnectionHealthy() {
+	l.failoverMutex.Lock()
+	defer l.failoverMutex.Unlock()
+	l.failover.consecutiveFailures = 0
+}
+
+// recordFailure counts a connection or health failure, and fails over
+// to the next VPN configuration if the failover threshold is reached.
+// It returns true if it failed over.
+func (l *Loop) recordFailure() (failedOver bool) {
+	failoverSettings := l.state.GetSettings().Failover
+	if !failoverSettings.Enabled() {
+		return false
+	}
+
+	l.failoverMutex.Lock()
+	defer l.failoverMutex.Unlock()
+	l.failover.consecutiveFailures++
+	if l.failover.consecutiveFailures < *failoverSettings.Threshold {
+		return false
+	}
+
+	configurations := len(failoverSettings.Backups) + 1
+	l.failover.index = (l.failover.index + 1) % configurations
+	l.failover.consecutiveFailures = 0
+	l.failover.failedOverAt = time.Now()
+	l.logger.Warn(fmt.Sprintf("%d consecutive failures, failing over to %s",
+		*failoverSettings.Threshold, failoverName(l.failover.index)))
+	return true
+}
+
+func failoverName(index int) string {
+	if index == 0 {
+		return "primary VPN configuration"
+	}
+	return fmt.Sprintf("backup VPN configuration %d", index)
+}
+
+// RunFailoverRetry switches back to the primary VPN configuration
+// once the retry primary period has elapsed since failing over to
+// a backup VPN configuration. The failover settings are read on each
+// check, so it follows settings changes made at runtime.
+func (l *Loop) RunFailoverRetry(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	const checkPeriod = time.Minute
+	ticker := time.NewTicker(checkPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		failoverSettings := l.state.GetSettings().Failover
+		retryPrimaryPeriod := *failoverSettings.RetryPrimaryPeriod
+		l.failoverMutex.Lock()
+		retry := failoverSettings.Enabled() && retryPrimaryPeriod > 0 &&
+			l.failover.index != 0 &&
+			time.Since(l.failover.failedOverAt) >= retryPrimaryPeriod
+		if retry {
+			l.failover.index = 0
+			l.failover.consecutiveFailures = 0
+		}
+		l.failoverMutex.Unlock()
+		if !retry {
+			continue
+		}
+
+		l.logger.Info("retrying " + failoverName(0))
+		if l.GetStatus() != constants.Running {
+			// the VPN loop uses the primary VPN configuration on its next try.
+			continue
+		}
+		_, _ = l.ApplyStatus(ctx, constants.Stopped)
+		_, _ = l.ApplyStatus(ctx, constants.Running)
+	}
+}
@@ -0,0 +1,52 @@
+package vpn
+
+import (
+	"io"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/vpn/state"
+	"github.com/qdm12/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Loop_failover(t *testing.T) {
+	t.Parallel()
+
+	vpnSettings := settings.VPN{
+		Type:     "openvpn",
+		Provider: settings.Provider{Name: "primary"},
+		Failover: settings.VPNFailover{
+			Backups: []settings.VPNBackup{
+				{Type: "wireguard", Provider: settings.Provider{Name: "backup1"}},
+				{Type: "openvpn", Provider: settings.Provider{Name: "backup2"}},
+			},
+			Threshold: ptrTo(uint(2)),
+		},
+	}
+	loop := &Loop{
+		state:  state.New(nil, vpnSettings),
+		logger: log.New(log.SetWriters(io.Discard)),
+	}
+
+	assert.Equal(t, "primary", loop.GetActiveSettings().Provider.Name)
+
+	assert.False(t, loop.recordFailure())
+	loop.MarkConnectionHealthy()
+	assert.False(t, loop.recordFailure())
+	assert.Equal(t, "primary", loop.GetActiveSettings().Provider.Name)
+
+	assert.True(t, loop.recordFailure())
+	activeSettings := loop.GetActiveSettings()
+	assert.Equal(t, "backup1", activeSettings.Provider.Name)
+	assert.Equal(t, "wireguard", activeSettings.Type)
+
+	assert.False(t, loop.recordFailure())
+	assert.True(t, loop.recordFailure())
+	assert.Equal(t, "backup2", loop.GetActiveSettings().Provider.Name)
+
+	// Wrap around to the primary VPN configuration
+	assert.False(t, loop.recordFailure())
+	assert.True(t, loop.recordFailure())
+	assert.Equal(t, "primary", loop.GetActiveSettings().Provider.Name)
+}
@@ -34,6 +34,21 @@ func (l *Loop) waitForError(ctx context.Context,
 }
 
 func (l *Loop) crashed(ctx context.Context, err error) {
+	if l.recordFailure() {
+		l.backoffTime = defaultBackoffTime
+	}
+	l.signalOrSetStatus(constants.Crashed)
+	l.logAndWait(ctx, err)
+}
+
+// connectionCrashed is like crashed but for a tunnel exiting right
+// after its connection was set, so the connection server is excluded
+// as for a tunnel exiting later on, and the connection is unset.
+func (l *Loop) connectionCrashed(ctx context.Context, err error) {
+	if l.markConnectionFailed(err.Error()) {
+		l.backoffTime = defaultBackoffTime
+	}
+	l.setConnection(models.Connection{})
 	l.signalOrSetStatus(constants.Crashed)
 	l.logAndWait(ctx, err)
 }
@@ -21,6 +21,7 @@ type Firewall interface {
 
 type Routing interface {
 	VPNLocalGatewayIP(vpnInterface string) (gateway netip.Addr, err error)
+	AssignedIP(interfaceName string, family int) (ip netip.Addr, err error)
 }
 
 type PortForward interface {
@@ -38,7 +39,8 @@ type Providers interface {
 }
 
 type Provider interface {
-	GetConnection(selection settings.ServerSelection, ipv6Supported bool) (connection models.Connection, err error)
+	GetConnection(ctx context.Context, selection settings.ServerSelection,
+		ipv6Supported bool) (connection models.Connection, err error)
 	OpenVPNConfig(connection models.Connection, settings settings.OpenVPN, ipv6Supported bool) (lines []string)
 	Name() string
 	FetchServers(ctx context.Context, minServers int) (
@@ -62,6 +64,7 @@ type NetLinker interface {
 	Ruler
 	Linker
 	IsWireguardSupported() (ok bool, err error)
+	LinkStatistics(name string) (statistics netlink.LinkStatistics, err error)
 }
 
 type Router interface {
@@ -87,11 +90,13 @@ type DNSLoop interface {
 	ApplyStatus(ctx context.Context, status models.LoopStatus) (
 		outcome string, err error)
 	GetSettings() (settings settings.DNS)
+	RunLeakTest(ctx context.Context, vpnPublicIP models.PublicIP)
 }
 
 type PublicIPLoop interface {
 	RunOnce(ctx context.Context) (err error)
 	ClearData() (err error)
+	GetData() (data models.PublicIP)
 }
 
 type CmdStarter interface {
@@ -99,3 +104,11 @@ type CmdStarter interface {
 		stdoutLines, stderrLines <-chan string,
 		waitError <-chan error, startErr error)
 }
+
+type HTTPProxy interface {
+	ActiveConnections() (count int)
+}
+
+type Infoer interface {
+	Info(message string)
+}
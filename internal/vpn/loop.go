@@ -1,7 +1,9 @@
 package vpn
 
 import (
+	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/qdm12/gluetun/internal/configuration/settings"
@@ -17,6 +19,8 @@ type Loop struct {
 	state         *state.State
 	providers     Providers
 	storage       Storage
+	breaker       *ConnectionBreaker
+	selector      *ConnectionSelector
 	// Fixed parameters
 	buildInfo     models.BuildInformation
 	versionInfo   bool
@@ -30,6 +34,7 @@ type Loop struct {
 	portForward PortForward
 	publicip    PublicIPLoop
 	dnsLooper   DNSLoop
+	httpProxy   HTTPProxy
 	// Other objects
 	starter CmdStarter // for OpenVPN
 	logger  log.LoggerInterface
@@ -40,8 +45,25 @@ type Loop struct {
 	start       <-chan struct{}
 	running     chan<- models.LoopStatus
 	userTrigger bool
+	stickyRead  bool
 	// Internal constant values
 	backoffTime time.Duration
+	// Current connection
+	connection      models.Connection
+	connectionMutex sync.RWMutex
+	// Failover state
+	failover      failover
+	failoverMutex sync.Mutex
+	// Traffic statistics
+	traffic traffic
+	// Up and down commands template variables values,
+	// set when the tunnel goes up.
+	commandVariables *commandVariables
+	// cancelUpCommand cancels the up command running, and is nil
+	// if no up command is running.
+	cancelUpCommand context.CancelFunc
+	upCommandDone   <-chan struct{}
+	commandMutex    sync.Mutex
 }
 
 const (
@@ -49,10 +71,11 @@ const (
 )
 
 func NewLoop(vpnSettings settings.VPN, ipv6Supported bool, vpnInputPorts []uint16,
-	providers Providers, storage Storage, openvpnConf OpenVPN,
+	providers Providers, storage Storage, breaker *ConnectionBreaker,
+	selector *ConnectionSelector, openvpnConf OpenVPN,
 	netLinker NetLinker, fw Firewall, routing Routing,
 	portForward PortForward, starter CmdStarter,
-	publicip PublicIPLoop, dnsLooper DNSLoop,
+	publicip PublicIPLoop, dnsLooper DNSLoop, httpProxy HTTPProxy,
 	logger log.LoggerInterface, client *http.Client,
 	buildInfo models.BuildInformation, versionInfo bool,
 ) *Loop {
@@ -69,6 +92,8 @@ func NewLoop(vpnSettings settings.VPN, ipv6Supported bool, vpnInputPorts []uint1
 		state:         state,
 		providers:     providers,
 		storage:       storage,
+		breaker:       breaker,
+		selector:      selector,
 		buildInfo:     buildInfo,
 		versionInfo:   versionInfo,
 		ipv6Supported: ipv6Supported,
@@ -80,6 +105,7 @@ func NewLoop(vpnSettings settings.VPN, ipv6Supported bool, vpnInputPorts []uint1
 		portForward:   portForward,
 		publicip:      publicip,
 		dnsLooper:     dnsLooper,
+		httpProxy:     httpProxy,
 		starter:       starter,
 		logger:        logger,
 		client:        client,
@@ -89,5 +115,8 @@ func NewLoop(vpnSettings settings.VPN, ipv6Supported bool, vpnInputPorts []uint1
 		stopped:       stopped,
 		userTrigger:   true,
 		backoffTime:   defaultBackoffTime,
+		traffic: traffic{
+			samplingPeriod: defaultTrafficSamplingPeriod,
+		},
 	}
 }
@@ -0,0 +1,97 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/constants"
+	"github.com/qdm12/gluetun/internal/cron"
+)
+
+// RunRotation reconnects the VPN to another server matching the
+// server selection, at the interval or on the cron schedule of the
+// rotation settings. It returns right away if rotation is disabled.
+func (l *Loop) RunRotation(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		rotationSettings := l.state.GetSettings().Rotation
+		if !rotationSettings.Enabled() {
+			return
+		}
+
+		wait, err := timeUntilRotation(rotationSettings, time.Now())
+		if err != nil {
+			l.logger.Error("server rotation: " + err.Error())
+			return
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		l.rotate(ctx, rotationSettings)
+	}
+}
+
+var ErrNoNextRotation = errors.New("no next rotation time found for cron expression")
+
+func timeUntilRotation(rotationSettings settings.VPNRotation, now time.Time) (
+	wait time.Duration, err error,
+) {
+	if *rotationSettings.Interval > 0 {
+		return *rotationSettings.Interval, nil
+	}
+
+	schedule, err := cron.Parse(*rotationSettings.Cron)
+	if err != nil {
+		return 0, fmt.Errorf("parsing cron expression: %w", err)
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return 0, fmt.Errorf("%w: %s", ErrNoNextRotation, *rotationSettings.Cron)
+	}
+	return next.Sub(now), nil
+}
+
+// rotate restarts the VPN loop, excluding the server currently
+// connected from the server selection. The firewall keeps blocking
+// traffic outside the VPN connection until the new connection is set.
+func (l *Loop) rotate(ctx context.Context, rotationSettings settings.VPNRotation) {
+	if l.GetStatus() != constants.Running {
+		l.logger.Info("skipping server rotation: VPN is not running")
+		return
+	}
+
+	if *rotationSettings.OnlyWhenIdle && l.httpProxy != nil {
+		activeConnections := l.httpProxy.ActiveConnections()
+		if activeConnections > 0 {
+			l.logger.Info(fmt.Sprintf("skipping server rotation: HTTP proxy has %d active connection(s)",
+				activeConnections))
+			return
+		}
+	}
+
+	connection := l.GetConnection()
+	if l.selector != nil && connection.IP.IsValid() {
+		l.selector.rotateFrom(connection)
+	}
+	l.logger.Info("rotating away from server " + connectionName(connection))
+
+	_, err := l.ApplyStatus(ctx, constants.Stopped)
+	if err != nil {
+		l.logger.Error("server rotation: stopping VPN: " + err.Error())
+		return
+	}
+	_, err = l.ApplyStatus(ctx, constants.Running)
+	if err != nil {
+		l.logger.Error("server rotation: starting VPN: " + err.Error())
+	}
+}
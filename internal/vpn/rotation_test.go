@@ -0,0 +1,58 @@
+package vpn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_timeUntilRotation(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		interval   time.Duration
+		cron       string
+		wait       time.Duration
+		errWrapped error
+		errMessage string
+	}{
+		"interval": {
+			interval: time.Hour,
+			wait:     time.Hour,
+		},
+		"cron": {
+			cron: "0 4 * * *",
+			wait: 17*time.Hour + 30*time.Minute,
+		},
+		"cron without next time": {
+			cron:       "0 0 31 2 *",
+			errWrapped: ErrNoNextRotation,
+			errMessage: "no next rotation time found for cron expression: 0 0 31 2 *",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rotationSettings := settings.VPNRotation{
+				Interval: &testCase.interval,
+				Cron:     &testCase.cron,
+			}
+
+			wait, err := timeUntilRotation(rotationSettings, now)
+
+			assert.Equal(t, testCase.wait, wait)
+			assert.ErrorIs(t, err, testCase.errWrapped)
+			if testCase.errWrapped != nil {
+				require.Error(t, err)
+				assert.EqualError(t, err, testCase.errMessage)
+			}
+		})
+	}
+}
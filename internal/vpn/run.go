@@ -5,6 +5,7 @@ import (
 
 	"github.com/qdm12/gluetun/internal/constants"
 	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gluetun/internal/models"
 	"github.com/qdm12/log"
 )
 
@@ -18,7 +19,11 @@ func (l *Loop) Run(ctx context.Context, done chan<- struct{}) {
 	}
 
 	for ctx.Err() == nil {
-		settings := l.state.GetSettings()
+		settings := l.GetActiveSettings()
+		if !l.stickyRead {
+			l.stickyRead = true
+			l.preferStickyConnection(settings)
+		}
 
 		providerConf := l.providers.Get(settings.Provider.Name)
 
@@ -28,26 +33,28 @@ func (l *Loop) Run(ctx context.Context, done chan<- struct{}) {
 		var vpnRunner interface {
 			Run(ctx context.Context, waitError chan<- error, tunnelReady chan<- struct{})
 		}
-		var serverName, vpnInterface string
-		var canPortForward bool
+		var connection models.Connection
+		var vpnInterface string
 		var err error
 		subLogger := l.logger.New(log.SetComponent(settings.Type))
 		if settings.Type == vpn.OpenVPN {
 			vpnInterface = settings.OpenVPN.Interface
-			vpnRunner, serverName, canPortForward, err = setupOpenVPN(ctx, l.fw,
+			vpnRunner, connection, err = setupOpenVPN(ctx, l.fw,
 				l.openvpnConf, providerConf, settings, l.ipv6Supported, l.starter, subLogger)
 		} else { // Wireguard
 			vpnInterface = settings.Wireguard.Interface
-			vpnRunner, serverName, canPortForward, err = setupWireguard(ctx, l.netLinker, l.fw,
+			vpnRunner, connection, err = setupWireguard(ctx, l.netLinker, l.fw,
 				providerConf, settings, l.ipv6Supported, subLogger)
 		}
 		if err != nil {
 			l.crashed(ctx, err)
 			continue
 		}
+		l.setConnection(connection)
 		tunnelUpData := tunnelUpData{
-			serverName:     serverName,
-			canPortForward: canPortForward,
+			vpnType:        settings.Type,
+			serverName:     connection.ServerName,
+			canPortForward: connection.PortForward,
 			portForwarder:  portForwarder,
 			vpnIntf:        vpnInterface,
 			username:       settings.Provider.PortForwarding.Username,
@@ -62,7 +69,7 @@ func (l *Loop) Run(ctx context.Context, done chan<- struct{}) {
 
 		if err := l.waitForError(ctx, waitError); err != nil {
 			openvpnCancel()
-			l.crashed(ctx, err)
+			l.connectionCrashed(ctx, err)
 			continue
 		}
 
@@ -96,6 +103,7 @@ func (l *Loop) Run(ctx context.Context, done chan<- struct{}) {
 			case err := <-waitError: // unexpected error
 				l.statusManager.Lock() // prevent SetStatus from running in parallel
 
+				l.MarkConnectionFailed(err.Error())
 				l.cleanup()
 				openvpnCancel()
 				l.statusManager.SetStatus(constants.Crashed)
@@ -0,0 +1,397 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/ping"
+	"github.com/qdm12/gluetun/internal/provider/utils"
+)
+
+type ProbeFirewall interface {
+	SetLatencyProbes(ctx context.Context, connections []models.Connection) error
+}
+
+type SelectorLogger interface {
+	Debug(message string)
+	Info(message string)
+}
+
+// ConnectionSelector picks connections according to the server selection
+// strategy, keeping the state needed across connection picks. It wraps
+// the storage given to the providers, and is given to the providers both
+// as their storage and as their strategy picker.
+type ConnectionSelector struct {
+	storage    Storage
+	firewall   ProbeFirewall
+	logger     SelectorLogger
+	randSource rand.Source
+	timeNow    func() time.Time
+	probe      func(ctx context.Context, connection models.Connection) (
+		latency time.Duration, err error)
+
+	mutex           sync.Mutex
+	probeMutex      sync.Mutex
+	roundRobinIndex int
+	working         models.Connection
+	rotatedFrom     models.Connection
+	preferred       models.Connection
+	latencies       map[latencyKey]latencyResult
+}
+
+var _ utils.StrategyPicker = (*ConnectionSelector)(nil)
+
+type latencyKey struct {
+	ip       netip.Addr
+	protocol string
+	port     uint16
+}
+
+type latencyResult struct {
+	latency time.Duration
+	err     error
+	time    time.Time
+}
+
+// NewConnectionSelector creates a connection selector wrapping the storage
+// given. It should be given to the providers both as their storage and
+// as their strategy picker.
+func NewConnectionSelector(storage Storage, firewall ProbeFirewall,
+	logger SelectorLogger,
+) *ConnectionSelector {
+	return &ConnectionSelector{
+		storage:    storage,
+		firewall:   firewall,
+		logger:     logger,
+		randSource: rand.NewSource(time.Now().UnixNano()),
+		timeNow:    time.Now,
+		probe:      probeLatency,
+		latencies:  make(map[latencyKey]latencyResult),
+	}
+}
+
+// FilterServers returns the servers from the wrapped storage,
+// excluding the server rotated away from, unless it is the only
+// server matching the selection.
+func (s *ConnectionSelector) FilterServers(provider string, selection settings.ServerSelection) (
+	servers []models.Server, err error,
+) {
+	servers, err = s.storage.FilterServers(provider, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	rotatedFrom := s.rotatedFrom
+	s.mutex.Unlock()
+	if !rotatedFrom.IP.IsValid() {
+		return servers, nil
+	}
+
+	filtered := make([]models.Server, 0, len(servers))
+	for _, server := range servers {
+		if !slices.Contains(server.IPs, rotatedFrom.IP) {
+			filtered = append(filtered, server)
+		}
+	}
+	if len(filtered) == 0 {
+		return servers, nil
+	}
+	return filtered, nil
+}
+
+var ErrSelectionStrategyUnknown = errors.New("selection strategy is unknown")
+
+// PickConnection picks a connection from the connections given
+// according to the selection strategy, unless the preferred
+// connection is one of the connections given. The context is
+// used to cancel latency probes for the lowest latency strategy.
+func (s *ConnectionSelector) PickConnection(ctx context.Context,
+	connections []models.Connection, selection settings.ServerSelection,
+) (connection models.Connection, err error) {
+	connection, ok := s.pickPreferred(connections)
+	if ok {
+		return connection, nil
+	}
+
+	switch selection.Strategy {
+	case "", settings.SelectionStrategyRandom, settings.SelectionStrategyLeastLoaded:
+		// The least loaded servers are already selected by the provider.
+		return s.pickRandom(connections), nil
+	case settings.SelectionStrategyRoundRobin:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		connection = connections[s.roundRobinIndex%len(connections)]
+		s.roundRobinIndex++
+		return connection, nil
+	case settings.SelectionStrategySticky:
+		s.mutex.Lock()
+		working := s.working
+		s.mutex.Unlock()
+		for _, connection := range connections {
+			if connection.IP == working.IP {
+				return connection, nil
+			}
+		}
+		return s.pickRandom(connections), nil
+	case settings.SelectionStrategyLowestLatency:
+		return s.pickLowestLatency(ctx, connections, selection), nil
+	default:
+		return connection, fmt.Errorf("%w: %s", ErrSelectionStrategyUnknown, selection.Strategy)
+	}
+}
+
+// pickPreferred returns the preferred connection if it is set and
+// is one of the connections given, and clears the preferred connection.
+func (s *ConnectionSelector) pickPreferred(connections []models.Connection) (
+	connection models.Connection, ok bool,
+) {
+	s.mutex.Lock()
+	preferred := s.preferred
+	s.preferred = models.Connection{}
+	s.mutex.Unlock()
+
+	if !preferred.IP.IsValid() {
+		return models.Connection{}, false
+	}
+	for _, connection := range connections {
+		if connection.Equal(preferred) {
+			s.logger.Info("picked last working server " + connectionName(connection))
+			return connection, true
+		}
+	}
+	s.logger.Info("last working server " + connectionName(preferred) +
+		" no longer matches the server selection")
+	return models.Connection{}, false
+}
+
+func (s *ConnectionSelector) pickRandom(connections []models.Connection) models.Connection {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return connections[rand.New(s.randSource).Intn(len(connections))] //nolint:gosec
+}
+
+// setWorking records the connection given as working,
+// to be picked again with the sticky strategy.
+func (s *ConnectionSelector) setWorking(connection models.Connection) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.working = connection
+	s.rotatedFrom = models.Connection{}
+}
+
+// prefer sets the connection given to be picked on the next
+// connection pick, if it is one of the connections to pick from.
+func (s *ConnectionSelector) prefer(connection models.Connection) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.preferred = connection
+}
+
+// rotateFrom excludes the server of the connection given from the
+// servers returned, until a connection to another server is working.
+func (s *ConnectionSelector) rotateFrom(connection models.Connection) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rotatedFrom = connection
+}
+
+// pickLowestLatency probes the latency of a random sample of the connections
+// given, and returns the connection with the lowest latency. Latencies are
+// cached to avoid probing the same servers again on each reconnection.
+// If no latency can be measured, a random connection of the sample is returned.
+// The selector mutex is not held while probing, so the selector can still be
+// used while the probes run.
+func (s *ConnectionSelector) pickLowestLatency(ctx context.Context,
+	connections []models.Connection, selection settings.ServerSelection,
+) (connection models.Connection) {
+	sampleSize := min(int(selection.LatencySampleSize), len(connections)) //nolint:gosec
+	sample := make([]models.Connection, sampleSize)
+	keys := make([]latencyKey, sampleSize)
+	results := make(map[latencyKey]latencyResult, sampleSize)
+	var toProbe []models.Connection
+
+	s.mutex.Lock()
+	for i, index := range rand.New(s.randSource).Perm(len(connections))[:sampleSize] { //nolint:gosec
+		sample[i] = connections[index]
+	}
+	s.removeExpiredLatencies()
+	for i, connection := range sample {
+		probeConnection := makeProbeConnection(connection, selection.LatencyProbe)
+		keys[i] = makeLatencyKey(probeConnection)
+		result, cached := s.latencies[keys[i]]
+		if cached {
+			results[keys[i]] = result
+		} else {
+			toProbe = append(toProbe, probeConnection)
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(toProbe) > 0 {
+		probeResults := s.probeLatencies(ctx, toProbe)
+		for i, connection := range toProbe {
+			results[makeLatencyKey(connection)] = probeResults[i]
+		}
+		if ctx.Err() == nil {
+			// Do not cache probes failing because the context is canceled.
+			s.mutex.Lock()
+			for i, connection := range toProbe {
+				s.latencies[makeLatencyKey(connection)] = probeResults[i]
+			}
+			s.mutex.Unlock()
+		}
+	}
+
+	bestIndex := -1
+	var bestLatency time.Duration
+	for i, key := range keys {
+		result := results[key]
+		if result.err != nil {
+			continue
+		}
+		if bestIndex == -1 || result.latency < bestLatency {
+			bestIndex = i
+			bestLatency = result.latency
+		}
+	}
+
+	if bestIndex == -1 {
+		s.logger.Info(fmt.Sprintf("no latency measured for %d server connection(s), "+
+			"picking one randomly", len(sample)))
+		return s.pickRandom(sample)
+	}
+
+	connection = sample[bestIndex]
+	s.logger.Info(fmt.Sprintf("picked server %s with the lowest latency %s "+
+		"amongst %d server connection(s)", connectionName(connection),
+		bestLatency.Round(time.Millisecond), len(sample)))
+	return connection
+}
+
+func makeLatencyKey(probeConnection models.Connection) latencyKey {
+	return latencyKey{
+		ip:       probeConnection.IP,
+		protocol: probeConnection.Protocol,
+		port:     probeConnection.Port,
+	}
+}
+
+func makeProbeConnection(connection models.Connection, probe string) models.Connection {
+	probeConnection := models.Connection{
+		IP:       connection.IP,
+		Protocol: probe,
+	}
+	if probe == settings.LatencyProbeTCP {
+		probeConnection.Port = connection.Port
+	}
+	return probeConnection
+}
+
+// probeLatencies probes the latencies of the connections given in parallel,
+// allowing them through the firewall for the duration of the probing.
+// Probe runs are serialized since they share the firewall latency probes.
+func (s *ConnectionSelector) probeLatencies(ctx context.Context,
+	connections []models.Connection,
+) (results []latencyResult) {
+	s.probeMutex.Lock()
+	defer s.probeMutex.Unlock()
+
+	err := s.firewall.SetLatencyProbes(ctx, connections)
+	if err != nil {
+		s.logger.Info("cannot allow latency probes through the firewall: " + err.Error())
+	}
+	defer func() {
+		// Remove the probes even if the context is canceled.
+		err := s.firewall.SetLatencyProbes(context.WithoutCancel(ctx), nil)
+		if err != nil {
+			s.logger.Info("cannot remove latency probes from the firewall: " + err.Error())
+		}
+	}()
+
+	const probeTimeout = 2 * time.Second
+	results = make([]latencyResult, len(connections))
+	var waitGroup sync.WaitGroup
+	for i, connection := range connections {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			latency, err := s.probe(probeCtx, connection)
+			results[i] = latencyResult{
+				latency: latency,
+				err:     err,
+				time:    s.timeNow(),
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	for i, connection := range connections {
+		if results[i].err != nil {
+			s.logger.Debug(fmt.Sprintf("probing latency of %s: %s", connection.IP, results[i].err))
+		}
+	}
+	return results
+}
+
+func (s *ConnectionSelector) removeExpiredLatencies() {
+	const latencyCacheTTL = 10 * time.Minute
+	now := s.timeNow()
+	for key, result := range s.latencies {
+		if now.Sub(result.time) >= latencyCacheTTL {
+			delete(s.latencies, key)
+		}
+	}
+}
+
+// probeLatency sends an ICMP echo request or dials a TCP connection
+// depending on the connection protocol, and returns the time taken
+// to get a reply. For TCP, a refused connection counts as a reply
+// since the server still answered.
+func probeLatency(ctx context.Context, connection models.Connection) (
+	latency time.Duration, err error,
+) {
+	start := time.Now()
+	if connection.Protocol == settings.LatencyProbeICMP {
+		err = ping.ICMP(ctx, connection.IP, "")
+		return time.Since(start), err
+	}
+
+	var dialer net.Dialer
+	address := net.JoinHostPort(connection.IP.String(), strconv.Itoa(int(connection.Port)))
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	latency = time.Since(start)
+	switch {
+	case err == nil:
+		_ = conn.Close()
+		return latency, nil
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return latency, nil
+	default:
+		return 0, err
+	}
+}
+
+func connectionName(connection models.Connection) string {
+	switch {
+	case connection.ServerName != "":
+		return connection.ServerName + " (" + connection.IP.String() + ")"
+	case connection.Hostname != "":
+		return connection.Hostname + " (" + connection.IP.String() + ")"
+	default:
+		return connection.IP.String()
+	}
+}
@@ -0,0 +1,231 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopProbeFirewall struct{}
+
+func (noopProbeFirewall) SetLatencyProbes(context.Context, []models.Connection) error {
+	return nil
+}
+
+type noopSelectorLogger struct{}
+
+func (noopSelectorLogger) Debug(string) {}
+func (noopSelectorLogger) Info(string)  {}
+
+func newTestSelector() *ConnectionSelector {
+	selector := NewConnectionSelector(nil, noopProbeFirewall{}, noopSelectorLogger{})
+	selector.randSource = rand.NewSource(0)
+	return selector
+}
+
+func Test_ConnectionSelector_PickConnection(t *testing.T) {
+	t.Parallel()
+
+	connections := []models.Connection{
+		{IP: netip.AddrFrom4([4]byte{1, 1, 1, 1})},
+		{IP: netip.AddrFrom4([4]byte{2, 2, 2, 2})},
+		{IP: netip.AddrFrom4([4]byte{3, 3, 3, 3})},
+	}
+
+	t.Run("random", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+
+		for _, strategy := range []string{"", settings.SelectionStrategyRandom,
+			settings.SelectionStrategyLeastLoaded} {
+			selection := settings.ServerSelection{Strategy: strategy}
+			connection, err := selector.PickConnection(context.Background(), connections, selection)
+			require.NoError(t, err)
+			assert.Contains(t, connections, connection)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+		selection := settings.ServerSelection{Strategy: "unknown"}
+
+		_, err := selector.PickConnection(context.Background(), connections, selection)
+		assert.ErrorIs(t, err, ErrSelectionStrategyUnknown)
+	})
+
+	t.Run("round-robin", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+		selection := settings.ServerSelection{Strategy: settings.SelectionStrategyRoundRobin}
+
+		for i := range 2 * len(connections) {
+			connection, err := selector.PickConnection(context.Background(), connections, selection)
+			require.NoError(t, err)
+			assert.Equal(t, connections[i%len(connections)], connection)
+		}
+	})
+
+	t.Run("sticky", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+		selection := settings.ServerSelection{Strategy: settings.SelectionStrategySticky}
+
+		selector.setWorking(connections[1])
+		for range 3 {
+			connection, err := selector.PickConnection(context.Background(), connections, selection)
+			require.NoError(t, err)
+			assert.Equal(t, connections[1], connection)
+		}
+	})
+
+	t.Run("preferred", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+		selection := settings.ServerSelection{Strategy: settings.SelectionStrategyRoundRobin}
+
+		selector.prefer(connections[2])
+		connection, err := selector.PickConnection(context.Background(), connections, selection)
+		require.NoError(t, err)
+		assert.Equal(t, connections[2], connection)
+
+		// Preference is used once only
+		connection, err = selector.PickConnection(context.Background(), connections, selection)
+		require.NoError(t, err)
+		assert.Equal(t, connections[0], connection)
+
+		// Preferred connection no longer in the connections
+		selector.prefer(models.Connection{IP: netip.AddrFrom4([4]byte{4, 4, 4, 4})})
+		connection, err = selector.PickConnection(context.Background(), connections, selection)
+		require.NoError(t, err)
+		assert.Equal(t, connections[1], connection)
+	})
+
+	t.Run("lowest-latency", func(t *testing.T) {
+		t.Parallel()
+		selector := newTestSelector()
+		var probes atomic.Int32
+		selector.probe = func(_ context.Context, connection models.Connection) (
+			latency time.Duration, err error,
+		) {
+			probes.Add(1)
+			switch connection.IP {
+			case connections[0].IP:
+				return 3 * time.Millisecond, nil
+			case connections[1].IP:
+				return 0, errors.New("timeout")
+			default:
+				return time.Millisecond, nil
+			}
+		}
+		selection := settings.ServerSelection{
+			Strategy:          settings.SelectionStrategyLowestLatency,
+			LatencyProbe:      settings.LatencyProbeICMP,
+			LatencySampleSize: 10,
+		}
+
+		connection, err := selector.PickConnection(context.Background(), connections, selection)
+		require.NoError(t, err)
+		assert.Equal(t, connections[2], connection)
+		assert.Equal(t, int32(len(connections)), probes.Load())
+
+		// Latencies are cached
+		connection, err = selector.PickConnection(context.Background(), connections, selection)
+		require.NoError(t, err)
+		assert.Equal(t, connections[2], connection)
+		assert.Equal(t, int32(len(connections)), probes.Load())
+	})
+}
+
+func Test_ConnectionSelector_PickConnection_probing(t *testing.T) {
+	t.Parallel()
+
+	connections := []models.Connection{
+		{IP: netip.AddrFrom4([4]byte{1, 1, 1, 1})},
+		{IP: netip.AddrFrom4([4]byte{2, 2, 2, 2})},
+	}
+	selection := settings.ServerSelection{
+		Strategy:          settings.SelectionStrategyLowestLatency,
+		LatencyProbe:      settings.LatencyProbeICMP,
+		LatencySampleSize: 10,
+	}
+
+	selector := newTestSelector()
+	probing := make(chan struct{}, len(connections))
+	var probes atomic.Int32
+	selector.probe = func(ctx context.Context, _ models.Connection) (
+		latency time.Duration, err error,
+	) {
+		probes.Add(1)
+		probing <- struct{}{}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	picked := make(chan models.Connection)
+	go func() {
+		connection, err := selector.PickConnection(ctx, connections, selection)
+		assert.NoError(t, err)
+		picked <- connection
+	}()
+
+	<-probing
+	// The selector is not locked while probing.
+	selector.setWorking(connections[0])
+	cancel()
+	connection := <-picked
+	assert.Contains(t, connections, connection)
+
+	// Probes canceled are not cached.
+	selector.probe = func(context.Context, models.Connection) (time.Duration, error) {
+		probes.Add(1)
+		return time.Millisecond, nil
+	}
+	_, err := selector.PickConnection(context.Background(), connections, selection)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2*len(connections)), probes.Load())
+}
+
+func Test_ConnectionSelector_FilterServers(t *testing.T) {
+	t.Parallel()
+
+	serverA := models.Server{Hostname: "a", IPs: []netip.Addr{netip.AddrFrom4([4]byte{1, 1, 1, 1})}}
+	serverB := models.Server{Hostname: "b", IPs: []netip.Addr{netip.AddrFrom4([4]byte{2, 2, 2, 2})}}
+	servers := []models.Server{serverA, serverB}
+	selector := newTestSelector()
+	selector.storage = storageFunc(func(string, settings.ServerSelection) ([]models.Server, error) {
+		return servers, nil
+	})
+
+	filtered, err := selector.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA, serverB}, filtered)
+
+	selector.rotateFrom(models.Connection{IP: serverA.IPs[0]})
+	filtered, err = selector.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverB}, filtered)
+
+	// Only the server rotated away from matches: keep it
+	servers = []models.Server{serverA}
+	filtered, err = selector.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA}, filtered)
+
+	// Connection to another server is working
+	servers = []models.Server{serverA, serverB}
+	selector.setWorking(models.Connection{IP: serverB.IPs[0]})
+	filtered, err = selector.FilterServers("provider", settings.ServerSelection{})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Server{serverA, serverB}, filtered)
+}
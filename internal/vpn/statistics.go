@@ -0,0 +1,223 @@
+package vpn
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/constants/vpn"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/netlink"
+	"golang.zx2c4.com/wireguard/wgctrl"
+)
+
+// traffic tracks the traffic counters of the VPN interface,
+// for the current connection and since the program started.
+type traffic struct {
+	mutex          sync.Mutex
+	vpnType        string
+	interfaceName  string
+	connectedSince time.Time
+	// baseline is the interface counters when the connection started.
+	baseline models.TrafficCounters
+	// connection is the last known counters of the current connection.
+	connection models.TrafficCounters
+	// previous is the sum of the counters of previous connections.
+	previous models.TrafficCounters
+	// samplingPeriod is the period to sample the counters at while
+	// connected, so the counters are known even if the interface
+	// is removed before the connection is cleaned up.
+	samplingPeriod time.Duration
+	// cancelSampling stops the periodic sampling, and is nil
+	// if no sampling is running.
+	cancelSampling context.CancelFunc
+	samplingDone   <-chan struct{}
+}
+
+const defaultTrafficSamplingPeriod = 10 * time.Second
+
+// startTrafficStatistics records the counters of the VPN interface
+// given as the baseline of the connection which just went up, and
+// starts sampling the counters periodically until stopTrafficStatistics
+// is called or the context is canceled. If it is called again without
+// stopTrafficStatistics being called, for example on an OpenVPN soft
+// restart, the counters of the connection ending are added to the
+// counters of the previous connections.
+func (l *Loop) startTrafficStatistics(ctx context.Context, vpnType, interfaceName string) {
+	l.traffic.mutex.Lock()
+	defer l.traffic.mutex.Unlock()
+
+	if l.traffic.interfaceName != "" {
+		l.sampleTraffic()
+		l.traffic.previous = addCounters(l.traffic.previous, l.traffic.connection)
+	}
+
+	if l.traffic.cancelSampling == nil {
+		samplingCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		l.traffic.cancelSampling = cancel
+		l.traffic.samplingDone = done
+		go l.sampleTrafficPeriodically(samplingCtx, done)
+	}
+
+	l.traffic.vpnType = vpnType
+	l.traffic.interfaceName = interfaceName
+	l.traffic.connectedSince = time.Now()
+	l.traffic.connection = models.TrafficCounters{}
+	l.traffic.baseline = models.TrafficCounters{}
+	statistics, err := l.netLinker.LinkStatistics(interfaceName)
+	if err != nil {
+		l.logger.Debug("getting statistics of " + interfaceName + ": " + err.Error())
+		return
+	}
+	l.traffic.baseline = linkStatisticsToCounters(statistics)
+}
+
+// stopTrafficStatistics stops the periodic sampling and adds the
+// counters of the connection ending to the counters of the previous
+// connections.
+func (l *Loop) stopTrafficStatistics() {
+	l.traffic.mutex.Lock()
+	cancelSampling, samplingDone := l.traffic.cancelSampling, l.traffic.samplingDone
+	l.traffic.cancelSampling, l.traffic.samplingDone = nil, nil
+	l.traffic.mutex.Unlock()
+	if cancelSampling != nil {
+		cancelSampling()
+		<-samplingDone
+	}
+
+	l.traffic.mutex.Lock()
+	defer l.traffic.mutex.Unlock()
+
+	if l.traffic.interfaceName == "" {
+		return
+	}
+	l.sampleTraffic()
+	l.traffic.previous = addCounters(l.traffic.previous, l.traffic.connection)
+	l.traffic.interfaceName = ""
+	l.traffic.connectedSince = time.Time{}
+	l.traffic.connection = models.TrafficCounters{}
+}
+
+func (l *Loop) sampleTrafficPeriodically(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(l.traffic.samplingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.traffic.mutex.Lock()
+			if l.traffic.interfaceName != "" {
+				l.sampleTraffic()
+			}
+			l.traffic.mutex.Unlock()
+		}
+	}
+}
+
+// sampleTraffic updates the counters of the current connection.
+// It must be called with the traffic mutex locked.
+func (l *Loop) sampleTraffic() {
+	statistics, err := l.netLinker.LinkStatistics(l.traffic.interfaceName)
+	if err != nil {
+		// keep the last known counters, for example
+		// if the interface was already removed.
+		return
+	}
+	l.traffic.connection = countersSince(
+		linkStatisticsToCounters(statistics), l.traffic.baseline)
+}
+
+// GetTunnelStatistics returns the traffic statistics of the VPN tunnel.
+func (l *Loop) GetTunnelStatistics() (statistics models.TunnelStatistics) {
+	l.traffic.mutex.Lock()
+	defer l.traffic.mutex.Unlock()
+
+	if l.traffic.interfaceName == "" {
+		statistics.Process = l.traffic.previous
+		return statistics
+	}
+
+	l.sampleTraffic()
+	connectedSince := l.traffic.connectedSince
+	statistics.ConnectedSince = &connectedSince
+	statistics.UptimeSeconds = uint64(time.Since(connectedSince).Seconds())
+	statistics.Connection = l.traffic.connection
+	statistics.Process = addCounters(l.traffic.previous, l.traffic.connection)
+
+	if l.traffic.vpnType == vpn.Wireguard {
+		wireguardStatistics, err := getWireguardStatistics(l.traffic.interfaceName)
+		if err != nil {
+			l.logger.Debug("getting Wireguard statistics: " + err.Error())
+		} else {
+			statistics.Wireguard = &wireguardStatistics
+		}
+	}
+
+	return statistics
+}
+
+func getWireguardStatistics(interfaceName string) (
+	statistics models.WireguardStatistics, err error,
+) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return statistics, err
+	}
+	defer client.Close()
+
+	device, err := client.Device(interfaceName)
+	if err != nil {
+		return statistics, err
+	}
+
+	var lastHandshake time.Time
+	for _, peer := range device.Peers {
+		if peer.LastHandshakeTime.After(lastHandshake) {
+			lastHandshake = peer.LastHandshakeTime
+		}
+		statistics.RxBytes += uint64(peer.ReceiveBytes)  //nolint:gosec
+		statistics.TxBytes += uint64(peer.TransmitBytes) //nolint:gosec
+	}
+	if !lastHandshake.IsZero() {
+		statistics.LastHandshake = &lastHandshake
+	}
+	return statistics, nil
+}
+
+func linkStatisticsToCounters(statistics netlink.LinkStatistics) models.TrafficCounters {
+	return models.TrafficCounters{
+		RxBytes:   statistics.RxBytes,
+		TxBytes:   statistics.TxBytes,
+		RxPackets: statistics.RxPackets,
+		TxPackets: statistics.TxPackets,
+	}
+}
+
+// countersSince returns the counters difference between the current
+// counters and the baseline counters. If a current counter is lower
+// than its baseline counter, the interface was re-created and the
+// current counters are returned.
+func countersSince(current, baseline models.TrafficCounters) models.TrafficCounters {
+	if current.RxBytes < baseline.RxBytes || current.TxBytes < baseline.TxBytes ||
+		current.RxPackets < baseline.RxPackets || current.TxPackets < baseline.TxPackets {
+		return current
+	}
+	return models.TrafficCounters{
+		RxBytes:   current.RxBytes - baseline.RxBytes,
+		TxBytes:   current.TxBytes - baseline.TxBytes,
+		RxPackets: current.RxPackets - baseline.RxPackets,
+		TxPackets: current.TxPackets - baseline.TxPackets,
+	}
+}
+
+func addCounters(a, b models.TrafficCounters) models.TrafficCounters {
+	return models.TrafficCounters{
+		RxBytes:   a.RxBytes + b.RxBytes,
+		TxBytes:   a.TxBytes + b.TxBytes,
+		RxPackets: a.RxPackets + b.RxPackets,
+		TxPackets: a.TxPackets + b.TxPackets,
+	}
+}
@@ -0,0 +1,138 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/netlink"
+	"github.com/qdm12/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var errLinkNotFound = errors.New("link not found")
+
+type fakeNetLinker struct {
+	NetLinker
+	mutex      sync.Mutex
+	statistics netlink.LinkStatistics
+	removed    bool
+}
+
+func (f *fakeNetLinker) LinkStatistics(string) (statistics netlink.LinkStatistics, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.removed {
+		return netlink.LinkStatistics{}, errLinkNotFound
+	}
+	return f.statistics, nil
+}
+
+func (f *fakeNetLinker) set(statistics netlink.LinkStatistics, removed bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.statistics, f.removed = statistics, removed
+}
+
+func Test_Loop_trafficStatistics_interfaceRemoved(t *testing.T) {
+	t.Parallel()
+
+	netLinker := &fakeNetLinker{
+		statistics: netlink.LinkStatistics{RxBytes: 100, TxBytes: 50},
+	}
+	loop := &Loop{
+		netLinker: netLinker,
+		logger:    log.New(log.SetWriters(io.Discard)),
+		traffic: traffic{
+			samplingPeriod: time.Millisecond,
+		},
+	}
+
+	sampledRxBytes := func() uint64 {
+		loop.traffic.mutex.Lock()
+		defer loop.traffic.mutex.Unlock()
+		return loop.traffic.connection.RxBytes
+	}
+
+	loop.startTrafficStatistics(context.Background(), "openvpn", "tun0")
+	netLinker.set(netlink.LinkStatistics{RxBytes: 2100, TxBytes: 1050}, false)
+	assert.Eventually(t, func() bool {
+		return sampledRxBytes() == 2000
+	}, time.Second, time.Millisecond)
+
+	// The interface is removed before the connection is cleaned up,
+	// for example when the VPN process crashes.
+	netLinker.set(netlink.LinkStatistics{}, true)
+	loop.stopTrafficStatistics()
+
+	statistics := loop.GetTunnelStatistics()
+	assert.Equal(t, models.TrafficCounters{RxBytes: 2000, TxBytes: 1000}, statistics.Process)
+}
+
+func Test_Loop_trafficStatistics_restartedConnection(t *testing.T) {
+	t.Parallel()
+
+	netLinker := &fakeNetLinker{
+		statistics: netlink.LinkStatistics{RxBytes: 100, TxBytes: 50},
+	}
+	loop := &Loop{
+		netLinker: netLinker,
+		logger:    log.New(log.SetWriters(io.Discard)),
+		traffic: traffic{
+			samplingPeriod: time.Hour,
+		},
+	}
+
+	loop.startTrafficStatistics(context.Background(), "openvpn", "tun0")
+	netLinker.set(netlink.LinkStatistics{RxBytes: 2100, TxBytes: 1050}, false)
+
+	// The connection restarts on the same interface without
+	// being stopped, for example on an OpenVPN soft restart.
+	loop.startTrafficStatistics(context.Background(), "openvpn", "tun0")
+	netLinker.set(netlink.LinkStatistics{RxBytes: 2200, TxBytes: 1100}, false)
+
+	statistics := loop.GetTunnelStatistics()
+	assert.Equal(t, models.TrafficCounters{RxBytes: 100, TxBytes: 50}, statistics.Connection)
+	assert.Equal(t, models.TrafficCounters{RxBytes: 2100, TxBytes: 1050}, statistics.Process)
+
+	loop.stopTrafficStatistics()
+}
+
+func Test_countersSince(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		current  models.TrafficCounters
+		baseline models.TrafficCounters
+		expected models.TrafficCounters
+	}{
+		"zero baseline": {
+			current:  models.TrafficCounters{RxBytes: 10, TxBytes: 20, RxPackets: 1, TxPackets: 2},
+			expected: models.TrafficCounters{RxBytes: 10, TxBytes: 20, RxPackets: 1, TxPackets: 2},
+		},
+		"difference": {
+			current:  models.TrafficCounters{RxBytes: 100, TxBytes: 200, RxPackets: 10, TxPackets: 20},
+			baseline: models.TrafficCounters{RxBytes: 40, TxBytes: 50, RxPackets: 4, TxPackets: 5},
+			expected: models.TrafficCounters{RxBytes: 60, TxBytes: 150, RxPackets: 6, TxPackets: 15},
+		},
+		"interface re-created": {
+			current:  models.TrafficCounters{RxBytes: 5, TxBytes: 200, RxPackets: 1, TxPackets: 20},
+			baseline: models.TrafficCounters{RxBytes: 40, TxBytes: 50, RxPackets: 4, TxPackets: 5},
+			expected: models.TrafficCounters{RxBytes: 5, TxBytes: 200, RxPackets: 1, TxPackets: 20},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			counters := countersSince(testCase.current, testCase.baseline)
+
+			assert.Equal(t, testCase.expected, counters)
+		})
+	}
+}
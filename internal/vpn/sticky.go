@@ -0,0 +1,135 @@
+package vpn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+)
+
+// stickyConnection is the last working connection persisted to file,
+// together with the hash of the server selection it was picked with.
+type stickyConnection struct {
+	Connection    models.Connection `json:"connection"`
+	SelectionHash string            `json:"selection_hash"`
+}
+
+// selectionHash returns a hash of the VPN type, provider name and
+// server selection, to detect if the server selection changed
+// since the sticky connection was saved.
+func selectionHash(vpnSettings settings.VPN) (hash string, err error) {
+	data, err := json.Marshal(struct {
+		Type      string                   `json:"type"`
+		Provider  string                   `json:"provider"`
+		Selection settings.ServerSelection `json:"selection"`
+	}{
+		Type:      vpnSettings.Type,
+		Provider:  vpnSettings.Provider.Name,
+		Selection: vpnSettings.Provider.ServerSelection,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding server selection: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// preferStickyConnection reads the last working connection from file
+// and makes the selector prefer it on the next connection pick, if the
+// server selection did not change since it was saved.
+func (l *Loop) preferStickyConnection(vpnSettings settings.VPN) {
+	stickySettings := vpnSettings.StickyConnection
+	if !*stickySettings.Enabled || l.selector == nil {
+		return
+	}
+
+	connection, err := readStickyConnection(*stickySettings.Filepath, vpnSettings)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return
+	case err != nil:
+		l.logger.Warn("reading last working connection: " + err.Error())
+		return
+	case !connection.IP.IsValid():
+		l.logger.Info("server selection changed since the last working connection, " +
+			"not preferring it")
+		return
+	}
+
+	l.selector.prefer(connection)
+}
+
+func readStickyConnection(path string, vpnSettings settings.VPN) (
+	connection models.Connection, err error,
+) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return connection, err
+	}
+
+	var sticky stickyConnection
+	err = json.Unmarshal(data, &sticky)
+	if err != nil {
+		return connection, fmt.Errorf("decoding file: %w", err)
+	}
+
+	hash, err := selectionHash(vpnSettings)
+	if err != nil {
+		return connection, err
+	}
+	if sticky.SelectionHash != hash {
+		return connection, nil
+	}
+	return sticky.Connection, nil
+}
+
+// saveStickyConnection writes the connection given to file, if the
+// sticky connection is enabled.
+func (l *Loop) saveStickyConnection(connection models.Connection) {
+	vpnSettings := l.GetActiveSettings()
+	stickySettings := vpnSettings.StickyConnection
+	if !*stickySettings.Enabled {
+		return
+	}
+
+	err := writeStickyConnection(*stickySettings.Filepath, connection, vpnSettings)
+	if err != nil {
+		l.logger.Warn("saving last working connection: " + err.Error())
+	}
+}
+
+func writeStickyConnection(path string, connection models.Connection,
+	vpnSettings settings.VPN,
+) (err error) {
+	hash, err := selectionHash(vpnSettings)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(stickyConnection{
+		Connection:    connection,
+		SelectionHash: hash,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding connection: %w", err)
+	}
+
+	const dirPerms = os.FileMode(0o755)
+	err = os.MkdirAll(filepath.Dir(path), dirPerms)
+	if err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	const filePerms = os.FileMode(0o600)
+	err = os.WriteFile(path, data, filePerms)
+	if err != nil {
+		return fmt.Errorf("writing file: %w", err)
+	}
+	return nil
+}
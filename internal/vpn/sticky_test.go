@@ -0,0 +1,48 @@
+package vpn
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+
+	"github.com/qdm12/gluetun/internal/configuration/settings"
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stickyConnection_file(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "subdirectory", "last_connection.json")
+	vpnSettings := settings.VPN{
+		Type: "wireguard",
+		Provider: settings.Provider{
+			Name: "mullvad",
+			ServerSelection: settings.ServerSelection{
+				Countries: []string{"sweden"},
+			},
+		},
+	}
+	connection := models.Connection{
+		Type:     "wireguard",
+		IP:       netip.AddrFrom4([4]byte{1, 2, 3, 4}),
+		Port:     51820,
+		Protocol: "udp",
+		Hostname: "se-sto-wg-001",
+		PubKey:   "key",
+	}
+
+	err := writeStickyConnection(path, connection, vpnSettings)
+	require.NoError(t, err)
+
+	readConnection, err := readStickyConnection(path, vpnSettings)
+	require.NoError(t, err)
+	assert.Equal(t, connection, readConnection)
+
+	// Server selection changed
+	vpnSettings.Provider.ServerSelection.Countries = []string{"norway"}
+	readConnection, err = readStickyConnection(path, vpnSettings)
+	require.NoError(t, err)
+	assert.Equal(t, models.Connection{}, readConnection)
+}
@@ -9,6 +9,7 @@ import (
 )
 
 type tunnelUpData struct {
+	vpnType string
 	// Port forwarding
 	vpnIntf        string
 	serverName     string // used for PIA
@@ -21,6 +22,14 @@ type tunnelUpData struct {
 func (l *Loop) onTunnelUp(ctx context.Context, data tunnelUpData) {
 	l.client.CloseIdleConnections()
 
+	connection := l.GetConnection()
+	if l.selector != nil {
+		l.selector.setWorking(connection)
+	}
+	l.saveStickyConnection(connection)
+	l.startTrafficStatistics(ctx, data.vpnType, data.vpnIntf)
+	go l.prepareVerification(ctx)
+
 	for _, vpnPort := range l.vpnInputPorts {
 		err := l.fw.SetAllowedPort(ctx, vpnPort, data.vpnIntf)
 		if err != nil {
@@ -40,8 +49,12 @@ func (l *Loop) onTunnelUp(ctx context.Context, data tunnelUpData) {
 	err := l.publicip.RunOnce(ctx)
 	if err != nil {
 		l.logger.Error("getting public IP address information: " + err.Error())
+	} else {
+		go l.dnsLooper.RunLeakTest(ctx, l.publicip.GetData())
 	}
 
+	l.startUpCommand(ctx)
+
 	if l.versionInfo {
 		l.versionInfo = false // only get the version information once
 		message, err := version.GetMessage(ctx, l.buildInfo, l.client)
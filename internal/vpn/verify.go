@@ -0,0 +1,55 @@
+package vpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qdm12/gluetun/internal/models"
+	"github.com/qdm12/gluetun/internal/provider"
+)
+
+var ErrVPNNotConnected = errors.New("VPN is not connected")
+
+// prepareVerification fetches the data the current VPN provider needs
+// to verify the connection, so it is not fetched when verifying it.
+func (l *Loop) prepareVerification(ctx context.Context) {
+	providerName := l.GetActiveSettings().Provider.Name
+	preparer, ok := l.providers.Get(providerName).(provider.VerificationPreparer)
+	if !ok {
+		return
+	}
+
+	const timeout = time.Minute
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := preparer.PrepareVerification(ctx)
+	if err != nil {
+		l.logger.Warn("preparing connection verification with " +
+			providerName + ": " + err.Error())
+	}
+}
+
+// VerifyConnection asks the current VPN provider to verify the
+// traffic goes through its VPN. It returns an error wrapping
+// provider.ErrVerificationNotSupported if the provider cannot verify it.
+func (l *Loop) VerifyConnection(ctx context.Context) (
+	verification models.ConnectionVerification, err error,
+) {
+	providerName := l.GetActiveSettings().Provider.Name
+	verifier, ok := l.providers.Get(providerName).(provider.ConnectionVerifier)
+	if !ok {
+		return verification, fmt.Errorf("%w: %s", provider.ErrVerificationNotSupported, providerName)
+	}
+
+	if !l.GetConnection().IP.IsValid() {
+		return verification, fmt.Errorf("%w", ErrVPNNotConnected)
+	}
+
+	verification, err = verifier.VerifyConnection(ctx)
+	if err != nil {
+		return verification, fmt.Errorf("verifying connection with %s: %w", providerName, err)
+	}
+	return verification, nil
+}